@@ -0,0 +1,182 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"sportgether/internal/calendar"
+	"sportgether/internal/models"
+	"strings"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+const (
+	// Calendar apps keep polling the feed, so the token should live long enough. Users can rotate it any time.
+	calendarFeedTokenTTL = 10 * 365 * 24 * time.Hour
+	// calendarFeedLookback keeps the recent past events in the feed, as calendar apps remove the events which drop out
+	// of it, even the ones in the past.
+	calendarFeedLookback = 90 * 24 * time.Hour
+)
+
+func (app *Application) downloadEventCalendar(w http.ResponseWriter, r *http.Request) {
+	eventId, err := app.readParam("eventId", r)
+	if err != nil {
+		app.logError(err, r)
+		app.writeBadRequestResponse(w, r)
+		return
+	}
+
+	user, ok := app.GetUserContext(r)
+	if !ok {
+		app.writeInvalidAuthenticationErrorResponse(w, r)
+		return
+	}
+
+	detail, err := app.daos.GetEventById(*eventId, user.ID)
+	if err != nil {
+		app.logError(err, r)
+		app.writeBadRequestResponse(w, r)
+		return
+	}
+
+	if !app.canAccessEvent(w, r, detail) {
+		return
+	}
+
+	event, err := toCalendarEvent(detail.ID, detail.EventName, detail.Description, detail.Destination, detail.StartTime, detail.EndTime, detail.IsCancelled(), detail.Version)
+	if err != nil {
+		app.logError(err, r)
+		app.writeInternalServerErrorResponse(w, r)
+		return
+	}
+
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"sportgether-event-%d.ics\"", detail.ID))
+	app.writeCalendar(w, r, detail.EventName, []calendar.Event{*event})
+}
+
+func (app *Application) createCalendarFeed(w http.ResponseWriter, r *http.Request) {
+	user, ok := app.GetUserContext(r)
+	if !ok {
+		app.writeInvalidAuthenticationErrorResponse(w, r)
+		return
+	}
+
+	// Only one feed url is valid at a time, so creating a new one revokes the old one.
+	err := app.daos.DeleteAllForUser(models.CalendarFeedScope, user.ID)
+	if err != nil {
+		app.logError(err, r)
+		app.writeInternalServerErrorResponse(w, r)
+		return
+	}
+
+	token, err := app.daos.TokenDao.New(user.ID, calendarFeedTokenTTL, models.CalendarFeedScope)
+	if err != nil {
+		app.logError(err, r)
+		app.writeInternalServerErrorResponse(w, r)
+		return
+	}
+
+	err = app.writeResponse(w, responseData{"calendarFeedPath": fmt.Sprintf("/v1/calendar/%s.ics", token.PlainText)}, http.StatusCreated, nil)
+	if err != nil {
+		app.logError(err, r)
+		app.writeInternalServerErrorResponse(w, r)
+	}
+}
+
+func (app *Application) deleteCalendarFeed(w http.ResponseWriter, r *http.Request) {
+	user, ok := app.GetUserContext(r)
+	if !ok {
+		app.writeInvalidAuthenticationErrorResponse(w, r)
+		return
+	}
+
+	err := app.daos.DeleteAllForUser(models.CalendarFeedScope, user.ID)
+	if err != nil {
+		app.logError(err, r)
+		app.writeInternalServerErrorResponse(w, r)
+	}
+}
+
+// getCalendarFeed is polled by calendar apps, which cannot send the bearer token, so the secret token in the url
+// is used to identify the user instead.
+func (app *Application) getCalendarFeed(w http.ResponseWriter, r *http.Request) {
+	params := httprouter.ParamsFromContext(r.Context())
+	token := strings.TrimSuffix(params.ByName("token"), ".ics")
+	if len(token) != 26 {
+		app.notFound(w, r)
+		return
+	}
+
+	user, err := app.daos.GetUserByToken(models.CalendarFeedScope, token)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			app.notFound(w, r)
+		default:
+			app.logError(err, r)
+			app.writeInternalServerErrorResponse(w, r)
+		}
+		return
+	}
+
+	userEvents, err := app.daos.GetUserEvents(user.ID, time.Now().Add(-calendarFeedLookback))
+	if err != nil {
+		app.logError(err, r)
+		app.writeInternalServerErrorResponse(w, r)
+		return
+	}
+
+	events := make([]calendar.Event, 0, len(userEvents.UserEvents))
+	for _, userEvent := range userEvents.UserEvents {
+		// Club events which the user has not joined are not in the calendar.
+		if !userEvent.IsJoined {
+			continue
+		}
+		event, err := toCalendarEvent(userEvent.EventId, userEvent.EventName, "", userEvent.Destination, userEvent.StartTime, userEvent.EndTime, userEvent.Deleted, userEvent.Version)
+		if err != nil {
+			app.logError(err, r)
+			app.writeInternalServerErrorResponse(w, r)
+			return
+		}
+		events = append(events, *event)
+	}
+
+	app.writeCalendar(w, r, "SportGether", events)
+}
+
+func (app *Application) writeCalendar(w http.ResponseWriter, r *http.Request, calendarName string, events []calendar.Event) {
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+
+	err := calendar.Write(w, calendarName, events, time.Now())
+	if err != nil {
+		app.logError(err, r)
+	}
+}
+
+func toCalendarEvent(eventId int64, eventName string, description string, destination string, startTime string, endTime string, cancelled bool, version int) (*calendar.Event, error) {
+	start, err := time.Parse(time.RFC3339Nano, startTime)
+	if err != nil {
+		return nil, err
+	}
+
+	end, err := time.Parse(time.RFC3339Nano, endTime)
+	if err != nil {
+		return nil, err
+	}
+
+	return &calendar.Event{
+		UID:         calendar.EventUID(eventId),
+		Sequence:    version,
+		Summary:     eventName,
+		Description: description,
+		Location:    destination,
+		Start:       start,
+		End:         end,
+		Cancelled:   cancelled,
+	}, nil
+}
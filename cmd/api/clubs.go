@@ -0,0 +1,612 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"sportgether/constants"
+	"sportgether/internal/models"
+	"sportgether/tools"
+	"strings"
+)
+
+type clubInput struct {
+	ClubName    string  `json:"clubName"`
+	Description *string `json:"description"`
+	IconUrl     *string `json:"iconUrl"`
+	JoinPolicy  string  `json:"joinPolicy"`
+}
+
+func (input *clubInput) validate(validator *tools.RequestValidator) {
+	input.ClubName = strings.TrimSpace(input.ClubName)
+	if input.JoinPolicy == "" {
+		input.JoinPolicy = models.ClubRequestJoin
+	}
+	validator.Check(input.ClubName != "", "clubName", "must be provided")
+	validator.Check(len(input.ClubName) <= 100, "clubName", "must not be more than 100 bytes long")
+	validator.Check(input.JoinPolicy == models.ClubOpenJoin || input.JoinPolicy == models.ClubRequestJoin, "joinPolicy", "must be OPEN or REQUEST")
+}
+
+func (app *Application) createClub(w http.ResponseWriter, r *http.Request) {
+	input := clubInput{}
+	err := app.readRequest(r, &input)
+	if err != nil {
+		app.logError(err, r)
+		app.writeBadRequestResponse(w, r)
+		return
+	}
+
+	validator := tools.NewRequestValidator()
+	input.validate(validator)
+	if !validator.Valid() {
+		app.writeError(w, r, http.StatusBadRequest, http.StatusBadRequest, validator.Errors)
+		return
+	}
+
+	user, ok := app.GetUserContext(r)
+	if !ok {
+		app.writeInvalidAuthenticationErrorResponse(w, r)
+		return
+	}
+
+	club := &models.Club{
+		ClubName:    input.ClubName,
+		Description: input.Description,
+		IconUrl:     input.IconUrl,
+		JoinPolicy:  input.JoinPolicy,
+		CreatedBy:   user.ID,
+	}
+	err = app.daos.WithTransaction(func(tx *sql.Tx) error {
+		return app.daos.CreateClub(club, tx)
+	})
+	if err != nil {
+		app.logError(err, r)
+		app.writeInternalServerErrorResponse(w, r)
+		return
+	}
+
+	err = app.writeResponse(w, responseData{"club": club}, http.StatusCreated, nil)
+	if err != nil {
+		app.logError(err, r)
+		app.writeInternalServerErrorResponse(w, r)
+	}
+}
+
+// getClubDetail is the club profile page, which is open to everyone.
+func (app *Application) getClubDetail(w http.ResponseWriter, r *http.Request) {
+	clubId, err := app.readParam("clubId", r)
+	if err != nil {
+		app.logError(err, r)
+		app.writeBadRequestResponse(w, r)
+		return
+	}
+
+	user, ok := app.GetUserContext(r)
+	if !ok {
+		app.writeInvalidAuthenticationErrorResponse(w, r)
+		return
+	}
+
+	detail, err := app.daos.GetClubDetail(*clubId, user.ID)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			app.notFound(w, r)
+		default:
+			app.logError(err, r)
+			app.writeInternalServerErrorResponse(w, r)
+		}
+		return
+	}
+
+	err = app.writeResponse(w, responseData{"club": detail}, http.StatusOK, nil)
+	if err != nil {
+		app.logError(err, r)
+		app.writeInternalServerErrorResponse(w, r)
+	}
+}
+
+func (app *Application) getUserClubs(w http.ResponseWriter, r *http.Request) {
+	user, ok := app.GetUserContext(r)
+	if !ok {
+		app.writeInvalidAuthenticationErrorResponse(w, r)
+		return
+	}
+
+	clubs, err := app.daos.GetUserClubs(user.ID)
+	if err != nil {
+		app.logError(err, r)
+		app.writeInternalServerErrorResponse(w, r)
+		return
+	}
+
+	err = app.writeResponse(w, responseData{"clubs": clubs}, http.StatusOK, nil)
+	if err != nil {
+		app.logError(err, r)
+		app.writeInternalServerErrorResponse(w, r)
+	}
+}
+
+func (app *Application) updateClub(w http.ResponseWriter, r *http.Request) {
+	input := clubInput{}
+	err := app.readRequest(r, &input)
+	if err != nil {
+		app.logError(err, r)
+		app.writeBadRequestResponse(w, r)
+		return
+	}
+
+	validator := tools.NewRequestValidator()
+	input.validate(validator)
+	if !validator.Valid() {
+		app.writeError(w, r, http.StatusBadRequest, http.StatusBadRequest, validator.Errors)
+		return
+	}
+
+	clubId, _, ok := app.requireClubRole(w, r, true)
+	if !ok {
+		return
+	}
+
+	club := &models.Club{
+		ID:          clubId,
+		ClubName:    input.ClubName,
+		Description: input.Description,
+		IconUrl:     input.IconUrl,
+		JoinPolicy:  input.JoinPolicy,
+	}
+	err = app.daos.UpdateClub(club)
+	if err != nil {
+		app.logError(err, r)
+		app.writeInternalServerErrorResponse(w, r)
+		return
+	}
+
+	err = app.writeResponse(w, responseData{"version": club.Version}, http.StatusOK, nil)
+	if err != nil {
+		app.logError(err, r)
+		app.writeInternalServerErrorResponse(w, r)
+	}
+}
+
+func (app *Application) deleteClub(w http.ResponseWriter, r *http.Request) {
+	clubId, role, ok := app.requireClubRole(w, r, true)
+	if !ok {
+		return
+	}
+
+	if *role != models.ClubOwner {
+		app.writeError(w, r, http.StatusForbidden, constants.NotClubAdminError.Code, constants.NotClubAdminError.Error())
+		return
+	}
+
+	err := app.daos.WithTransaction(func(tx *sql.Tx) error {
+		return app.daos.DeleteClub(clubId, tx)
+	})
+	if err != nil {
+		app.logError(err, r)
+		app.writeInternalServerErrorResponse(w, r)
+	}
+}
+
+func (app *Application) getClubMembers(w http.ResponseWriter, r *http.Request) {
+	clubId, err := app.readParam("clubId", r)
+	if err != nil {
+		app.logError(err, r)
+		app.writeBadRequestResponse(w, r)
+		return
+	}
+
+	pageNumber, pageSize, ok := app.readClubPage(w, r)
+	if !ok {
+		return
+	}
+
+	members, err := app.daos.GetClubMembers(*clubId, pageNumber, pageSize)
+	if err != nil {
+		app.logError(err, r)
+		app.writeInternalServerErrorResponse(w, r)
+		return
+	}
+
+	err = app.writeResponse(w, responseData{"members": members}, http.StatusOK, nil)
+	if err != nil {
+		app.logError(err, r)
+		app.writeInternalServerErrorResponse(w, r)
+	}
+}
+
+// getClubEvents is the club feed. The non-members only see the events which are open to the public.
+func (app *Application) getClubEvents(w http.ResponseWriter, r *http.Request) {
+	clubId, err := app.readParam("clubId", r)
+	if err != nil {
+		app.logError(err, r)
+		app.writeBadRequestResponse(w, r)
+		return
+	}
+
+	pageNumber, pageSize, ok := app.readClubPage(w, r)
+	if !ok {
+		return
+	}
+
+	user, ok := app.GetUserContext(r)
+	if !ok {
+		app.writeInvalidAuthenticationErrorResponse(w, r)
+		return
+	}
+
+	events, err := app.daos.GetClubEvents(*clubId, user.ID, pageNumber, pageSize)
+	if err != nil {
+		app.logError(err, r)
+		app.writeInternalServerErrorResponse(w, r)
+		return
+	}
+
+	err = app.writeResponse(w, responseData{"events": events}, http.StatusOK, nil)
+	if err != nil {
+		app.logError(err, r)
+		app.writeInternalServerErrorResponse(w, r)
+	}
+}
+
+// joinClub adds the user to the club right away when the club is open, else a join request is sent to the admins.
+func (app *Application) joinClub(w http.ResponseWriter, r *http.Request) {
+	input := struct {
+		ClubId  int64   `json:"clubId"`
+		Message *string `json:"message"`
+	}{}
+	err := app.readRequest(r, &input)
+	if err != nil {
+		app.logError(err, r)
+		app.writeBadRequestResponse(w, r)
+		return
+	}
+
+	user, ok := app.GetUserContext(r)
+	if !ok {
+		app.writeInvalidAuthenticationErrorResponse(w, r)
+		return
+	}
+
+	club, err := app.daos.GetClubDetail(input.ClubId, user.ID)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			app.notFound(w, r)
+		default:
+			app.logError(err, r)
+			app.writeInternalServerErrorResponse(w, r)
+		}
+		return
+	}
+
+	if club.Role != nil {
+		app.writeError(w, r, http.StatusConflict, constants.ClubMemberExistError.Code, constants.ClubMemberExistError.Error())
+		return
+	}
+
+	if club.JoinPolicy == models.ClubOpenJoin {
+		err = app.daos.AddClubMember(club.ID, user.ID, models.ClubMember, nil)
+		if err != nil {
+			app.logError(err, r)
+			app.writeInternalServerErrorResponse(w, r)
+			return
+		}
+
+		err = app.writeResponse(w, responseData{"role": models.ClubMember}, http.StatusCreated, nil)
+		if err != nil {
+			app.logError(err, r)
+			app.writeInternalServerErrorResponse(w, r)
+		}
+		return
+	}
+
+	request := &models.ClubJoinRequest{
+		ClubId:  club.ID,
+		UserId:  user.ID,
+		Message: input.Message,
+	}
+	err = app.daos.CreateClubJoinRequest(request)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			app.writeError(w, r, http.StatusConflict, constants.ClubJoinRequestExistError.Code, constants.ClubJoinRequestExistError.Error())
+		default:
+			app.logError(err, r)
+			app.writeInternalServerErrorResponse(w, r)
+		}
+		return
+	}
+
+	err = app.writeResponse(w, responseData{"joinRequest": request}, http.StatusCreated, nil)
+	if err != nil {
+		app.logError(err, r)
+		app.writeInternalServerErrorResponse(w, r)
+	}
+}
+
+func (app *Application) cancelClubJoinRequest(w http.ResponseWriter, r *http.Request) {
+	clubId, err := app.readParam("clubId", r)
+	if err != nil {
+		app.logError(err, r)
+		app.writeBadRequestResponse(w, r)
+		return
+	}
+
+	user, ok := app.GetUserContext(r)
+	if !ok {
+		app.writeInvalidAuthenticationErrorResponse(w, r)
+		return
+	}
+
+	err = app.daos.CancelClubJoinRequest(*clubId, user.ID)
+	if err != nil {
+		app.logError(err, r)
+		app.writeInternalServerErrorResponse(w, r)
+	}
+}
+
+func (app *Application) getClubJoinRequests(w http.ResponseWriter, r *http.Request) {
+	clubId, _, ok := app.requireClubRole(w, r, true)
+	if !ok {
+		return
+	}
+
+	pageNumber, pageSize, ok := app.readClubPage(w, r)
+	if !ok {
+		return
+	}
+
+	requests, err := app.daos.GetPendingClubJoinRequests(clubId, pageNumber, pageSize)
+	if err != nil {
+		app.logError(err, r)
+		app.writeInternalServerErrorResponse(w, r)
+		return
+	}
+
+	err = app.writeResponse(w, responseData{"joinRequests": requests}, http.StatusOK, nil)
+	if err != nil {
+		app.logError(err, r)
+		app.writeInternalServerErrorResponse(w, r)
+	}
+}
+
+func (app *Application) reviewClubJoinRequest(w http.ResponseWriter, r *http.Request) {
+	input := struct {
+		ClubId    int64 `json:"clubId"`
+		RequestId int64 `json:"requestId"`
+		Approve   bool  `json:"approve"`
+	}{}
+	err := app.readRequest(r, &input)
+	if err != nil {
+		app.logError(err, r)
+		app.writeBadRequestResponse(w, r)
+		return
+	}
+
+	user, ok := app.GetUserContext(r)
+	if !ok {
+		app.writeInvalidAuthenticationErrorResponse(w, r)
+		return
+	}
+
+	club, err := app.daos.GetClubDetail(input.ClubId, user.ID)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			app.notFound(w, r)
+		default:
+			app.logError(err, r)
+			app.writeInternalServerErrorResponse(w, r)
+		}
+		return
+	}
+
+	if club.Role == nil || !club.Role.CanManage() {
+		app.writeError(w, r, http.StatusForbidden, constants.NotClubAdminError.Code, constants.NotClubAdminError.Error())
+		return
+	}
+
+	var requesterId int64
+	err = app.daos.WithTransaction(func(tx *sql.Tx) error {
+		requesterId, err = app.daos.ReviewClubJoinRequest(input.ClubId, input.RequestId, user.ID, input.Approve, tx)
+		return err
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			app.writeError(w, r, http.StatusConflict, constants.StaleInfoError.Code, "The join request is staled. Please refresh")
+		default:
+			app.logError(err, r)
+			app.writeInternalServerErrorResponse(w, r)
+		}
+		return
+	}
+
+	err = app.sendClubJoinRequestReviewedMessage(r, club.ID, requesterId, club.ClubName, input.Approve)
+	if err != nil {
+		app.logError(err, r)
+	}
+}
+
+// updateClubMemberRole promotes a member to admin or the other way round, which only the owner can do.
+func (app *Application) updateClubMemberRole(w http.ResponseWriter, r *http.Request) {
+	input := struct {
+		ClubId int64           `json:"clubId"`
+		UserId int64           `json:"userId"`
+		Role   models.ClubRole `json:"role"`
+	}{}
+	err := app.readRequest(r, &input)
+	if err != nil {
+		app.logError(err, r)
+		app.writeBadRequestResponse(w, r)
+		return
+	}
+
+	validator := tools.NewRequestValidator()
+	validator.Check(input.Role == models.ClubAdmin || input.Role == models.ClubMember, "role", "must be ADMIN or MEMBER")
+	if !validator.Valid() {
+		app.writeError(w, r, http.StatusBadRequest, http.StatusBadRequest, validator.Errors)
+		return
+	}
+
+	user, ok := app.GetUserContext(r)
+	if !ok {
+		app.writeInvalidAuthenticationErrorResponse(w, r)
+		return
+	}
+
+	role, err := app.daos.GetClubMemberRole(input.ClubId, user.ID)
+	if err != nil {
+		app.logError(err, r)
+		app.writeInternalServerErrorResponse(w, r)
+		return
+	}
+	if role == nil || *role != models.ClubOwner {
+		app.writeError(w, r, http.StatusForbidden, constants.NotClubAdminError.Code, constants.NotClubAdminError.Error())
+		return
+	}
+
+	targetRole, err := app.daos.GetClubMemberRole(input.ClubId, input.UserId)
+	if err != nil {
+		app.logError(err, r)
+		app.writeInternalServerErrorResponse(w, r)
+		return
+	}
+	switch {
+	case targetRole == nil:
+		app.writeError(w, r, http.StatusUnprocessableEntity, constants.NotClubMemberError.Code, constants.NotClubMemberError.Error())
+		return
+	case *targetRole == models.ClubOwner:
+		app.writeError(w, r, http.StatusUnprocessableEntity, constants.ClubOwnerError.Code, constants.ClubOwnerError.Error())
+		return
+	}
+
+	err = app.daos.UpdateClubMemberRole(input.ClubId, input.UserId, input.Role)
+	if err != nil {
+		app.logError(err, r)
+		app.writeInternalServerErrorResponse(w, r)
+	}
+}
+
+// removeClubMember lets the owner remove anyone, while the admins can only remove the members.
+func (app *Application) removeClubMember(w http.ResponseWriter, r *http.Request) {
+	clubId, role, ok := app.requireClubRole(w, r, true)
+	if !ok {
+		return
+	}
+
+	userId, err := app.readParam("userId", r)
+	if err != nil {
+		app.logError(err, r)
+		app.writeBadRequestResponse(w, r)
+		return
+	}
+
+	targetRole, err := app.daos.GetClubMemberRole(clubId, *userId)
+	if err != nil {
+		app.logError(err, r)
+		app.writeInternalServerErrorResponse(w, r)
+		return
+	}
+	switch {
+	case targetRole == nil:
+		app.writeError(w, r, http.StatusUnprocessableEntity, constants.NotClubMemberError.Code, constants.NotClubMemberError.Error())
+		return
+	case *targetRole == models.ClubOwner:
+		app.writeError(w, r, http.StatusUnprocessableEntity, constants.ClubOwnerError.Code, constants.ClubOwnerError.Error())
+		return
+	case *targetRole == models.ClubAdmin && *role != models.ClubOwner:
+		app.writeError(w, r, http.StatusForbidden, constants.NotClubAdminError.Code, constants.NotClubAdminError.Error())
+		return
+	}
+
+	err = app.daos.RemoveClubMember(clubId, *userId)
+	if err != nil {
+		app.logError(err, r)
+		app.writeInternalServerErrorResponse(w, r)
+	}
+}
+
+func (app *Application) quitClub(w http.ResponseWriter, r *http.Request) {
+	clubId, role, ok := app.requireClubRole(w, r, false)
+	if !ok {
+		return
+	}
+
+	// The owner has to delete the club instead.
+	if *role == models.ClubOwner {
+		app.writeError(w, r, http.StatusUnprocessableEntity, constants.ClubOwnerError.Code, constants.ClubOwnerError.Error())
+		return
+	}
+
+	user, _ := app.GetUserContext(r)
+	err := app.daos.RemoveClubMember(clubId, user.ID)
+	if err != nil {
+		app.logError(err, r)
+		app.writeInternalServerErrorResponse(w, r)
+	}
+}
+
+// requireClubRole reads the club from the url params, and makes sure the user is a member, or an admin when
+// adminOnly is true. The error response is written when it is not ok.
+func (app *Application) requireClubRole(w http.ResponseWriter, r *http.Request, adminOnly bool) (int64, *models.ClubRole, bool) {
+	clubId, err := app.readParam("clubId", r)
+	if err != nil {
+		app.logError(err, r)
+		app.writeBadRequestResponse(w, r)
+		return 0, nil, false
+	}
+
+	user, ok := app.GetUserContext(r)
+	if !ok {
+		app.writeInvalidAuthenticationErrorResponse(w, r)
+		return 0, nil, false
+	}
+
+	role, err := app.daos.GetClubMemberRole(*clubId, user.ID)
+	if err != nil {
+		app.logError(err, r)
+		app.writeInternalServerErrorResponse(w, r)
+		return 0, nil, false
+	}
+
+	switch {
+	case role == nil && !adminOnly:
+		app.writeError(w, r, http.StatusForbidden, constants.NotClubMemberError.Code, constants.NotClubMemberError.Error())
+		return 0, nil, false
+	case adminOnly && (role == nil || !role.CanManage()):
+		app.writeError(w, r, http.StatusForbidden, constants.NotClubAdminError.Code, constants.NotClubAdminError.Error())
+		return 0, nil, false
+	}
+
+	return *clubId, role, true
+}
+
+func (app *Application) readClubPage(w http.ResponseWriter, r *http.Request) (int64, int64, bool) {
+	query := r.URL.Query()
+	pageNumber, err := app.readInt(query, "pageNumber", 1)
+	if err != nil {
+		app.logError(err, r)
+		app.writeBadRequestResponse(w, r)
+		return 0, 0, false
+	}
+
+	pageSize, err := app.readInt(query, "pageSize", 20)
+	if err != nil {
+		app.logError(err, r)
+		app.writeBadRequestResponse(w, r)
+		return 0, 0, false
+	}
+
+	validator := tools.NewRequestValidator()
+	validator.Check(pageNumber >= 1, "pageNumber", "must be at least 1")
+	validator.Check(pageSize >= 1 && pageSize <= 100, "pageSize", "must be between 1 and 100")
+	if !validator.Valid() {
+		app.writeError(w, r, http.StatusBadRequest, http.StatusBadRequest, validator.Errors)
+		return 0, 0, false
+	}
+
+	return pageNumber, pageSize, true
+}
@@ -0,0 +1,493 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"sportgether/tools"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+type config struct {
+	port     int
+	env      string
+	dbConfig struct {
+		dsn               string
+		maxOpenConnection int
+		maxIdleConnection int
+		maxIdleTime       time.Duration
+	}
+	firebase struct {
+		credentialsFile string
+	}
+	smtp struct {
+		Host     string
+		Port     int
+		Username string
+		Password string
+		Sender   string
+	}
+	cloudinaryUrl string
+	tls           tlsConfig
+	mail          mailConfig
+	reminder      reminderConfig
+	digest        digestConfig
+
+	// metricsPort serves /metrics apart from the API, so that it is only reachable within the cluster.
+	metricsPort int
+
+	// printConfig prints the config instead of starting the server, see print.
+	printConfig bool
+	settings    []*setting
+}
+
+// setting is an option of the config, which is given by, from the highest precedence, the flag of the key, the env, the
+// key in the config file, the legacy smtp config file for the smtp settings, or else the default. E.g. db.max-open-conns is given by --db.max-open-conns, DB_MAX_OPEN_CONNS
+// or max-open-conns under db in the file.
+type setting struct {
+	key   string
+	env   string
+	usage string
+	value flag.Value
+	// redact hides the secrets of the value when the config is printed.
+	redact func(string) string
+	// source is where the value is given, e.g. env DATABASE_URL.
+	source string
+}
+
+func (c *config) newSettings() []*setting {
+	return []*setting{
+		{key: "port", env: "PORT", usage: "Port for listening", value: newIntValue(&c.port, 3000)},
+		{key: "env", env: "ENV", usage: "Environment, DEV or PRD", value: newStringValue(&c.env, "DEV")},
+		{key: "db.dsn", env: "DATABASE_URL", usage: "PostgreSQL DSN", value: newStringValue(&c.dbConfig.dsn, ""), redact: redactUrl},
+		{key: "db.max-open-conns", env: "DB_MAX_OPEN_CONNS", usage: "Max open connections of the pool", value: newIntValue(&c.dbConfig.maxOpenConnection, 25)},
+		{key: "db.max-idle-conns", env: "DB_MAX_IDLE_CONNS", usage: "Max idle connections of the pool", value: newIntValue(&c.dbConfig.maxIdleConnection, 25)},
+		{key: "db.max-idle-time", env: "DB_MAX_IDLE_TIME", usage: "Max idle time of a connection, e.g. 15m", value: newDurationValue(&c.dbConfig.maxIdleTime, 15*time.Minute)},
+		{key: "tls.cert-file", env: "TLS_CERT_FILE", usage: "Certificate file, HTTPS is served when it is given along with the key file", value: newStringValue(&c.tls.CertFile, "")},
+		{key: "tls.key-file", env: "TLS_KEY_FILE", usage: "Private key file of the certificate", value: newStringValue(&c.tls.KeyFile, "")},
+		{key: "tls.min-version", env: "TLS_MIN_VERSION", usage: "Minimum TLS version, 1.2 or 1.3", value: newStringValue(&c.tls.MinVersion, "1.2")},
+		{key: "tls.ciphers", env: "TLS_CIPHERS", usage: "TLS 1.2 cipher suites, comma separated, the Go defaults when not given", value: newStringListValue(&c.tls.Ciphers, []string{})},
+		{key: "tls.redirect-port", env: "TLS_REDIRECT_PORT", usage: "Port redirecting HTTP to HTTPS, none when 0", value: newIntValue(&c.tls.RedirectPort, 0)},
+		{key: "tls.reload-interval", env: "TLS_RELOAD_INTERVAL", usage: "How often the certificate files are checked for changes", value: newDurationValue(&c.tls.ReloadInterval, time.Minute)},
+		{key: "metrics.port", env: "METRICS_PORT", usage: "Admin port serving /metrics, none when 0", value: newIntValue(&c.metricsPort, 0)},
+		{key: "firebase.credentials-file", env: "FIREBASE_CREDENTIALS_FILE", usage: "Service account file of Firebase", value: newStringValue(&c.firebase.credentialsFile, "./data/service-account-file.json")},
+		{key: "mail.transport", env: "MAIL_TRANSPORT", usage: "smtp, maildir or memory, which is smtp in PRD and maildir otherwise when not given", value: newStringValue(&c.mail.Transport, "")},
+		{key: "mail.maildir-path", env: "MAILDIR_PATH", usage: "Dir of the maildir transport", value: newStringValue(&c.mail.MaildirPath, "./maildir")},
+		{key: "smtp.host", env: "SMTP_HOST", usage: "Host of the SMTP server", value: newStringValue(&c.smtp.Host, "")},
+		{key: "smtp.port", env: "SMTP_PORT", usage: "Port of the SMTP server", value: newIntValue(&c.smtp.Port, 587)},
+		{key: "smtp.username", env: "SMTP_USERNAME", usage: "Username of the SMTP server", value: newStringValue(&c.smtp.Username, "")},
+		{key: "smtp.password", env: "SMTP_PASSWORD", usage: "Password of the SMTP server", value: newStringValue(&c.smtp.Password, ""), redact: redactSecret},
+		{key: "smtp.sender", env: "SMTP_SENDER", usage: "Sender of the emails", value: newStringValue(&c.smtp.Sender, "")},
+		{key: "media.cloudinary-url", env: "CLOUDINARY_URL", usage: "Cloudinary URL, which must be given in PRD, the media is kept in the local file system when not given", value: newStringValue(&c.cloudinaryUrl, ""), redact: redactUrl},
+		{key: "reminder.interval", env: "REMINDER_INTERVAL", usage: "How often the event reminders are sent", value: newDurationValue(&c.reminder.Interval, time.Minute)},
+		{key: "reminder.offsets-in-min", env: "REMINDER_OFFSETS_IN_MIN", usage: "Minutes before the event to remind, comma separated", value: newIntListValue(&c.reminder.OffsetsInMin, []int{24 * 60, 60})},
+		{key: "digest.public-url", env: "PUBLIC_URL", usage: "Where the app is reached from the emails, which is localhost when not given", value: newStringValue(&c.digest.PublicUrl, "")},
+	}
+}
+
+// loadConfig layers the settings, where the config file is given by --config or CONFIG_FILE, and can be YAML or TOML.
+// The config is not validated yet, so that an invalid config can still be printed, see validate.
+func loadConfig(args []string, lookupEnv func(string) (string, bool)) (*config, error) {
+	c := &config{}
+	c.settings = c.newSettings()
+
+	flags := flag.NewFlagSet("sportgether", flag.ContinueOnError)
+	configFile := flags.String("config", "", "Config file, YAML or TOML (env CONFIG_FILE)")
+	flags.BoolVar(&c.printConfig, "print-config", false, "Print the config with the secrets redacted, then exit")
+	flagValues := map[string]string{}
+	for _, setting := range c.settings {
+		flags.Var(&flagValue{key: setting.key, values: flagValues}, setting.key, fmt.Sprintf("%s (env %s)", setting.usage, setting.env))
+	}
+
+	err := flags.Parse(args)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, setting := range c.settings {
+		setting.source = "default"
+	}
+
+	if *configFile == "" {
+		*configFile, _ = lookupEnv("CONFIG_FILE")
+	}
+	if *configFile != "" {
+		fileValues, err := readConfigFile(*configFile)
+		if err != nil {
+			return nil, err
+		}
+		err = c.apply(fileValues, "file "+*configFile)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for _, setting := range c.settings {
+		value, ok := lookupEnv(setting.env)
+		if !ok {
+			continue
+		}
+		err = c.apply(map[string]string{setting.key: value}, "env "+setting.env)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = c.apply(flagValues, "flag")
+	if err != nil {
+		return nil, err
+	}
+
+	err = c.applyLegacySmtpConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	c.digest.PublicUrl = strings.TrimSuffix(c.digest.PublicUrl, "/")
+	if c.digest.PublicUrl == "" {
+		c.digest.PublicUrl = fmt.Sprintf("http://localhost:%d", c.port)
+	}
+
+	return c, nil
+}
+
+// applyLegacySmtpConfig reads the smtp settings from ./data/smtp_config_prod, or ./data/smtp_config_dev outside PRD,
+// where they were kept before the config file and the env. It only sets the ones still at their defaults, so that it is
+// the lowest layer, and the deploys can move to SMTP_* one setting at a time. The file is optional.
+func (c *config) applyLegacySmtpConfig() error {
+	path := "./data/smtp_config_dev"
+	if c.isProd() {
+		path = "./data/smtp_config_prod"
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+
+	legacy := map[string]any{}
+	err = json.Unmarshal(content, &legacy)
+	if err != nil {
+		return fmt.Errorf("invalid smtp config file %s: %w", path, err)
+	}
+
+	values := map[string]string{}
+	for _, name := range []string{"host", "port", "username", "password", "sender"} {
+		value, ok := legacy[name]
+		if !ok || value == nil {
+			continue
+		}
+		key := "smtp." + name
+		index := slices.IndexFunc(c.settings, func(setting *setting) bool {
+			return setting.key == key
+		})
+		if c.settings[index].source == "default" {
+			values[key] = fmt.Sprint(value)
+		}
+	}
+
+	return c.apply(values, "file "+path)
+}
+
+// apply sets the settings of the keys, where the source is kept for print.
+func (c *config) apply(values map[string]string, source string) error {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		index := slices.IndexFunc(c.settings, func(setting *setting) bool {
+			return setting.key == key
+		})
+		if index < 0 {
+			return fmt.Errorf("unknown config %s from %s", key, source)
+		}
+
+		setting := c.settings[index]
+		err := setting.value.Set(values[key])
+		if err != nil {
+			return fmt.Errorf("invalid config %s from %s: %w", key, source, err)
+		}
+		setting.source = source
+	}
+
+	return nil
+}
+
+func (c *config) validate() error {
+	validator := tools.NewRequestValidator()
+	validator.Check(c.port > 0 && c.port <= 65535, "port", "must be between 1 and 65535")
+	validator.Check(c.env == "DEV" || c.env == "PRD", "env", "must be DEV or PRD")
+	validator.Check(c.dbConfig.dsn != "", "db.dsn", "must be given")
+	validator.Check(c.dbConfig.maxOpenConnection > 0, "db.max-open-conns", "must be positive")
+	validator.Check(c.dbConfig.maxIdleConnection >= 0 && c.dbConfig.maxIdleConnection <= c.dbConfig.maxOpenConnection, "db.max-idle-conns", "must be between 0 and db.max-open-conns")
+	validator.Check(c.dbConfig.maxIdleTime > 0, "db.max-idle-time", "must be positive")
+	c.tls.validate(validator, c.port)
+	validator.Check(c.metricsPort >= 0 && c.metricsPort <= 65535 && c.metricsPort != c.port, "metrics.port", "must be between 0 and 65535, other than port")
+	validator.Check(c.metricsPort == 0 || c.metricsPort != c.tls.RedirectPort, "metrics.port", "must be other than tls.redirect-port")
+	validator.Check(c.firebase.credentialsFile != "", "firebase.credentials-file", "must be given")
+	// The local file system of an instance is neither shared with the others nor kept across deploys.
+	validator.Check(!c.isProd() || c.cloudinaryUrl != "", "media.cloudinary-url", "must be given in PRD")
+	validator.Check(slices.Contains([]string{"", "smtp", "maildir", "memory"}, c.mail.Transport), "mail.transport", "must be smtp, maildir or memory")
+	if c.mailTransport() == "smtp" {
+		validator.Check(c.smtp.Host != "", "smtp.host", "must be given for the smtp transport")
+		validator.Check(c.smtp.Port > 0 && c.smtp.Port <= 65535, "smtp.port", "must be between 1 and 65535")
+		validator.Check(c.smtp.Sender != "", "smtp.sender", "must be given for the smtp transport")
+	}
+	validator.Check(c.reminder.Interval > 0, "reminder.interval", "must be positive")
+	validator.Check(len(c.reminder.OffsetsInMin) > 0, "reminder.offsets-in-min", "must not be empty")
+	for _, offset := range c.reminder.OffsetsInMin {
+		validator.Check(offset > 0, "reminder.offsets-in-min", "must be positive")
+	}
+	publicUrl, err := url.Parse(c.digest.PublicUrl)
+	validator.Check(err == nil && (publicUrl.Scheme == "http" || publicUrl.Scheme == "https") && publicUrl.Host != "", "digest.public-url", "must be a http url")
+	if validator.Valid() {
+		return nil
+	}
+
+	problems := make([]string, 0, len(validator.Errors))
+	for key, detail := range validator.Errors {
+		problems = append(problems, fmt.Sprintf("%s %s", key, detail))
+	}
+	sort.Strings(problems)
+	return fmt.Errorf("invalid config: %s", strings.Join(problems, "; "))
+}
+
+// print writes the settings with where they are given, so that the config can be checked before deploying.
+func (c *config) print(w io.Writer) error {
+	for _, setting := range c.settings {
+		value := setting.value.String()
+		if setting.redact != nil {
+			value = setting.redact(value)
+		}
+		_, err := fmt.Fprintf(w, "%s = %q # %s\n", setting.key, value, setting.source)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *config) isProd() bool {
+	return c.env == "PRD"
+}
+
+// readConfigFile returns the values of the file by the keys, where the nested keys are joined by ".", and the lists are
+// joined by ",".
+func readConfigFile(path string) (map[string]string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	raw := map[string]any{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(content, &raw)
+	case ".toml":
+		err = toml.Unmarshal(content, &raw)
+	default:
+		return nil, fmt.Errorf("config file %s must be .yaml, .yml or .toml", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+
+	values := map[string]string{}
+	flattenConfig("", raw, values)
+	return values, nil
+}
+
+func flattenConfig(prefix string, raw map[string]any, values map[string]string) {
+	for key, value := range raw {
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+
+		switch value := value.(type) {
+		case nil:
+		case map[string]any:
+			flattenConfig(key, value, values)
+		case []any:
+			items := make([]string, 0, len(value))
+			for _, item := range value {
+				items = append(items, fmt.Sprint(item))
+			}
+			values[key] = strings.Join(items, ",")
+		default:
+			values[key] = fmt.Sprint(value)
+		}
+	}
+}
+
+func redactSecret(value string) string {
+	if value == "" {
+		return ""
+	}
+	return "[REDACTED]"
+}
+
+// The password of the key=value DSN, which is quoted when it has spaces, e.g. password='a b'.
+var dsnPasswordRX = regexp.MustCompile(`(password\s*=\s*)('(?:[^'\\]|\\.)*'|\S+)`)
+
+// redactUrl only hides the password of the url, or of the key=value DSN, so that the host is still shown. Anything
+// else is hidden as a whole.
+func redactUrl(value string) string {
+	if !strings.Contains(value, "://") {
+		if strings.Contains(value, "=") {
+			return dsnPasswordRX.ReplaceAllString(value, "${1}REDACTED")
+		}
+		return redactSecret(value)
+	}
+
+	parsed, err := url.Parse(value)
+	if err != nil || parsed.Scheme == "" {
+		return redactSecret(value)
+	}
+	if _, ok := parsed.User.Password(); ok {
+		parsed.User = url.UserPassword(parsed.User.Username(), "REDACTED")
+	}
+	if parsed.Query().Has("password") {
+		query := parsed.Query()
+		query.Set("password", "REDACTED")
+		parsed.RawQuery = query.Encode()
+	}
+	return parsed.String()
+}
+
+// flagValue keeps the flags given, which are applied after the file and the env.
+type flagValue struct {
+	key    string
+	values map[string]string
+}
+
+func (v *flagValue) String() string {
+	if v.values == nil {
+		return ""
+	}
+	return v.values[v.key]
+}
+
+func (v *flagValue) Set(value string) error {
+	v.values[v.key] = value
+	return nil
+}
+
+type stringValue struct{ p *string }
+
+func newStringValue(p *string, defaultValue string) *stringValue {
+	*p = defaultValue
+	return &stringValue{p}
+}
+
+func (v *stringValue) String() string { return *v.p }
+
+func (v *stringValue) Set(value string) error {
+	*v.p = value
+	return nil
+}
+
+type intValue struct{ p *int }
+
+func newIntValue(p *int, defaultValue int) *intValue {
+	*p = defaultValue
+	return &intValue{p}
+}
+
+func (v *intValue) String() string { return strconv.Itoa(*v.p) }
+
+func (v *intValue) Set(value string) error {
+	parsed, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil {
+		return errors.New("must be an integer")
+	}
+	*v.p = parsed
+	return nil
+}
+
+type durationValue struct{ p *time.Duration }
+
+func newDurationValue(p *time.Duration, defaultValue time.Duration) *durationValue {
+	*p = defaultValue
+	return &durationValue{p}
+}
+
+func (v *durationValue) String() string { return v.p.String() }
+
+func (v *durationValue) Set(value string) error {
+	parsed, err := time.ParseDuration(strings.TrimSpace(value))
+	if err != nil {
+		return errors.New("must be a duration, e.g. 15m")
+	}
+	*v.p = parsed
+	return nil
+}
+
+type stringListValue struct{ p *[]string }
+
+func newStringListValue(p *[]string, defaultValue []string) *stringListValue {
+	*p = defaultValue
+	return &stringListValue{p}
+}
+
+func (v *stringListValue) String() string { return strings.Join(*v.p, ",") }
+
+func (v *stringListValue) Set(value string) error {
+	parsed := []string{}
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			parsed = append(parsed, item)
+		}
+	}
+	*v.p = parsed
+	return nil
+}
+
+type intListValue struct{ p *[]int }
+
+func newIntListValue(p *[]int, defaultValue []int) *intListValue {
+	*p = defaultValue
+	return &intListValue{p}
+}
+
+func (v *intListValue) String() string {
+	items := make([]string, 0, len(*v.p))
+	for _, item := range *v.p {
+		items = append(items, strconv.Itoa(item))
+	}
+	return strings.Join(items, ",")
+}
+
+func (v *intListValue) Set(value string) error {
+	parsed := []int{}
+	for _, item := range strings.Split(value, ",") {
+		if strings.TrimSpace(item) == "" {
+			continue
+		}
+		number, err := strconv.Atoi(strings.TrimSpace(item))
+		if err != nil {
+			return errors.New("must be comma separated integers")
+		}
+		parsed = append(parsed, number)
+	}
+	*v.p = parsed
+	return nil
+}
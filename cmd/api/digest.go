@@ -0,0 +1,299 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sportgether/internal/i18n"
+	"sportgether/internal/models"
+	"sportgether/tools"
+	"time"
+)
+
+const (
+	digestBatchSize = 100
+	// The digest of the week is sent from the monday at digestSendHour in the timezone of the user.
+	digestSendHour    = 9
+	digestMaxEvents   = 6
+	digestPageSize    = 30
+	digestRadiusInKm  = 30
+	digestPeriod      = 7 * 24 * time.Hour
+	digestMaxSports   = 20
+	digestTemplate    = "weekly_digest.tmpl"
+	digestUnsubscribe = "/v1/digest/unsubscribe"
+)
+
+type digestConfig struct {
+	// PublicUrl is where the app is reached from the emails, e.g. for the unsubscribe link.
+	PublicUrl string
+}
+
+// digestEventCard is an event in the digest email.
+type digestEventCard struct {
+	EventName    string
+	EventType    string
+	Destination  string
+	StartTime    string
+	DistanceInKm string
+	SpotsLeft    int
+	ImageUrl     string
+}
+
+func (app *Application) getDigestSetting(w http.ResponseWriter, r *http.Request) {
+	user, ok := app.GetUserContext(r)
+	if !ok {
+		app.writeInvalidAuthenticationErrorResponse(w, r)
+		return
+	}
+
+	setting, err := app.daos.GetDigestSetting(user.ID)
+	if err != nil {
+		app.logError(err, r)
+		app.writeInternalServerErrorResponse(w, r)
+		return
+	}
+
+	err = app.writeResponse(w, responseData{"digestSetting": setting}, http.StatusOK, nil)
+	if err != nil {
+		app.logError(err, r)
+		app.writeInternalServerErrorResponse(w, r)
+	}
+}
+
+func (app *Application) updateDigestSetting(w http.ResponseWriter, r *http.Request) {
+	input := struct {
+		Enabled         *bool           `json:"enabled"`
+		HomeLocation    *models.GeoType `json:"homeLocation"`
+		FavouriteSports []string        `json:"favouriteSports"`
+	}{}
+	err := app.readRequest(r, &input)
+	if err != nil {
+		app.logError(err, r)
+		app.writeBadRequestResponse(w, r)
+		return
+	}
+
+	validator := tools.NewRequestValidator()
+	if input.HomeLocation != nil {
+		validator.Check(input.HomeLocation.Longitude >= -180 && input.HomeLocation.Longitude <= 180, "homeLocation", "longitude must be between -180 and 180")
+		validator.Check(input.HomeLocation.Latitude >= -90 && input.HomeLocation.Latitude <= 90, "homeLocation", "latitude must be between -90 and 90")
+	}
+	validator.Check(len(input.FavouriteSports) <= digestMaxSports, "favouriteSports", fmt.Sprintf("must have at most %d sports", digestMaxSports))
+	if validator.Valid() {
+		inactive, err := app.daos.GetInactiveSports(input.FavouriteSports)
+		if err != nil {
+			app.logError(err, r)
+			app.writeInternalServerErrorResponse(w, r)
+			return
+		}
+		for _, sport := range inactive {
+			validator.AppendError("favouriteSports", fmt.Sprintf("%s is not an available sport", sport))
+		}
+	}
+	if !validator.Valid() {
+		app.writeError(w, r, http.StatusBadRequest, http.StatusBadRequest, validator.Errors)
+		return
+	}
+
+	user, ok := app.GetUserContext(r)
+	if !ok {
+		app.writeInvalidAuthenticationErrorResponse(w, r)
+		return
+	}
+
+	setting, err := app.daos.GetDigestSetting(user.ID)
+	if err != nil {
+		app.logError(err, r)
+		app.writeInternalServerErrorResponse(w, r)
+		return
+	}
+
+	if input.Enabled != nil {
+		setting.Enabled = *input.Enabled
+	}
+	if input.HomeLocation != nil {
+		setting.HomeLocation = input.HomeLocation
+	}
+	if input.FavouriteSports != nil {
+		setting.FavouriteSports = input.FavouriteSports
+	}
+
+	err = app.daos.UpdateDigestSetting(user.ID, setting)
+	if err != nil {
+		app.logError(err, r)
+		app.writeInternalServerErrorResponse(w, r)
+		return
+	}
+
+	err = app.writeResponse(w, responseData{"digestSetting": setting}, http.StatusOK, nil)
+	if err != nil {
+		app.logError(err, r)
+		app.writeInternalServerErrorResponse(w, r)
+	}
+}
+
+// unsubscribeDigest is the one-click unsubscribe link of the digest email. It is opened from the email without logging
+// in, so the signed token identifies the user instead. Mail clients supporting List-Unsubscribe-Post send a POST to the
+// same link.
+func (app *Application) unsubscribeDigest(w http.ResponseWriter, r *http.Request) {
+	userId, ok := tools.ParseSignedToken(r.URL.Query().Get("token"), tools.DIGEST_UNSUBSCRIBE_SCOPE)
+	if !ok {
+		app.notFound(w, r)
+		return
+	}
+
+	err := app.daos.DisableDigest(*userId)
+	if err != nil {
+		app.logError(err, r)
+		app.writeInternalServerErrorResponse(w, r)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	_, err = w.Write([]byte(i18n.Translate(app.userLocale(*userId, r), "digest.unsubscribed", nil)))
+	if err != nil {
+		app.logError(err, r)
+	}
+}
+
+// sendWeeklyDigests goes through the subscribers in batches. The digest is claimed per user per week before being
+// queued, so running it again, or on several instances, never sends the digest of the same week twice.
+func (app *Application) sendWeeklyDigests(ctx context.Context, now time.Time) error {
+	sports, err := app.daos.GetSports(true)
+	if err != nil {
+		return err
+	}
+	imageUrls := make(map[string]string, len(sports))
+	for _, sport := range sports {
+		imageUrls[sport.Sport] = sport.ImageUrl
+	}
+
+	afterUserId := int64(0)
+	for {
+		subscribers, err := app.daos.GetDigestSubscribers(afterUserId, digestBatchSize)
+		if err != nil {
+			return err
+		}
+
+		userIds := make([]int64, 0, len(subscribers))
+		for _, subscriber := range subscribers {
+			userIds = append(userIds, subscriber.UserId)
+		}
+		settings, err := app.daos.GetNotificationSettings(userIds)
+		if err != nil {
+			return err
+		}
+
+		for _, subscriber := range subscribers {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+
+			afterUserId = subscriber.UserId
+			err = app.sendWeeklyDigest(subscriber, settings[subscriber.UserId], imageUrls, now)
+			if err != nil {
+				app.logger.Error(err.Error(), "userId", subscriber.UserId)
+			}
+		}
+
+		if len(subscribers) < digestBatchSize {
+			return nil
+		}
+	}
+}
+
+func (app *Application) sendWeeklyDigest(subscriber *models.DigestSubscriber, setting *models.NotificationSetting, imageUrls map[string]string, now time.Time) error {
+	location := setting.Location()
+	local := now.In(location)
+	weekStart := time.Date(local.Year(), local.Month(), local.Day()-(int(local.Weekday())+6)%7, 0, 0, 0, 0, location)
+	if local.Before(weekStart.Add(digestSendHour*time.Hour)) || len(subscriber.Sports) == 0 {
+		return nil
+	}
+
+	claimed, err := app.daos.ClaimWeeklyDigest(subscriber.UserId, weekStart)
+	if err != nil || !claimed {
+		return err
+	}
+
+	cards, err := app.digestEventCards(subscriber, location, imageUrls, now)
+	if err != nil {
+		return app.releaseWeeklyDigest(subscriber.UserId, weekStart, err)
+	}
+	if len(cards) == 0 {
+		return app.daos.CompleteWeeklyDigest(subscriber.UserId, weekStart, nil, 0)
+	}
+
+	token := tools.GenerateSignedToken(subscriber.UserId, tools.DIGEST_UNSUBSCRIBE_SCOPE)
+	unsubscribeUrl := fmt.Sprintf("%s%s?token=%s", app.config.digest.PublicUrl, digestUnsubscribe, url.QueryEscape(token))
+	data := map[string]any{
+		"username":       subscriber.Username,
+		"eventCount":     len(cards),
+		"events":         cards,
+		"unsubscribeUrl": unsubscribeUrl,
+	}
+	headers := map[string]string{
+		"List-Unsubscribe":      fmt.Sprintf("<%s>", unsubscribeUrl),
+		"List-Unsubscribe-Post": "List-Unsubscribe=One-Click",
+	}
+
+	email, err := app.enqueueEmail(subscriber.Email, setting.NotificationLocale(i18n.DefaultLocale), digestTemplate, data, headers)
+	if err != nil {
+		return app.releaseWeeklyDigest(subscriber.UserId, weekStart, err)
+	}
+
+	return app.daos.CompleteWeeklyDigest(subscriber.UserId, weekStart, &email.ID, len(cards))
+}
+
+// releaseWeeklyDigest gives the claim back, so that the digest is retried in the next run, and returns the cause.
+func (app *Application) releaseWeeklyDigest(userId int64, weekStart time.Time, cause error) error {
+	err := app.daos.ReleaseWeeklyDigest(userId, weekStart)
+	if err != nil {
+		app.logger.Error(err.Error(), "userId", userId)
+	}
+	return cause
+}
+
+// digestEventCards picks the events of the coming week with GetEvents, so that the digest has the same events the user
+// would find in the app, i.e. the visible events of the sports from the nearest, and keeps the ones with spots left
+// within digestRadiusInKm. The imageUrls are the images of the sports by the code.
+func (app *Application) digestEventCards(subscriber *models.DigestSubscriber, location *time.Location, imageUrls map[string]string, now time.Time) ([]digestEventCard, error) {
+	filter := tools.Filter{
+		PageSize:     digestPageSize,
+		EventTypes:   subscriber.Sports,
+		FromLocation: &tools.UserFromLocationFilter{Longitude: subscriber.HomeLocation.Longitude, Latitude: subscriber.HomeLocation.Latitude},
+	}
+	res, err := app.daos.GetEvents(filter, &models.User{ID: subscriber.UserId})
+	if err != nil {
+		return nil, err
+	}
+
+	cards := []digestEventCard{}
+	for _, event := range res.Events {
+		if len(cards) == digestMaxEvents || event.Distance > digestRadiusInKm*1000 {
+			break
+		}
+
+		startTime, err := time.Parse(time.RFC3339Nano, event.StartTime)
+		if err != nil {
+			return nil, err
+		}
+		spotsLeft := event.MaxParticipantCount - len(event.Participants)
+		if startTime.After(now.Add(digestPeriod)) || spotsLeft <= 0 || event.IsJoined || event.IsHost {
+			continue
+		}
+
+		cards = append(cards, digestEventCard{
+			EventName:    event.EventName,
+			EventType:    event.EventType,
+			Destination:  event.Destination,
+			StartTime:    startTime.In(location).Format("Mon, 2 Jan 15:04"),
+			DistanceInKm: fmt.Sprintf("%.1f", event.Distance/1000),
+			SpotsLeft:    spotsLeft,
+			ImageUrl:     imageUrls[event.EventType],
+		})
+	}
+
+	return cards, nil
+}
@@ -0,0 +1,219 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sportgether/internal/i18n"
+	"sportgether/internal/mailer"
+	"sportgether/internal/models"
+	"sportgether/tools"
+	"time"
+)
+
+const (
+	emailBatchSize   = 20
+	emailMaxAttempts = 6
+	emailBaseBackoff = time.Minute
+	emailMaxBackoff  = time.Hour
+	emailLease       = time.Minute
+)
+
+type mailConfig struct {
+	// Transport can be smtp, maildir or memory. It is smtp in production and maildir otherwise when not given.
+	Transport   string
+	MaildirPath string
+}
+
+// initMailer keeps the emails in the maildir outside of production, so that only production needs the SMTP server.
+func initMailer(c *config) (mailer.Mailer, error) {
+	switch transport := c.mailTransport(); transport {
+	case "smtp":
+		return mailer.NewSMTPMailer(c.smtp.Host, c.smtp.Port, c.smtp.Username, c.smtp.Password, c.smtp.Sender), nil
+	case "maildir":
+		return mailer.NewMaildirMailer(c.mail.MaildirPath, "SportGether <no-reply@sportgether.local>")
+	case "memory":
+		return &mailer.MemoryMailer{}, nil
+	default:
+		return nil, fmt.Errorf("unknown mail transport %q", transport)
+	}
+}
+
+func (c *config) mailTransport() string {
+	if c.mail.Transport != "" {
+		return c.mail.Transport
+	}
+	if c.isProd() {
+		return "smtp"
+	}
+	return "maildir"
+}
+
+// sendEmail renders the email in the locale and puts it in the email queue, then dispatches it right away, instead of
+// waiting for the scheduled dispatch.
+func (app *Application) sendEmail(r *http.Request, recipient string, locale i18n.Locale, templateFile string, data any) error {
+	_, err := app.enqueueEmail(recipient, locale, templateFile, data, nil)
+	if err != nil {
+		return err
+	}
+
+	app.background(func() {
+		err := app.dispatchEmails(context.Background(), time.Now())
+		if err != nil {
+			app.logError(err, r)
+		}
+	}, r)
+
+	return nil
+}
+
+// enqueueEmail renders the email in the locale and puts it in the email queue, which is sent by the scheduled dispatch.
+func (app *Application) enqueueEmail(recipient string, locale i18n.Locale, templateFile string, data any, headers map[string]string) (*models.QueuedEmail, error) {
+	message, err := mailer.Render(recipient, locale, templateFile, data)
+	if err != nil {
+		return nil, err
+	}
+
+	email := &models.QueuedEmail{
+		Recipient: message.To,
+		Template:  templateFile,
+		Locale:    string(locale),
+		Subject:   message.Subject,
+		PlainBody: message.PlainBody,
+		HtmlBody:  message.HtmlBody,
+		Headers:   headers,
+	}
+	err = app.daos.EnqueueEmail(email)
+	if err != nil {
+		return nil, err
+	}
+
+	return email, nil
+}
+
+// dispatchEmails sends the emails in the queue and logs every attempt. Failed sends are retried with exponential
+// backoff until emailMaxAttempts, while the emails rejected by the mail server for good are bounced right away.
+func (app *Application) dispatchEmails(ctx context.Context, now time.Time) error {
+	emails, err := app.daos.ClaimEmails(now, emailBatchSize, emailLease)
+	if err != nil {
+		return err
+	}
+
+	for _, email := range emails {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		err = app.mailer.Send(&mailer.Message{
+			To:        email.Recipient,
+			Subject:   email.Subject,
+			PlainBody: email.PlainBody,
+			HtmlBody:  email.HtmlBody,
+			Headers:   email.Headers,
+		})
+		attemptedAt := time.Now()
+
+		var attemptError *string
+		if err != nil {
+			message := err.Error()
+			attemptError = &message
+		}
+		logErr := app.daos.InsertEmailDeliveryAttempt(email.ID, app.mailer.Name(), attemptError, attemptedAt)
+		if logErr != nil {
+			app.logger.Error(logErr.Error(), "emailId", email.ID)
+		}
+
+		if err == nil {
+			err = app.daos.CompleteEmail(email.ID, attemptedAt)
+			if err != nil {
+				app.logger.Error(err.Error(), "emailId", email.ID)
+			}
+			continue
+		}
+
+		attempts := email.Attempts + 1
+		app.logger.Error(err.Error(), "emailId", email.ID, "attempts", attempts)
+
+		status := models.EmailPending
+		switch {
+		case mailer.IsPermanent(err):
+			status = models.EmailBounced
+		case attempts >= emailMaxAttempts:
+			status = models.EmailFailed
+		}
+		backoff := min(emailBaseBackoff*time.Duration(1<<min(attempts, 20)), emailMaxBackoff)
+		err = app.daos.RetryEmail(email.ID, err.Error(), now.Add(backoff), status)
+		if err != nil {
+			app.logger.Error(err.Error(), "emailId", email.ID)
+		}
+	}
+
+	return nil
+}
+
+func (app *Application) getQueuedEmails(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	pageNumber, err := app.readInt(query, "pageNumber", 1)
+	if err != nil {
+		app.logError(err, r)
+		app.writeBadRequestResponse(w, r)
+		return
+	}
+
+	pageSize, err := app.readInt(query, "pageSize", 20)
+	if err != nil {
+		app.logError(err, r)
+		app.writeBadRequestResponse(w, r)
+		return
+	}
+
+	var status *models.EmailStatus
+	if value := query.Get("status"); value != "" {
+		status = new(models.EmailStatus)
+		*status = models.EmailStatus(value)
+	}
+
+	validator := tools.NewRequestValidator()
+	validator.Check(pageNumber >= 1, "pageNumber", "must be at least 1")
+	validator.Check(pageSize >= 1 && pageSize <= 100, "pageSize", "must be between 1 and 100")
+	validator.Check(status == nil || status.IsValid(), "status", "must be PENDING, SENT, FAILED or BOUNCED")
+	if !validator.Valid() {
+		app.writeError(w, r, http.StatusBadRequest, http.StatusBadRequest, validator.Errors)
+		return
+	}
+
+	emails, err := app.daos.GetQueuedEmails(status, pageNumber, pageSize)
+	if err != nil {
+		app.logError(err, r)
+		app.writeInternalServerErrorResponse(w, r)
+		return
+	}
+
+	err = app.writeResponse(w, responseData{"emails": emails}, http.StatusOK, nil)
+	if err != nil {
+		app.logError(err, r)
+		app.writeInternalServerErrorResponse(w, r)
+	}
+}
+
+func (app *Application) getEmailDeliveryAttempts(w http.ResponseWriter, r *http.Request) {
+	emailId, err := app.readParam("emailId", r)
+	if err != nil {
+		app.logError(err, r)
+		app.writeBadRequestResponse(w, r)
+		return
+	}
+
+	attempts, err := app.daos.GetEmailDeliveryAttempts(*emailId)
+	if err != nil {
+		app.logError(err, r)
+		app.writeInternalServerErrorResponse(w, r)
+		return
+	}
+
+	err = app.writeResponse(w, responseData{"emailId": *emailId, "attempts": attempts}, http.StatusOK, nil)
+	if err != nil {
+		app.logError(err, r)
+		app.writeInternalServerErrorResponse(w, r)
+	}
+}
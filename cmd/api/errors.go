@@ -2,6 +2,8 @@ package main
 
 import (
 	"net/http"
+	"sportgether/constants"
+	"sportgether/internal/models"
 )
 
 func (app *Application) writeBadRequestResponse(w http.ResponseWriter, r *http.Request) {
@@ -26,3 +28,28 @@ func (app *Application) writeUserActivationRequiredResponse(w http.ResponseWrite
 func (app *Application) writeForceUpdateResponse(w http.ResponseWriter, r *http.Request) error {
 	return app.writeResponse(w, nil, http.StatusBadRequest, responseHeader{"x-sg-auth-forbidden": "APP_NOT_SUPPORTED"})
 }
+
+func (app *Application) writeSoftUpdateHeader(w http.ResponseWriter, recommendedVersion string) {
+	w.Header().Set("x-sg-soft-update", recommendedVersion)
+}
+
+func (app *Application) writeMaintenanceResponse(w http.ResponseWriter, r *http.Request, message string) {
+	w.Header().Set("x-sg-maintenance", "true")
+	app.writeError(w, r, http.StatusServiceUnavailable, constants.MaintenanceError.Code, message)
+}
+
+// writeHostingQuotaExceedResponse writes the error along with the hosting config, so that the app can tell the user
+// when the next slot frees up.
+func (app *Application) writeHostingQuotaExceedResponse(w http.ResponseWriter, r *http.Request, config *models.UserHostingConfigInfo) {
+	errContent := map[string]any{
+		"errorCode":         constants.HostingQuotaExceedError.Code,
+		"message":           constants.HostingQuotaExceedError.Error(),
+		"hostingConfigInfo": config,
+	}
+
+	err := app.writeResponse(w, responseData{"error": errContent}, http.StatusUnprocessableEntity, nil)
+	if err != nil {
+		app.logError(err, r)
+		w.WriteHeader(500)
+	}
+}
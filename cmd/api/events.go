@@ -5,9 +5,12 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"slices"
 	"sportgether/constants"
 	"sportgether/internal/models"
 	"sportgether/tools"
+	"strings"
+	"time"
 )
 
 func (app *Application) getAllEvents(w http.ResponseWriter, r *http.Request) {
@@ -15,7 +18,21 @@ func (app *Application) getAllEvents(w http.ResponseWriter, r *http.Request) {
 	err := app.readRequest(r, &filter)
 	if err != nil {
 		app.writeBadRequestResponse(w, r)
+		return
+	}
+
+	// Searching a retired sport would only find the past events, which are never listed anyway.
+	inactive, err := app.daos.GetInactiveSports(filter.EventTypes)
+	if err != nil {
+		app.logError(err, r)
+		app.writeInternalServerErrorResponse(w, r)
+		return
+	}
+	if len(inactive) > 0 {
+		app.writeError(w, r, http.StatusBadRequest, constants.SportConfigNotFoundError.Code, fmt.Sprintf("%s is not an available sport", strings.Join(inactive, ", ")))
+		return
 	}
+
 	user, ok := app.GetUserContext(r)
 	if !ok {
 		app.writeInvalidAuthenticationErrorResponse(w, r)
@@ -44,16 +61,10 @@ func (app *Application) getUserEvents(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	events, err := app.daos.GetUserEvents(user.ID)
+	events, err := app.daos.GetUserEvents(user.ID, time.Now())
 	if err != nil {
 		app.logError(err, r)
-
-		switch {
-		case errors.Is(err, constants.SportConfigNotFoundError):
-			app.writeBadRequestResponse(w, r)
-		default:
-			app.writeInternalServerErrorResponse(w, r)
-		}
+		app.writeInternalServerErrorResponse(w, r)
 		return
 	}
 
@@ -86,7 +97,7 @@ func (app *Application) updateEvent(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	host, ok := app.GetUserContext(r)
+	editor, ok := app.GetUserContext(r)
 	if !ok {
 		app.logError(errors.New("cannot get user object from request context"), r)
 		app.writeInternalServerErrorResponse(w, r)
@@ -94,13 +105,12 @@ func (app *Application) updateEvent(w http.ResponseWriter, r *http.Request) {
 	}
 	event := &models.Event{
 		ID:          *eventId,
-		HostId:      host.ID,
 		StartTime:   input.StartTime,
 		EndTime:     input.EndTime,
 		Description: input.Description,
 	}
 
-	err = app.daos.UpdateEvent(event)
+	err = app.daos.UpdateEvent(event, editor.ID)
 	if err != nil {
 		app.logError(err, r)
 		app.writeInternalServerErrorResponse(w, r)
@@ -115,14 +125,20 @@ func (app *Application) updateEvent(w http.ResponseWriter, r *http.Request) {
 
 func (app *Application) createEvent(w http.ResponseWriter, r *http.Request) {
 	input := struct {
-		EventName           string         `json:"eventName"`
-		StartTime           string         `json:"startTime"`
-		EndTime             string         `json:"endTime"`
-		Destination         string         `json:"destination"`
-		LongLat             models.GeoType `json:"longLat"`
-		EventType           string         `json:"eventType"`
-		MaxParticipantCount int            `json:"maxParticipantCount"`
-		Description         string         `json:"description"`
+		EventName           string                  `json:"eventName"`
+		StartTime           string                  `json:"startTime"`
+		EndTime             string                  `json:"endTime"`
+		Destination         string                  `json:"destination"`
+		LongLat             models.GeoType          `json:"longLat"`
+		EventType           string                  `json:"eventType"`
+		MaxParticipantCount int                     `json:"maxParticipantCount"`
+		Description         string                  `json:"description"`
+		ClubId              *int64                  `json:"clubId"`
+		Visibility          models.EventVisibility  `json:"visibility"`
+		PublicAt            *string                 `json:"publicAt"`
+		MinSkillLevel       *models.SkillLevel      `json:"minSkillLevel"`
+		MaxSkillLevel       *models.SkillLevel      `json:"maxSkillLevel"`
+		SkillRangePolicy    models.SkillRangePolicy `json:"skillRangePolicy"`
 	}{}
 
 	err := app.readRequest(r, &input)
@@ -132,12 +148,65 @@ func (app *Application) createEvent(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if input.Visibility == "" {
+		input.Visibility = models.PublicEvent
+	}
+	if input.SkillRangePolicy == "" {
+		input.SkillRangePolicy = models.WarnSkillRange
+	}
+	validator := tools.NewRequestValidator()
+	validator.Check(input.Visibility.IsValid(), "visibility", "must be PUBLIC, MEMBERS_ONLY or MEMBERS_FIRST")
+	validator.Check(input.ClubId != nil || input.Visibility == models.PublicEvent, "visibility", "must be PUBLIC when the event is not hosted for a club")
+	validator.Check((input.Visibility == models.ClubMembersFirstEvent) == (input.PublicAt != nil), "publicAt", "must be given only for MEMBERS_FIRST event")
+	if input.PublicAt != nil {
+		_, err = time.Parse(time.RFC3339, *input.PublicAt)
+		validator.Check(err == nil, "publicAt", "must be in RFC3339 format")
+	}
+	validator.Check(input.MinSkillLevel == nil || input.MinSkillLevel.IsValid(), "minSkillLevel", "must be BEGINNER, INTERMEDIATE or ADVANCED")
+	validator.Check(input.MaxSkillLevel == nil || input.MaxSkillLevel.IsValid(), "maxSkillLevel", "must be BEGINNER, INTERMEDIATE or ADVANCED")
+	validator.Check(input.MinSkillLevel == nil || models.InSkillRange(input.MinSkillLevel, nil, input.MaxSkillLevel), "maxSkillLevel", "must not be lower than minSkillLevel")
+	validator.Check(input.SkillRangePolicy.IsValid(), "skillRangePolicy", "must be WARN or BLOCK")
+	if !validator.Valid() {
+		app.writeError(w, r, http.StatusBadRequest, http.StatusBadRequest, validator.Errors)
+		return
+	}
+
+	sport, err := app.daos.GetActiveSport(input.EventType)
+	if err != nil {
+		switch {
+		case errors.Is(err, constants.SportConfigNotFoundError):
+			app.writeError(w, r, http.StatusBadRequest, constants.SportConfigNotFoundError.Code, fmt.Sprintf("%s is not an available sport", input.EventType))
+		default:
+			app.logError(err, r)
+			app.writeInternalServerErrorResponse(w, r)
+		}
+		return
+	}
+	// The capacity is left to the default of the sport when not given.
+	if input.MaxParticipantCount == 0 {
+		input.MaxParticipantCount = sport.DefaultMaxParticipantCount
+	}
+
 	host, ok := app.GetUserContext(r)
 	if !ok {
 		app.logError(errors.New("cannot get user object from request context"), r)
 		app.writeInternalServerErrorResponse(w, r)
 		return
 	}
+
+	// Only the club owner and admins can host on behalf of the club.
+	if input.ClubId != nil {
+		role, err := app.daos.GetClubMemberRole(*input.ClubId, host.ID)
+		if err != nil {
+			app.logError(err, r)
+			app.writeInternalServerErrorResponse(w, r)
+			return
+		}
+		if role == nil || !role.CanManage() {
+			app.writeError(w, r, http.StatusForbidden, constants.NotClubAdminError.Code, constants.NotClubAdminError.Error())
+			return
+		}
+	}
 	event := &models.Event{
 		EventName:           input.EventName,
 		HostId:              host.ID,
@@ -148,10 +217,25 @@ func (app *Application) createEvent(w http.ResponseWriter, r *http.Request) {
 		EventType:           input.EventType,
 		MaxParticipantCount: input.MaxParticipantCount,
 		Description:         input.Description,
+		ClubId:              input.ClubId,
+		Visibility:          input.Visibility,
+		PublicAt:            input.PublicAt,
+		MinSkillLevel:       input.MinSkillLevel,
+		MaxSkillLevel:       input.MaxSkillLevel,
+		SkillRangePolicy:    input.SkillRangePolicy,
 	}
 
 	// Create transaction
+	var hostingConfig *models.UserHostingConfigInfo
 	err = app.daos.WithTransaction(func(tx *sql.Tx) error {
+		hostingConfig, err = app.daos.GetUserHostingConfig(host.ID, tx)
+		if err != nil {
+			return err
+		}
+		if !hostingConfig.CanHost() {
+			return constants.HostingQuotaExceedError
+		}
+
 		err = app.daos.CreateEvent(event, tx)
 		if err != nil {
 			return err
@@ -162,18 +246,34 @@ func (app *Application) createEvent(w http.ResponseWriter, r *http.Request) {
 			return err
 		}
 
-		_, err = app.daos.UpdateUserHostingConfig(host.ID, true, tx)
+		// Let the other club members know about the new club event.
+		if event.ClubId == nil {
+			return nil
+		}
+		memberIds, err := app.daos.GetClubMemberIds(*event.ClubId, tx)
 		if err != nil {
 			return err
 		}
+		memberIds = slices.DeleteFunc(memberIds, func(memberId int64) bool {
+			return memberId == host.ID
+		})
 
-		return nil
+		return app.daos.EnqueueNotification(newEventCreatedNotification(event, memberIds), tx)
 	})
 
 	if err != nil {
-		app.logError(err, r)
-		app.writeInternalServerErrorResponse(w, r)
+		switch {
+		case errors.Is(err, constants.HostingQuotaExceedError):
+			app.writeHostingQuotaExceedResponse(w, r, hostingConfig)
+		default:
+			app.logError(err, r)
+			app.writeInternalServerErrorResponse(w, r)
+		}
+		return
 	}
+
+	app.metrics.eventsCreated.Inc()
+	app.dispatchNotificationsNow(r)
 }
 
 func (app *Application) getEventById(w http.ResponseWriter, r *http.Request) {
@@ -197,6 +297,10 @@ func (app *Application) getEventById(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if !app.canAccessEvent(w, r, event) {
+		return
+	}
+
 	err = app.writeResponse(w, event, http.StatusOK, nil)
 	if err != nil {
 		app.logError(err, r)
@@ -204,9 +308,20 @@ func (app *Application) getEventById(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// canAccessEvent writes 403 when the event is only open to the club members for now, and the user is not one of them.
+func (app *Application) canAccessEvent(w http.ResponseWriter, r *http.Request, detail *models.EventDetail) bool {
+	if detail.IsRestricted {
+		app.writeError(w, r, http.StatusForbidden, constants.ClubMembersOnlyError.Code, constants.ClubMembersOnlyError.Error())
+		return false
+	}
+	return true
+}
+
 func (app *Application) joinEvent(w http.ResponseWriter, r *http.Request) {
 	input := struct {
 		EventId int64 `json:"eventId"`
+		// AcceptSkillWarning is true when the user still joins after being warned of the skill range.
+		AcceptSkillWarning bool `json:"acceptSkillWarning"`
 	}{}
 	err := app.readRequest(r, &input)
 	if err != nil {
@@ -228,8 +343,74 @@ func (app *Application) joinEvent(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	banned, err := app.daos.IsUserBannedFromEvent(input.EventId, user.ID)
+	if err != nil {
+		app.logError(err, r)
+		app.writeInternalServerErrorResponse(w, r)
+		return
+	}
+	if banned {
+		app.writeError(w, r, http.StatusForbidden, constants.EventBannedError.Code, constants.EventBannedError.Error())
+		return
+	}
+
+	if !app.canAccessEvent(w, r, eventDetail) {
+		return
+	}
+
+	canJoin, err := app.daos.CanJoinMatchEvent(input.EventId, user.ID)
+	if err != nil {
+		app.logError(err, r)
+		app.writeInternalServerErrorResponse(w, r)
+		return
+	}
+	if !canJoin {
+		app.writeError(w, r, http.StatusForbidden, constants.MatchEntryMembersOnlyError.Code, constants.MatchEntryMembersOnlyError.Error())
+		return
+	}
+
+	if eventDetail.MinSkillLevel != nil || eventDetail.MaxSkillLevel != nil {
+		level, err := app.daos.GetUserSkillLevel(user.ID, eventDetail.EventType)
+		if err != nil {
+			app.logError(err, r)
+			app.writeInternalServerErrorResponse(w, r)
+			return
+		}
+		if !models.InSkillRange(level, eventDetail.MinSkillLevel, eventDetail.MaxSkillLevel) {
+			switch {
+			case eventDetail.SkillRangePolicy == models.BlockSkillRange:
+				app.writeError(w, r, http.StatusForbidden, constants.SkillLevelOutOfRangeError.Code, constants.SkillLevelOutOfRangeError.Error())
+				return
+			case !input.AcceptSkillWarning:
+				app.writeError(w, r, http.StatusConflict, constants.SkillLevelWarningError.Code, constants.SkillLevelWarningError.Error())
+				return
+			}
+		}
+	}
+
+	// The name shown to the other participants.
+	preferredName := user.UserName
+	detail, err := app.daos.GetProfileDetail(user.ID)
+	if err != nil {
+		app.logError(err, r)
+	} else if detail.PreferredName != nil {
+		preferredName = *detail.PreferredName
+	}
+
 	// Try join event
-	err = app.daos.JoinEventByParticipant(input.EventId, eventDetail.MaxParticipantCount, user.ID)
+	err = app.daos.WithTransaction(func(tx *sql.Tx) error {
+		err := app.daos.JoinEventByParticipant(input.EventId, eventDetail.MaxParticipantCount, user.ID, tx)
+		if err != nil {
+			return err
+		}
+
+		participantIds, err := app.daos.GetEventParticipantIds(input.EventId, tx)
+		if err != nil {
+			return err
+		}
+
+		return app.daos.EnqueueNotification(newEventJoinedNotification(input.EventId, preferredName, participantIds), tx)
+	})
 	if err != nil {
 		switch {
 		case errors.Is(err, constants.StaleInfoError):
@@ -243,16 +424,8 @@ func (app *Application) joinEvent(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	detail, err := app.daos.GetProfileDetail(user.ID)
-	if err != nil {
-		app.logError(err, r)
-		// Fail silently, so we don't want to affect the client.
-		return
-	}
-	err = app.broadCastEventJoinedMessage(r, input.EventId, *detail.PreferredName)
-	if err != nil {
-		app.logError(err, r)
-	}
+	app.metrics.eventJoins.Inc()
+	app.dispatchNotificationsNow(r)
 }
 
 func (app *Application) quitEvent(w http.ResponseWriter, r *http.Request) {
@@ -273,7 +446,10 @@ func (app *Application) quitEvent(w http.ResponseWriter, r *http.Request) {
 	if err != nil {
 		app.logError(err, r)
 		app.writeInternalServerErrorResponse(w, r)
+		return
 	}
+
+	app.metrics.eventQuits.Inc()
 }
 
 func (app *Application) deleteEvent(w http.ResponseWriter, r *http.Request) {
@@ -303,18 +479,26 @@ func (app *Application) deleteEvent(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = app.daos.EventDao.DeleteEvent(*value)
+	err = app.daos.WithTransaction(func(tx *sql.Tx) error {
+		err := app.daos.EventDao.DeleteEvent(*value, tx)
+		if err != nil {
+			return err
+		}
+
+		participantIds, err := app.daos.GetEventParticipantIds(*value, tx)
+		if err != nil {
+			return err
+		}
+
+		return app.daos.EnqueueNotification(newEventCancelledNotification(*value, detail.EventName, participantIds), tx)
+	})
 	if err != nil {
 		app.logError(err, r)
 		app.writeInternalServerErrorResponse(w, r)
 		return
 	}
 
-	err = app.broadcastEventDeletedMessage(r, *value, user.ID)
-	if err != nil {
-		app.logError(err, r)
-		// Fail silently
-	}
+	app.dispatchNotificationsNow(r)
 }
 
 func (app *Application) getEventHistory(w http.ResponseWriter, r *http.Request) {
@@ -380,12 +564,21 @@ func (app *Application) getMutualEventInfo(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
+	quitEventCount, err := app.daos.GetUserQuitEventCount(*otherUserId)
+	if err != nil {
+		app.logError(err, r)
+		app.writeInternalServerErrorResponse(w, r)
+		return
+	}
+
 	output := struct {
 		JoinedEventCount int `json:"joinedEventCount"`
 		MutualEventCount int `json:"mutualEventCount"`
+		QuitEventCount   int `json:"quitEventCount"`
 	}{
 		JoinedEventCount: joinedEventCount,
 		MutualEventCount: mutualEventCount,
+		QuitEventCount:   quitEventCount,
 	}
 
 	err = app.writeResponse(w, responseData{"mutualEventInfo": output}, http.StatusOK, nil)
@@ -416,7 +609,7 @@ func (app *Application) updateUserHostingConfigInfo(w http.ResponseWriter, r *ht
 		return
 	}
 
-	config, err := app.daos.UpdateUserHostingConfig(user.ID, false, nil)
+	config, err := app.daos.GetUserHostingConfig(user.ID, nil)
 	if err != nil {
 		app.logError(err, r)
 		app.writeInternalServerErrorResponse(w, r)
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"net/http"
+	remoteConfig "sportgether/remote_config"
+)
+
+// getFeatureFlags returns every flag evaluated for the caller, so that the app can dark-launch the features. It is
+// open to the unauthenticated callers too, which only get the flags rolled out to everyone.
+func (app *Application) getFeatureFlags(w http.ResponseWriter, r *http.Request) {
+	flags := app.remoteConfig.Current().EvaluateFlags(app.flagContext(r))
+
+	err := app.writeResponse(w, responseData{"flags": flags}, http.StatusOK, nil)
+	if err != nil {
+		app.logError(err, r)
+		app.writeInternalServerErrorResponse(w, r)
+	}
+}
+
+// isFlagOn tells the handlers whether the feature is on for the caller of the request.
+func (app *Application) isFlagOn(r *http.Request, name string) bool {
+	return app.remoteConfig.Current().IsFlagOn(name, app.flagContext(r))
+}
+
+// flagContext is the caller of the request, where the app sends its version in Curr-Version and its platform, i.e.
+// IOS, ANDROID or WEB, in Curr-Platform, see requestPlatform.
+func (app *Application) flagContext(r *http.Request) remoteConfig.FlagContext {
+	ctx := remoteConfig.FlagContext{
+		AppVersion: r.Header.Get("Curr-Version"),
+		Platform:   requestPlatform(r),
+	}
+
+	user, ok := app.GetUserContext(r)
+	if ok && !user.UnauthenticatedUser() {
+		ctx.UserId = &user.ID
+	}
+
+	return ctx
+}
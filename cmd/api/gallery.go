@@ -0,0 +1,323 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"sportgether/constants"
+	"sportgether/internal/models"
+	"sportgether/tools"
+	"time"
+)
+
+const (
+	maxEventPhotoSize = 10 << 20
+	// uploadTimeout is how long the upload of a photo may take, see allowSlowUpload.
+	uploadTimeout = 2 * time.Minute
+)
+
+var (
+	supportedPhotoContentTypes = []string{"image/jpeg", "image/png", "image/webp", "image/gif"}
+)
+
+func (app *Application) uploadEventPhoto(w http.ResponseWriter, r *http.Request) {
+	eventId, err := app.readParam("eventId", r)
+	if err != nil {
+		app.logError(err, r)
+		app.writeBadRequestResponse(w, r)
+		return
+	}
+
+	user, ok := app.GetUserContext(r)
+	if !ok {
+		app.writeInvalidAuthenticationErrorResponse(w, r)
+		return
+	}
+
+	detail, err := app.daos.GetEventById(*eventId, user.ID)
+	if err != nil {
+		app.logError(err, r)
+		app.writeBadRequestResponse(w, r)
+		return
+	}
+
+	if !detail.IsJoined {
+		app.writeError(w, r, http.StatusForbidden, constants.NotEventParticipantError.Code, constants.NotEventParticipantError.Error())
+		return
+	}
+
+	if detail.IsCancelled() {
+		app.writeError(w, r, http.StatusUnprocessableEntity, constants.EventNotEditableError.Code, constants.EventNotEditableError.Error())
+		return
+	}
+
+	endTime, err := time.Parse(time.RFC3339Nano, detail.EndTime)
+	if err != nil {
+		app.logError(err, r)
+		app.writeInternalServerErrorResponse(w, r)
+		return
+	}
+	if endTime.After(time.Now()) {
+		app.writeError(w, r, http.StatusUnprocessableEntity, constants.EventNotEndedError.Code, constants.EventNotEndedError.Error())
+		return
+	}
+
+	content, err := app.readPhoto(w, r, "photo")
+	if err != nil {
+		switch {
+		case errors.Is(err, constants.MediaTooLargeError):
+			app.writeError(w, r, http.StatusRequestEntityTooLarge, constants.MediaTooLargeError.Code, constants.MediaTooLargeError.Error())
+		case errors.Is(err, constants.UnsupportedMediaError):
+			app.writeError(w, r, http.StatusUnsupportedMediaType, constants.UnsupportedMediaError.Code, constants.UnsupportedMediaError.Error())
+		default:
+			app.logError(err, r)
+			app.writeBadRequestResponse(w, r)
+		}
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	stored, err := app.mediaStorage.Upload(ctx, fmt.Sprintf("sportgether/events/%d", *eventId), content)
+	if err != nil {
+		app.logError(err, r)
+		app.writeInternalServerErrorResponse(w, r)
+		return
+	}
+
+	photo := &models.EventPhoto{
+		EventId:    *eventId,
+		UploaderId: user.ID,
+		PublicId:   stored.PublicId,
+		Url:        stored.Url,
+	}
+	err = app.daos.InsertEventPhoto(photo)
+	if err != nil {
+		app.logError(err, r)
+		app.writeInternalServerErrorResponse(w, r)
+
+		// Do not leave the uploaded photo behind.
+		err = app.daos.EnqueueMediaCleanup(stored.PublicId, nil)
+		if err != nil {
+			app.logError(err, r)
+		}
+		return
+	}
+
+	err = app.writeResponse(w, responseData{"photo": photo}, http.StatusCreated, nil)
+	if err != nil {
+		app.logError(err, r)
+		app.writeInternalServerErrorResponse(w, r)
+	}
+}
+
+func (app *Application) getEventPhotos(w http.ResponseWriter, r *http.Request) {
+	eventId, err := app.readParam("eventId", r)
+	if err != nil {
+		app.logError(err, r)
+		app.writeBadRequestResponse(w, r)
+		return
+	}
+
+	query := r.URL.Query()
+	pageNumber, err := app.readInt(query, "pageNumber", 1)
+	if err != nil {
+		app.logError(err, r)
+		app.writeBadRequestResponse(w, r)
+		return
+	}
+
+	pageSize, err := app.readInt(query, "pageSize", 20)
+	if err != nil {
+		app.logError(err, r)
+		app.writeBadRequestResponse(w, r)
+		return
+	}
+
+	validator := tools.NewRequestValidator()
+	validator.Check(pageNumber >= 1, "pageNumber", "must be at least 1")
+	validator.Check(pageSize >= 1 && pageSize <= 100, "pageSize", "must be between 1 and 100")
+	if !validator.Valid() {
+		app.writeError(w, r, http.StatusBadRequest, http.StatusBadRequest, validator.Errors)
+		return
+	}
+
+	user, ok := app.GetUserContext(r)
+	if !ok {
+		app.writeInvalidAuthenticationErrorResponse(w, r)
+		return
+	}
+
+	detail, err := app.daos.GetEventById(*eventId, user.ID)
+	if err != nil {
+		app.logError(err, r)
+		app.writeBadRequestResponse(w, r)
+		return
+	}
+
+	if !app.canAccessEvent(w, r, detail) {
+		return
+	}
+
+	photos, err := app.daos.GetEventPhotos(*eventId, detail.CanManage(), pageNumber, pageSize)
+	if err != nil {
+		app.logError(err, r)
+		app.writeInternalServerErrorResponse(w, r)
+		return
+	}
+
+	err = app.writeResponse(w, responseData{"photos": photos}, http.StatusOK, nil)
+	if err != nil {
+		app.logError(err, r)
+		app.writeInternalServerErrorResponse(w, r)
+	}
+}
+
+func (app *Application) deleteEventPhoto(w http.ResponseWriter, r *http.Request) {
+	detail, photo, ok := app.readEventPhoto(w, r)
+	if !ok {
+		return
+	}
+
+	user, _ := app.GetUserContext(r)
+
+	// The uploader can delete their own photo, while the host and co-hosts can delete any photo.
+	if photo.UploaderId != user.ID && !detail.CanManage() {
+		app.writeError(w, r, http.StatusForbidden, constants.NotEventHostError.Code, constants.NotEventHostError.Error())
+		return
+	}
+
+	// The photo is removed from the storage in background.
+	err := app.daos.WithTransaction(func(tx *sql.Tx) error {
+		err := app.daos.DeleteEventPhoto(photo.ID, tx)
+		if err != nil {
+			return err
+		}
+
+		return app.daos.EnqueueMediaCleanup(photo.PublicId, tx)
+	})
+	if err != nil {
+		app.logError(err, r)
+		app.writeInternalServerErrorResponse(w, r)
+	}
+}
+
+func (app *Application) updateEventPhotoVisibility(w http.ResponseWriter, r *http.Request) {
+	input := struct {
+		Hidden bool `json:"hidden"`
+	}{}
+	err := app.readRequest(r, &input)
+	if err != nil {
+		app.logError(err, r)
+		app.writeBadRequestResponse(w, r)
+		return
+	}
+
+	detail, photo, ok := app.readEventPhoto(w, r)
+	if !ok {
+		return
+	}
+
+	if !detail.CanManage() {
+		app.writeError(w, r, http.StatusForbidden, constants.NotEventHostError.Code, constants.NotEventHostError.Error())
+		return
+	}
+
+	status := models.EventPhotoVisible
+	if input.Hidden {
+		status = models.EventPhotoHidden
+	}
+
+	err = app.daos.UpdateEventPhotoStatus(photo.ID, status)
+	if err != nil {
+		app.logError(err, r)
+		app.writeInternalServerErrorResponse(w, r)
+	}
+}
+
+// readEventPhoto looks up the event and the photo from the url params. The error response is written when it is not ok.
+func (app *Application) readEventPhoto(w http.ResponseWriter, r *http.Request) (*models.EventDetail, *models.EventPhoto, bool) {
+	eventId, err := app.readParam("eventId", r)
+	if err != nil {
+		app.logError(err, r)
+		app.writeBadRequestResponse(w, r)
+		return nil, nil, false
+	}
+
+	photoId, err := app.readParam("photoId", r)
+	if err != nil {
+		app.logError(err, r)
+		app.writeBadRequestResponse(w, r)
+		return nil, nil, false
+	}
+
+	user, ok := app.GetUserContext(r)
+	if !ok {
+		app.writeInvalidAuthenticationErrorResponse(w, r)
+		return nil, nil, false
+	}
+
+	detail, err := app.daos.GetEventById(*eventId, user.ID)
+	if err != nil {
+		app.logError(err, r)
+		app.writeBadRequestResponse(w, r)
+		return nil, nil, false
+	}
+
+	photo, err := app.daos.GetEventPhoto(*eventId, *photoId)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			app.notFound(w, r)
+		default:
+			app.logError(err, r)
+			app.writeInternalServerErrorResponse(w, r)
+		}
+		return nil, nil, false
+	}
+
+	return detail, photo, true
+}
+
+// readPhoto reads the image from the multipart form field, after checking its size and format.
+func (app *Application) readPhoto(w http.ResponseWriter, r *http.Request, field string) (io.Reader, error) {
+	// Leave some room for the other parts of the form.
+	r.Body = http.MaxBytesReader(w, r.Body, maxEventPhotoSize+(1<<20))
+	err := r.ParseMultipartForm(maxEventPhotoSize)
+	if err != nil {
+		var maxBytesError *http.MaxBytesError
+		if errors.As(err, &maxBytesError) {
+			return nil, constants.MediaTooLargeError
+		}
+		return nil, err
+	}
+
+	file, header, err := r.FormFile(field)
+	if err != nil {
+		return nil, err
+	}
+
+	if header.Size > maxEventPhotoSize {
+		return nil, constants.MediaTooLargeError
+	}
+
+	// Do not trust the content type sent by the client.
+	head := make([]byte, 512)
+	n, err := io.ReadFull(file, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return nil, err
+	}
+	head = head[:n]
+
+	if !slices.Contains(supportedPhotoContentTypes, http.DetectContentType(head)) {
+		return nil, constants.UnsupportedMediaError
+	}
+
+	return io.MultiReader(bytes.NewReader(head), file), nil
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"sportgether/constants"
+	"sportgether/internal/models"
+	"sportgether/tools"
+	"time"
+)
+
+// getHostingQuota shows the admins the assignment of the user, along with the quota applied.
+func (app *Application) getHostingQuota(w http.ResponseWriter, r *http.Request) {
+	userId, ok := app.readHostingQuotaUser(w, r)
+	if !ok {
+		return
+	}
+
+	app.writeHostingQuota(w, r, userId)
+}
+
+// updateHostingQuota assigns the tier, and overrides the max count of the tier until the expiry. The fields not given
+// are kept, and clearOverride removes the override.
+func (app *Application) updateHostingQuota(w http.ResponseWriter, r *http.Request) {
+	input := struct {
+		Tier             *models.HostingTier `json:"tier"`
+		MaxCountOverride *int                `json:"maxCountOverride"`
+		OverrideExpireAt *string             `json:"overrideExpireAt"`
+		OverrideNote     *string             `json:"overrideNote"`
+		ClearOverride    bool                `json:"clearOverride"`
+	}{}
+	err := app.readRequest(r, &input)
+	if err != nil {
+		app.logError(err, r)
+		app.writeBadRequestResponse(w, r)
+		return
+	}
+
+	var overrideExpireAt time.Time
+	validator := tools.NewRequestValidator()
+	validator.Check(input.Tier == nil || input.Tier.IsValid(), "tier", "must be NEW, VERIFIED, TRUSTED_HOST or CLUB_ADMIN")
+	validator.Check(input.MaxCountOverride == nil || *input.MaxCountOverride >= 0, "maxCountOverride", "must not be negative")
+	if input.OverrideExpireAt != nil {
+		overrideExpireAt, err = time.Parse(time.RFC3339, *input.OverrideExpireAt)
+		validator.Check(err == nil, "overrideExpireAt", "must be in RFC3339 format")
+	}
+	validator.Check(!input.ClearOverride || (input.MaxCountOverride == nil && input.OverrideExpireAt == nil), "clearOverride", "must not be given along with the override")
+	if !validator.Valid() {
+		app.writeError(w, r, http.StatusBadRequest, http.StatusBadRequest, validator.Errors)
+		return
+	}
+
+	userId, ok := app.readHostingQuotaUser(w, r)
+	if !ok {
+		return
+	}
+
+	assignment, err := app.daos.GetHostingQuotaAssignment(userId)
+	if err != nil {
+		app.logError(err, r)
+		app.writeInternalServerErrorResponse(w, r)
+		return
+	}
+
+	if input.Tier != nil {
+		assignment.Tier = *input.Tier
+	}
+	if input.MaxCountOverride != nil {
+		assignment.MaxCountOverride = input.MaxCountOverride
+	}
+	if input.OverrideExpireAt != nil {
+		assignment.OverrideExpireAt = &overrideExpireAt
+	}
+	if input.OverrideNote != nil {
+		assignment.OverrideNote = input.OverrideNote
+	}
+	if input.ClearOverride {
+		assignment.MaxCountOverride = nil
+		assignment.OverrideExpireAt = nil
+		assignment.OverrideNote = nil
+	}
+
+	err = app.daos.UpdateHostingQuotaAssignment(assignment)
+	if err != nil {
+		app.logError(err, r)
+		app.writeInternalServerErrorResponse(w, r)
+		return
+	}
+
+	app.writeHostingQuota(w, r, userId)
+}
+
+// readHostingQuotaUser reads the user of the :userId param, and writes 404 when there is no such user.
+func (app *Application) readHostingQuotaUser(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	userId, err := app.readParam("userId", r)
+	if err != nil {
+		app.logError(err, r)
+		app.writeBadRequestResponse(w, r)
+		return 0, false
+	}
+
+	_, err = app.daos.UserDao.GetById(*userId)
+	if err != nil {
+		switch {
+		case errors.Is(err, constants.UserNotFoundError):
+			app.writeError(w, r, http.StatusNotFound, constants.UserNotFoundError.Code, constants.UserNotFoundError.Error())
+		default:
+			app.logError(err, r)
+			app.writeInternalServerErrorResponse(w, r)
+		}
+		return 0, false
+	}
+
+	return *userId, true
+}
+
+func (app *Application) writeHostingQuota(w http.ResponseWriter, r *http.Request, userId int64) {
+	assignment, err := app.daos.GetHostingQuotaAssignment(userId)
+	if err != nil {
+		app.logError(err, r)
+		app.writeInternalServerErrorResponse(w, r)
+		return
+	}
+
+	config, err := app.daos.GetUserHostingConfig(userId, nil)
+	if err != nil {
+		app.logError(err, r)
+		app.writeInternalServerErrorResponse(w, r)
+		return
+	}
+
+	err = app.writeResponse(w, responseData{"assignment": assignment, "hostingConfigInfo": config}, http.StatusOK, nil)
+	if err != nil {
+		app.logError(err, r)
+		app.writeInternalServerErrorResponse(w, r)
+	}
+}
@@ -0,0 +1,277 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"sportgether/constants"
+	"sportgether/internal/models"
+	"sportgether/tools"
+	"unicode/utf8"
+)
+
+func (app *Application) addEventCoHost(w http.ResponseWriter, r *http.Request) {
+	input := struct {
+		EventId int64 `json:"eventId"`
+		UserId  int64 `json:"userId"`
+	}{}
+	err := app.readRequest(r, &input)
+	if err != nil {
+		app.logError(err, r)
+		app.writeBadRequestResponse(w, r)
+		return
+	}
+
+	user, ok := app.GetUserContext(r)
+	if !ok {
+		app.writeInvalidAuthenticationErrorResponse(w, r)
+		return
+	}
+
+	detail, err := app.daos.GetEventById(input.EventId, user.ID)
+	if err != nil {
+		app.logError(err, r)
+		app.writeBadRequestResponse(w, r)
+		return
+	}
+
+	// Only the host can appoint co-hosts
+	if !detail.IsHost {
+		app.writeError(w, r, http.StatusForbidden, constants.NotEventHostError.Code, constants.NotEventHostError.Error())
+		return
+	}
+
+	if input.UserId == detail.HostId || !detail.HasParticipant(input.UserId) {
+		app.writeError(w, r, http.StatusUnprocessableEntity, constants.NotEventParticipantError.Code, constants.NotEventParticipantError.Error())
+		return
+	}
+
+	err = app.daos.AddCoHost(input.EventId, input.UserId)
+	if err != nil {
+		app.logError(err, r)
+		app.writeInternalServerErrorResponse(w, r)
+	}
+}
+
+func (app *Application) removeEventCoHost(w http.ResponseWriter, r *http.Request) {
+	eventId, err := app.readParam("eventId", r)
+	if err != nil {
+		app.logError(err, r)
+		app.writeBadRequestResponse(w, r)
+		return
+	}
+
+	coHostId, err := app.readParam("userId", r)
+	if err != nil {
+		app.logError(err, r)
+		app.writeBadRequestResponse(w, r)
+		return
+	}
+
+	user, ok := app.GetUserContext(r)
+	if !ok {
+		app.writeInvalidAuthenticationErrorResponse(w, r)
+		return
+	}
+
+	detail, err := app.daos.GetEventById(*eventId, user.ID)
+	if err != nil {
+		app.logError(err, r)
+		app.writeBadRequestResponse(w, r)
+		return
+	}
+
+	// Host can remove any co-host, while co-host can only step down by themselves.
+	if !detail.IsHost && !(detail.IsCoHost && user.ID == *coHostId) {
+		app.writeError(w, r, http.StatusForbidden, constants.NotEventHostError.Code, constants.NotEventHostError.Error())
+		return
+	}
+
+	err = app.daos.RemoveCoHost(*eventId, *coHostId)
+	if err != nil {
+		app.logError(err, r)
+		app.writeInternalServerErrorResponse(w, r)
+	}
+}
+
+func (app *Application) transferEventHost(w http.ResponseWriter, r *http.Request) {
+	eventId, err := app.readParam("eventId", r)
+	if err != nil {
+		app.logError(err, r)
+		app.writeBadRequestResponse(w, r)
+		return
+	}
+
+	input := struct {
+		NewHostId int64 `json:"newHostId"`
+	}{}
+	err = app.readRequest(r, &input)
+	if err != nil {
+		app.logError(err, r)
+		app.writeBadRequestResponse(w, r)
+		return
+	}
+
+	user, ok := app.GetUserContext(r)
+	if !ok {
+		app.writeInvalidAuthenticationErrorResponse(w, r)
+		return
+	}
+
+	detail, err := app.daos.GetEventById(*eventId, user.ID)
+	if err != nil {
+		app.logError(err, r)
+		app.writeBadRequestResponse(w, r)
+		return
+	}
+
+	if !detail.IsHost {
+		app.logError(errors.New(fmt.Sprintf("this user = %d cannot transfer this event as no authority right", user.ID)), r)
+		app.writeError(w, r, http.StatusForbidden, constants.NotEventHostError.Code, constants.NotEventHostError.Error())
+		return
+	}
+
+	if detail.IsCancelled() {
+		app.writeError(w, r, http.StatusUnprocessableEntity, constants.EventNotEditableError.Code, constants.EventNotEditableError.Error())
+		return
+	}
+
+	if input.NewHostId == user.ID || !detail.HasParticipant(input.NewHostId) {
+		app.writeError(w, r, http.StatusUnprocessableEntity, constants.NotEventParticipantError.Code, constants.NotEventParticipantError.Error())
+		return
+	}
+
+	// The slot goes along with the event, from the old host to the new host, so the new host must have one.
+	var newHostConfig *models.UserHostingConfigInfo
+	err = app.daos.WithTransaction(func(tx *sql.Tx) error {
+		newHostConfig, err = app.daos.GetUserHostingConfig(input.NewHostId, tx)
+		if err != nil {
+			return err
+		}
+		if !newHostConfig.CanHost() {
+			return constants.HostingQuotaExceedError
+		}
+
+		return app.daos.TransferHost(*eventId, user.ID, input.NewHostId, tx)
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, constants.HostingQuotaExceedError):
+			app.writeHostingQuotaExceedResponse(w, r, newHostConfig)
+		case errors.Is(err, constants.EventNotEditableError):
+			app.writeError(w, r, http.StatusUnprocessableEntity, constants.EventNotEditableError.Code, constants.EventNotEditableError.Error())
+		default:
+			app.logError(err, r)
+			app.writeInternalServerErrorResponse(w, r)
+		}
+		return
+	}
+
+	// The name shown to the other participants.
+	var newHostName string
+	for _, participant := range detail.Participants {
+		if participant.ParticipantId == input.NewHostId {
+			newHostName = participant.ParticipantUsername
+		}
+	}
+	newHost, err := app.daos.GetProfileDetail(input.NewHostId)
+	if err != nil {
+		app.logError(err, r)
+	} else if newHost.PreferredName != nil {
+		newHostName = *newHost.PreferredName
+	}
+
+	err = app.broadCastEventHostTransferredMessage(r, *eventId, newHostName)
+	if err != nil {
+		app.logError(err, r)
+	}
+}
+
+func (app *Application) kickEventParticipant(w http.ResponseWriter, r *http.Request) {
+	input := struct {
+		EventId  int64                `json:"eventId"`
+		UserId   int64                `json:"userId"`
+		Reason   *string              `json:"reason"`
+		BanScope models.EventBanScope `json:"banScope"`
+	}{}
+	err := app.readRequest(r, &input)
+	if err != nil {
+		app.logError(err, r)
+		app.writeBadRequestResponse(w, r)
+		return
+	}
+
+	if input.BanScope == "" {
+		input.BanScope = models.NoBan
+	}
+
+	validator := tools.NewRequestValidator()
+	validator.Check(input.BanScope.IsValid(), "banScope", "must be one of NONE, EVENT or HOST")
+	validator.Check(input.Reason == nil || utf8.RuneCountInString(*input.Reason) <= 200, "reason", "must not be more than 200 chars")
+	if !validator.Valid() {
+		app.writeError(w, r, http.StatusBadRequest, http.StatusBadRequest, validator.Errors)
+		return
+	}
+
+	user, ok := app.GetUserContext(r)
+	if !ok {
+		app.writeInvalidAuthenticationErrorResponse(w, r)
+		return
+	}
+
+	detail, err := app.daos.GetEventById(input.EventId, user.ID)
+	if err != nil {
+		app.logError(err, r)
+		app.writeBadRequestResponse(w, r)
+		return
+	}
+
+	if !detail.CanManage() {
+		app.writeError(w, r, http.StatusForbidden, constants.NotEventHostError.Code, constants.NotEventHostError.Error())
+		return
+	}
+
+	// Nobody can kick the host, and only the host can kick a co-host.
+	if input.UserId == detail.HostId || input.UserId == user.ID || (!detail.IsHost && detail.HasCoHost(input.UserId)) {
+		app.writeError(w, r, http.StatusForbidden, constants.NotEventHostError.Code, constants.NotEventHostError.Error())
+		return
+	}
+
+	err = app.daos.WithTransaction(func(tx *sql.Tx) error {
+		err := app.daos.KickParticipant(input.EventId, input.UserId, user.ID, input.Reason, tx)
+		if err != nil {
+			return err
+		}
+
+		if input.BanScope == models.NoBan {
+			return nil
+		}
+
+		ban := &models.EventBan{
+			HostId:       detail.HostId,
+			BannedUserId: input.UserId,
+			Reason:       input.Reason,
+		}
+		if input.BanScope == models.EventOnlyBan {
+			ban.EventId = &input.EventId
+		}
+
+		return app.daos.BanUser(ban, tx)
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, constants.NotEventParticipantError):
+			app.writeError(w, r, http.StatusUnprocessableEntity, constants.NotEventParticipantError.Code, constants.NotEventParticipantError.Error())
+		default:
+			app.logError(err, r)
+			app.writeInternalServerErrorResponse(w, r)
+		}
+		return
+	}
+
+	err = app.sendEventKickedMessage(r, input.EventId, input.UserId, detail.EventName, input.Reason)
+	if err != nil {
+		app.logError(err, r)
+	}
+}
@@ -2,6 +2,7 @@ package main // "main" indicating this is main app, not library. Other files can
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"flag"
 	"log"
 	"log/slog"
@@ -9,97 +10,111 @@ import (
 	"sportgether/internal/models"
 	"sync"
 	"time"
+	// The timezones of the notification quiet hours, as the image may not have the tz database.
+	_ "time/tzdata"
 
 	"sportgether/internal/mailer"
+	"sportgether/internal/media"
+	"sportgether/internal/push"
+	"sportgether/internal/scheduler"
+	remoteConfig "sportgether/remote_config"
 
 	firebase "firebase.google.com/go/v4"
 	"github.com/cloudinary/cloudinary-go/v2"
-	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
 	"google.golang.org/api/option"
 )
 
-type sslCertConfig struct {
-	certPath string
-	certKey  string
-}
-
-type config struct {
-	port     int
-	env      string
-	dbConfig struct {
-		dsn               string
-		maxOpenConnection int
-		maxIdleConnection int
-		maxIdleTime       time.Duration
-	}
-	smtp struct {
-		Host     string `json:"host"`
-		Port     int    `json:"port"`
-		Username string `json:"username"`
-		Password string `json:"password"`
-		Sender   string `json:"sender"`
-	}
-}
-
-func (c config) getCertConfig() sslCertConfig {
-	if c.env == "PRD" {
-		return sslCertConfig{
-			certPath: "./cert/prd_ca_cert.pem",
-			certKey:  "./cert/prd_ca_key.pem",
-		}
-	} else {
-		return sslCertConfig{
-			certPath: "cert.crt",
-			certKey:  "cert.key",
-		}
-	}
-}
-
 type Application struct {
-	config        config
-	logger        *slog.Logger
-	daos          models.Daos
-	firebaseApp   *firebase.App
-	cloudinaryApp *cloudinary.Cloudinary
-	mailer        mailer.Mailer
-	wg            sync.WaitGroup
+	config       config
+	logger       *slog.Logger
+	daos         models.Daos
+	pushSender   push.Sender
+	mediaStorage media.Storage
+	mailer       mailer.Mailer
+	remoteConfig *remoteConfig.Store
+	scheduler    *scheduler.Scheduler
+	metrics      *appMetrics
+	wg           sync.WaitGroup
 }
 
 func main() {
-	config := config{}
 	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
-	cld := credentials()
 
-	flag.IntVar(&config.port, "port", 3000, "Port for listening")
-	config.env = os.Getenv("ENV")
-	if config.env == "" {
-		config.env = "DEV"
+	config, err := loadConfig(os.Args[1:], os.LookupEnv)
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return
+		}
+		logger.Error(err.Error())
+		os.Exit(1)
+	}
+	if config.printConfig {
+		err = config.print(os.Stdout)
+		if err != nil {
+			logger.Error(err.Error())
+			os.Exit(1)
+		}
+	}
+	err = config.validate()
+	if err != nil {
+		logger.Error(err.Error())
+		os.Exit(1)
+	}
+	if config.printConfig {
+		return
 	}
 
-	opt := option.WithCredentialsFile("./data/service-account-file.json")
+	opt := option.WithCredentialsFile(config.firebase.credentialsFile)
 	firebaseApp, err := firebase.NewApp(context.Background(), nil, opt)
 	if err != nil {
 		log.Fatalf("error initializing app: %v\n", err)
 	}
+	pushSender, err := firebaseApp.Messaging(context.Background())
+	if err != nil {
+		log.Fatalf("error initializing messaging: %v\n", err)
+	}
+
+	metricSet := newAppMetrics()
 
-	flag.Parse()
+	db, err := config.openDatabase(queryTracer{duration: metricSet.dbQueryDuration})
+	if err != nil {
+		logger.Error(err.Error())
+		os.Exit(1)
+	}
+
+	mailSender, err := initMailer(config)
+	if err != nil {
+		logger.Error(err.Error())
+		os.Exit(1)
+	}
 
-	db, err := config.openDatabase()
+	configStore, err := remoteConfig.NewStore("./data", logger)
 	if err != nil {
 		logger.Error(err.Error())
 		os.Exit(1)
 	}
 
-	initSmtpConfig(&config)
+	mediaStorage, err := initMediaStorage(config)
+	if err != nil {
+		logger.Error(err.Error())
+		os.Exit(1)
+	}
 
 	app := Application{
-		config:        config,
-		logger:        logger,
-		daos:          models.NewDaoHandler(db),
-		firebaseApp:   firebaseApp,
-		cloudinaryApp: cld,
-		mailer:        mailer.New(config.smtp.Host, config.smtp.Port, config.smtp.Username, config.smtp.Password, config.smtp.Sender),
+		config:       *config,
+		logger:       logger,
+		daos:         models.NewDaoHandler(db, configStore),
+		pushSender:   pushSender,
+		mediaStorage: mediaStorage,
+		mailer:       mailSender,
+		remoteConfig: configStore,
+		scheduler:    scheduler.New(scheduler.SystemClock{}, logger),
+		metrics:      metricSet,
 	}
+	app.collectDatabase(db)
+	app.registerScheduledJobs()
 
 	err = app.serve()
 	if err != nil {
@@ -108,27 +123,30 @@ func main() {
 	}
 }
 
-func initSmtpConfig(c *config) {
-	var path string
-	if c.isProd() {
-		path = "./data/smtp_config_prod"
-	} else {
-		path = "./data/smtp_config_dev"
+// Cloudinary is configured by media.cloudinary-url. Without it, media is kept in the local file system, which is
+// enough for development, and is never allowed in PRD, see validate.
+func initMediaStorage(c *config) (media.Storage, error) {
+	if c.cloudinaryUrl == "" {
+		return media.NewLocalStorage("./media", "/media", "/v1/media/local-upload")
 	}
-	readJsonFromFile(path, &c.smtp)
-}
 
-func credentials() *cloudinary.Cloudinary {
-	cld, _ := cloudinary.New()
+	cld, err := cloudinary.NewFromURL(c.cloudinaryUrl)
+	if err != nil {
+		return nil, err
+	}
 	cld.Config.URL.Secure = true
-	return cld
+
+	return media.NewCloudinaryStorage(cld), nil
 }
 
-func (cfg config) openDatabase() (*sql.DB, error) {
-	db, err := sql.Open("pgx", os.Getenv("DATABASE_URL"))
+// openDatabase traces the queries of the pool by tracer, for their durations to be measured.
+func (cfg config) openDatabase(tracer pgx.QueryTracer) (*sql.DB, error) {
+	connConfig, err := pgx.ParseConfig(cfg.dbConfig.dsn)
 	if err != nil {
 		return nil, err
 	}
+	connConfig.Tracer = tracer
+	db := stdlib.OpenDB(*connConfig)
 
 	db.SetMaxOpenConns(cfg.dbConfig.maxOpenConnection)
 	db.SetMaxIdleConns(cfg.dbConfig.maxIdleConnection)
@@ -144,7 +162,3 @@ func (cfg config) openDatabase() (*sql.DB, error) {
 
 	return db, nil
 }
-
-func (c config) isProd() bool {
-	return c.env == "PRD"
-}
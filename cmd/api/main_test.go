@@ -0,0 +1,203 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sportgether/internal/models"
+	"sportgether/internal/push"
+	"sportgether/internal/scheduler"
+	remoteConfig "sportgether/remote_config"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// The tests against the database need TEST_DATABASE_URL, e.g. postgres://localhost/sportgether_test, with PostGIS.
+// The schema is dropped and deployed again by each test, so never point it to a database with data to keep.
+const testDatabaseEnv = "TEST_DATABASE_URL"
+
+// testDatabaseMu keeps the tests from deploying the schema of each other.
+var testDatabaseMu sync.Mutex
+
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (clock *fakeClock) Now() time.Time {
+	clock.mu.Lock()
+	defer clock.mu.Unlock()
+	return clock.now
+}
+
+func (clock *fakeClock) Set(now time.Time) {
+	clock.mu.Lock()
+	defer clock.mu.Unlock()
+	clock.now = now
+}
+
+// newTestDatabase deploys the schema from the sqitch plan to the database of TEST_DATABASE_URL, and skips the test
+// without it.
+func newTestDatabase(t *testing.T) *sql.DB {
+	t.Helper()
+
+	dsn, ok := os.LookupEnv(testDatabaseEnv)
+	if !ok {
+		t.Skipf("%s is not given", testDatabaseEnv)
+	}
+
+	testDatabaseMu.Lock()
+	t.Cleanup(testDatabaseMu.Unlock)
+
+	cfg := config{}
+	cfg.dbConfig.dsn = dsn
+	cfg.dbConfig.maxOpenConnection = 5
+	cfg.dbConfig.maxIdleConnection = 5
+	cfg.dbConfig.maxIdleTime = time.Minute
+	db, err := cfg.openDatabase(queryTracer{duration: newAppMetrics().dbQueryDuration})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		db.Close()
+	})
+
+	_, err = db.Exec(`DROP SCHEMA IF EXISTS sportgether_schema CASCADE`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, change := range readSqitchPlan(t) {
+		script, err := os.ReadFile(filepath.Join("..", "..", "database", "deploy", change+".sql"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		// Without the arguments, the script is sent as is, along with its BEGIN and COMMIT.
+		_, err = db.Exec(string(script))
+		if err != nil {
+			t.Fatalf("deploy %s: %v", change, err)
+		}
+	}
+
+	return db
+}
+
+func readSqitchPlan(t *testing.T) []string {
+	t.Helper()
+
+	file, err := os.Open(filepath.Join("..", "..", "database", "sqitch.plan"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	changes := []string{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "%") || strings.HasPrefix(line, "#") {
+			continue
+		}
+		changes = append(changes, strings.Fields(line)[0])
+	}
+	if err = scanner.Err(); err != nil {
+		t.Fatal(err)
+	}
+	return changes
+}
+
+// testApplication is the application on the test database, where the scheduled jobs run at the time of the clock,
+// and the push messages are kept by the fake sender.
+type testApplication struct {
+	*Application
+	db     *sql.DB
+	sender *push.FakeSender
+	clock  *fakeClock
+}
+
+func newTestApplication(t *testing.T, now time.Time) *testApplication {
+	t.Helper()
+
+	db := newTestDatabase(t)
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	store, err := remoteConfig.NewStore(filepath.Join("..", "..", "data"), logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	clock := &fakeClock{now: now}
+	sender := &push.FakeSender{}
+	app := &Application{
+		logger:       logger,
+		daos:         models.NewDaoHandler(db, store),
+		pushSender:   sender,
+		remoteConfig: store,
+		scheduler:    scheduler.New(clock, logger),
+		metrics:      newAppMetrics(),
+	}
+	app.config.reminder = reminderConfig{Interval: time.Minute, OffsetsInMin: []int{24 * 60, 60}}
+	app.registerScheduledJobs()
+
+	return &testApplication{Application: app, db: db, sender: sender, clock: clock}
+}
+
+// insertTestUser adds an activated user with a device of the token.
+func insertTestUser(t *testing.T, app *testApplication, name string) int64 {
+	t.Helper()
+
+	var userId int64
+	err := app.db.QueryRow(`
+		INSERT INTO sportgether_schema.users (username, email, password, status)
+		VALUES ($1, $1 || '@sportgether.test', 'x', 'ACTIVATED')
+		RETURNING id
+	`, name).Scan(&userId)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = app.daos.UpdateFCMToken(userId, "token-"+name, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return userId
+}
+
+// insertTestEvent adds the event hosted by the host, which the participants have joined.
+func insertTestEvent(t *testing.T, app *testApplication, hostId int64, startTime time.Time, participantIds ...int64) int64 {
+	t.Helper()
+
+	event := &models.Event{
+		EventName:           fmt.Sprintf("Futsal %d", startTime.Unix()),
+		HostId:              hostId,
+		StartTime:           startTime.Format(time.RFC3339),
+		EndTime:             startTime.Add(2 * time.Hour).Format(time.RFC3339),
+		Destination:         "Court",
+		LongLat:             models.GeoType{Longitude: 101.7, Latitude: 3.1},
+		EventType:           "FUTSAL",
+		MaxParticipantCount: 10,
+		Visibility:          models.PublicEvent,
+	}
+	err := app.daos.WithTransaction(func(tx *sql.Tx) error {
+		err := app.daos.CreateEvent(event, tx)
+		if err != nil {
+			return err
+		}
+		for _, userId := range append([]int64{hostId}, participantIds...) {
+			_, err = tx.ExecContext(context.Background(), `INSERT INTO sportgether_schema.event_participant (eventid, participantid) VALUES ($1, $2)`, event.ID, userId)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return event.ID
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"sportgether/internal/media"
+	"time"
+)
+
+const (
+	mediaCleanupBatchSize   = 20
+	mediaCleanupMaxAttempts = 10
+	mediaCleanupMaxBackoff  = 24 * time.Hour
+)
+
+// cleanupMedia deletes the media which is no longer referenced, e.g. the old profile icons. Failed deletions are
+// retried with exponential backoff, until mediaCleanupMaxAttempts.
+func (app *Application) cleanupMedia(ctx context.Context, now time.Time) error {
+	tasks, err := app.daos.ClaimMediaCleanupTasks(now, mediaCleanupBatchSize, 5*time.Minute)
+	if err != nil {
+		return err
+	}
+
+	for _, task := range tasks {
+		deleteCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+		err := app.mediaStorage.Delete(deleteCtx, task.PublicId)
+		cancel()
+
+		if err == nil || errors.Is(err, media.MediaNotFoundError) {
+			err = app.daos.CompleteMediaCleanupTask(task.ID)
+			if err != nil {
+				app.logger.Error(err.Error(), "publicId", task.PublicId)
+			}
+			continue
+		}
+
+		attempts := task.Attempts + 1
+		app.logger.Error(err.Error(), "publicId", task.PublicId, "attempts", attempts)
+
+		backoff := min(time.Duration(1<<min(attempts, 20))*time.Minute, mediaCleanupMaxBackoff)
+		err = app.daos.RetryMediaCleanupTask(task.ID, err.Error(), now.Add(backoff), attempts >= mediaCleanupMaxAttempts)
+		if err != nil {
+			app.logger.Error(err.Error(), "publicId", task.PublicId)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"runtime"
+	"sportgether/internal/metrics"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// The routes of the requests rejected before routing, e.g. by the authentication, or not found, so that the paths,
+// which may have ids, never become the labels.
+const unmatchedRoute = "unmatched"
+
+type appMetrics struct {
+	registry *metrics.Registry
+
+	httpRequests        *metrics.CounterVec
+	httpRequestDuration *metrics.HistogramVec
+	dbQueryDuration     *metrics.HistogramVec
+	pushMessages        *metrics.CounterVec
+	pushSendErrors      *metrics.CounterVec
+
+	eventsCreated  *metrics.CounterVec
+	eventJoins     *metrics.CounterVec
+	eventQuits     *metrics.CounterVec
+	upcomingEvents *metrics.GaugeVec
+}
+
+func newAppMetrics() *appMetrics {
+	registry := metrics.NewRegistry()
+	return &appMetrics{
+		registry:            registry,
+		httpRequests:        registry.NewCounterVec("sportgether_http_requests_total", "Number of the HTTP requests by route and status.", "method", "route", "status"),
+		httpRequestDuration: registry.NewHistogramVec("sportgether_http_request_duration_seconds", "Latency of the HTTP requests by route.", metrics.DefaultBuckets, "method", "route"),
+		dbQueryDuration:     registry.NewHistogramVec("sportgether_db_query_duration_seconds", "Duration of the database queries by the DAO method running them.", metrics.DefaultBuckets, "dao"),
+		pushMessages:        registry.NewCounterVec("sportgether_fcm_messages_total", "Number of the FCM messages by the result of each device.", "result"),
+		pushSendErrors:      registry.NewCounterVec("sportgether_fcm_send_errors_total", "Number of the FCM multicasts failed to be sent at all."),
+		eventsCreated:       registry.NewCounterVec("sportgether_events_created_total", "Number of the events created."),
+		eventJoins:          registry.NewCounterVec("sportgether_event_joins_total", "Number of the participants joined the events."),
+		eventQuits:          registry.NewCounterVec("sportgether_event_quits_total", "Number of the participants quit the events."),
+		upcomingEvents:      registry.NewGaugeVec("sportgether_upcoming_events", "Number of the events not started yet."),
+	}
+}
+
+// collectDatabase reads the pool stats of the database on every scrape, along with the upcoming events.
+func (app *Application) collectDatabase(db *sql.DB) {
+	open := app.metrics.registry.NewGaugeVec("sportgether_db_open_connections", "Number of the connections open, in use or idle.", "state")
+	waitCount := app.metrics.registry.NewGaugeVec("sportgether_db_wait_count", "Number of the connections waited for, since start.")
+	waitDuration := app.metrics.registry.NewGaugeVec("sportgether_db_wait_duration_seconds", "Time waited for the connections, since start.")
+	maxOpen := app.metrics.registry.NewGaugeVec("sportgether_db_max_open_connections", "Max number of the connections open.")
+
+	app.metrics.registry.OnCollect(func() {
+		stats := db.Stats()
+		open.Set(float64(stats.InUse), "in_use")
+		open.Set(float64(stats.Idle), "idle")
+		waitCount.Set(float64(stats.WaitCount))
+		waitDuration.Set(stats.WaitDuration.Seconds())
+		maxOpen.Set(float64(stats.MaxOpenConnections))
+
+		count, err := app.daos.CountUpcomingEvents()
+		if err != nil {
+			app.logger.Error("failed to count the upcoming events for metrics", "error", err.Error())
+			return
+		}
+		app.metrics.upcomingEvents.Set(float64(count))
+	})
+}
+
+// metricsHandler is served on the admin port only, so that it is never exposed along with the API.
+func (app *Application) metricsHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", app.metrics.registry.Handler())
+	return mux
+}
+
+type routeContextKey struct{}
+
+// setRoute records the pattern of the route matched, for metricRequests to label the request by.
+func setRoute(r *http.Request, pattern string) {
+	route, ok := r.Context().Value(routeContextKey{}).(*string)
+	if ok {
+		*route = pattern
+	}
+}
+
+// metricMethod keeps the methods sent by the clients from adding series, as any method can be sent.
+func metricMethod(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete, http.MethodOptions:
+		return method
+	default:
+		return "OTHER"
+	}
+}
+
+// statusRecorder keeps the status written, which is 200 when only the body is written.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (recorder *statusRecorder) WriteHeader(status int) {
+	if recorder.status == 0 {
+		recorder.status = status
+	}
+	recorder.ResponseWriter.WriteHeader(status)
+}
+
+func (recorder *statusRecorder) Write(body []byte) (int, error) {
+	if recorder.status == 0 {
+		recorder.status = http.StatusOK
+	}
+	return recorder.ResponseWriter.Write(body)
+}
+
+// Unwrap is for http.ResponseController to reach the writer of the server.
+func (recorder *statusRecorder) Unwrap() http.ResponseWriter {
+	return recorder.ResponseWriter
+}
+
+// queryTracer times the queries by the DAO method running them, found from the stack, since the queries are sent
+// through database/sql without any name.
+type queryTracer struct {
+	duration *metrics.HistogramVec
+}
+
+type queryStartKey struct{}
+
+type queryStart struct {
+	dao string
+	at  time.Time
+}
+
+func (tracer queryTracer) TraceQueryStart(ctx context.Context, _ *pgx.Conn, _ pgx.TraceQueryStartData) context.Context {
+	return context.WithValue(ctx, queryStartKey{}, queryStart{dao: daoMethod(), at: time.Now()})
+}
+
+func (tracer queryTracer) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, _ pgx.TraceQueryEndData) {
+	start, ok := ctx.Value(queryStartKey{}).(queryStart)
+	if ok {
+		tracer.duration.Observe(time.Since(start.at).Seconds(), start.dao)
+	}
+}
+
+// daoMethod returns the first method of the models package on the stack, e.g. EventDao.GetEvents, or other for the
+// queries of pgx itself.
+func daoMethod() string {
+	const prefix = "sportgether/internal/models."
+
+	pcs := make([]uintptr, 32)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(3, pcs)])
+	for {
+		frame, more := frames.Next()
+		if name, ok := strings.CutPrefix(frame.Function, prefix); ok {
+			name = strings.NewReplacer("(*", "", ")", "").Replace(name)
+			// The closures, e.g. EventDao.GetEvents.func1, are counted as their method.
+			parts := strings.SplitN(name, ".", 3)
+			if len(parts) >= 2 && !strings.HasPrefix(parts[1], "func") {
+				return parts[0] + "." + parts[1]
+			}
+			return parts[0]
+		}
+		if !more {
+			return "other"
+		}
+	}
+}
@@ -1,19 +1,31 @@
 package main
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"net/http"
 	"sportgether/constants"
 	"sportgether/internal/models"
 	"sportgether/tools"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 )
 
+// requiredMinAppVersion checks the app against the supported versions of its platform, see requestPlatform. The
+// apps older than the min version are asked to update, and the ones older than the recommended version are prompted
+// to update by the soft update header, without being blocked.
 func (app *Application) requiredMinAppVersion(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		versions := app.remoteConfig.Current().MinVersion.Value.ForPlatform(requestPlatform(r))
+		if versions.Maintenance.Enabled {
+			app.writeMaintenanceResponse(w, r, versions.Maintenance.Message)
+			return
+		}
+
 		appVersionHeader := r.Header.Get("Curr-Version")
 		if appVersionHeader == "" {
 			// Let's skip for now. Only cater when client send the version.
@@ -21,32 +33,50 @@ func (app *Application) requiredMinAppVersion(next http.Handler) http.Handler {
 			return
 		}
 
-		input := struct {
-			MinVersion string `json:"minVersion"`
-		}{}
-		err := readJsonFromFile("./data/supported_min_version.json", &input)
-		if err != nil {
-			app.logError(err, r)
-			app.writeInternalServerErrorResponse(w, r)
-			return
-		}
+		if versions.MinVersion != "" {
+			// The versions of the config are checked when it is loaded, so the error is of the version of the app,
+			// which is too broken to tell whether it is supported, and is asked to update as well.
+			isValid, err := isValidVersion(appVersionHeader, versions.MinVersion)
+			if err != nil {
+				app.logError(err, r)
+			}
 
-		isValid, err := isValidVersion(appVersionHeader, input.MinVersion)
-		if err != nil {
-			app.logError(err, r)
-			app.writeBadRequestResponse(w, r)
-			return
+			if err != nil || !isValid {
+				app.writeForceUpdateResponse(w, r)
+				return
+			}
 		}
 
-		if !isValid {
-			app.writeForceUpdateResponse(w, r)
-			return
+		if versions.RecommendedVersion != "" {
+			isRecommended, err := isValidVersion(appVersionHeader, versions.RecommendedVersion)
+			if err == nil && !isRecommended {
+				app.writeSoftUpdateHeader(w, versions.RecommendedVersion)
+			}
 		}
 
 		next.ServeHTTP(w, r)
 	})
 }
 
+// requestPlatform is the platform given in Curr-Platform, or guessed from the User-Agent for the builds before
+// Curr-Platform, which is "" when the User-Agent does not tell, so that only the top level versions apply.
+func requestPlatform(r *http.Request) string {
+	platform := r.Header.Get("Curr-Platform")
+	if platform != "" {
+		return platform
+	}
+
+	userAgent := strings.ToLower(r.UserAgent())
+	switch {
+	case strings.Contains(userAgent, "android") || strings.Contains(userAgent, "okhttp"):
+		return string(models.AndroidPlatform)
+	case strings.Contains(userAgent, "iphone") || strings.Contains(userAgent, "ipad") || strings.Contains(userAgent, "cfnetwork") || strings.Contains(userAgent, "darwin"):
+		return string(models.IOSPlatform)
+	default:
+		return ""
+	}
+}
+
 func (app *Application) authenticationHandler(nextHandler http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		authorizationHeader := r.Header.Get("Authorization")
@@ -147,6 +177,71 @@ func (app *Application) requiredActivatedUser(next http.HandlerFunc) http.Handle
 	return app.requiredAuthenticatedUser(fn)
 }
 
+// requiredAdminUser only lets the admins of the app through, see the admin_user table.
+func (app *Application) requiredAdminUser(next http.HandlerFunc) http.HandlerFunc {
+	fn := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, ok := app.GetUserContext(r)
+		if !ok {
+			app.writeInternalServerErrorResponse(w, r)
+			return
+		}
+
+		isAdmin, err := app.daos.IsAdmin(user.ID)
+		if err != nil {
+			app.logError(err, r)
+			app.writeInternalServerErrorResponse(w, r)
+			return
+		}
+
+		if !isAdmin {
+			app.writeError(w, r, http.StatusForbidden, constants.NotAdminError.Code, constants.NotAdminError.Error())
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+
+	return app.requiredActivatedUser(fn)
+}
+
+// allowSlowUpload extends the read and write deadlines of the server for the routes which take the uploads up to
+// maxEventPhotoSize, which a slow mobile network cannot send within the deadlines of the other requests.
+func (app *Application) allowSlowUpload(next http.HandlerFunc) http.HandlerFunc {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		controller := http.NewResponseController(w)
+		deadline := time.Now().Add(uploadTimeout)
+		// The upload is only cut off by the default deadlines when they cannot be extended, e.g. behind a writer which does
+		// not support them.
+		if err := controller.SetReadDeadline(deadline); err != nil {
+			app.logError(err, r)
+		}
+		if err := controller.SetWriteDeadline(deadline.Add(10 * time.Second)); err != nil {
+			app.logError(err, r)
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// metricRequests counts the requests and their latency by the route matched, which is unmatchedRoute for the requests
+// never routed.
+func (app *Application) metricRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		route := unmatchedRoute
+		recorder := &statusRecorder{ResponseWriter: w}
+
+		next.ServeHTTP(recorder, r.WithContext(context.WithValue(r.Context(), routeContextKey{}, &route)))
+
+		if recorder.status == 0 {
+			recorder.status = http.StatusOK
+		}
+		method := metricMethod(r.Method)
+		app.metrics.httpRequests.Inc(method, route, strconv.Itoa(recorder.status))
+		app.metrics.httpRequestDuration.Observe(time.Since(start).Seconds(), method, route)
+	})
+}
+
 func (app *Application) recoverPanic(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		// Create a deferred function (which will always be run in the event of a panic // as Go unwinds the stack).
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	remoteConfig "sportgether/remote_config"
+	"testing"
+)
+
+func TestRequestPlatform(t *testing.T) {
+	tests := []struct {
+		name      string
+		platform  string
+		userAgent string
+		want      string
+	}{
+		{name: "given platform", platform: "WEB", userAgent: "okhttp/4.9.3", want: "WEB"},
+		{name: "android", userAgent: "Mozilla/5.0 (Linux; Android 13; Pixel 7)", want: "ANDROID"},
+		{name: "okhttp", userAgent: "okhttp/4.9.3", want: "ANDROID"},
+		{name: "ios", userAgent: "Sportgether/1.2.0 CFNetwork/1410.0.3 Darwin/22.6.0", want: "IOS"},
+		{name: "unknown", userAgent: "Dart/3.1 (dart:io)", want: ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/v1/healthcheck", nil)
+			if test.platform != "" {
+				r.Header.Set("Curr-Platform", test.platform)
+			}
+			r.Header.Set("User-Agent", test.userAgent)
+
+			if got := requestPlatform(r); got != test.want {
+				t.Errorf("got platform %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestRequiredMinAppVersion(t *testing.T) {
+	// The config of ../../data, with the min version set.
+	dir := t.TempDir()
+	for _, name := range []string{remoteConfig.MainMessageFile, remoteConfig.HostingConfigFile, remoteConfig.FeatureFlagsFile} {
+		content, err := os.ReadFile(filepath.Join("..", "..", "data", name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = os.WriteFile(filepath.Join(dir, name), content, 0o644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	err := os.WriteFile(filepath.Join(dir, remoteConfig.SupportedMinVersionFile), []byte(`{"minVersion": "1.2.0", "recommendedVersion": "1.5.0"}`), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	store, err := remoteConfig.NewStore(dir, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	app := &Application{logger: logger, remoteConfig: store}
+	handler := app.requiredMinAppVersion(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	tests := []struct {
+		name           string
+		version        string
+		wantStatus     int
+		wantForced     bool
+		wantSoftUpdate string
+	}{
+		{name: "no version", version: "", wantStatus: http.StatusNoContent},
+		{name: "recommended", version: "1.5.0", wantStatus: http.StatusNoContent},
+		{name: "supported", version: "1.10.0", wantStatus: http.StatusNoContent},
+		{name: "older than recommended", version: "1.2.0", wantStatus: http.StatusNoContent, wantSoftUpdate: "1.5.0"},
+		{name: "older than min", version: "1.1.9", wantStatus: http.StatusBadRequest, wantForced: true},
+		{name: "unparsable", version: "1.2.0 (45)", wantStatus: http.StatusBadRequest, wantForced: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/v1/healthcheck", nil)
+			if test.version != "" {
+				r.Header.Set("Curr-Version", test.version)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			if w.Code != test.wantStatus {
+				t.Errorf("got status %d, want %d", w.Code, test.wantStatus)
+			}
+			if forced := w.Header().Get("x-sg-auth-forbidden") == "APP_NOT_SUPPORTED"; forced != test.wantForced {
+				t.Errorf("got force update %t, want %t", forced, test.wantForced)
+			}
+			if got := w.Header().Get("x-sg-soft-update"); got != test.wantSoftUpdate {
+				t.Errorf("got soft update %q, want %q", got, test.wantSoftUpdate)
+			}
+		})
+	}
+}
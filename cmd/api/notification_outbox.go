@@ -0,0 +1,261 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"sportgether/internal/i18n"
+	"sportgether/internal/models"
+	"sportgether/internal/push"
+	"time"
+
+	"firebase.google.com/go/v4/messaging"
+)
+
+const (
+	notificationBatchSize   = 50
+	notificationMaxAttempts = 8
+	notificationBaseBackoff = 30 * time.Second
+	notificationMaxBackoff  = time.Hour
+	notificationLease       = time.Minute
+)
+
+func newEventCreatedNotification(event *models.Event, recipientIds []int64) *models.OutboxNotification {
+	return &models.OutboxNotification{
+		Kind: models.EventCreatedNotification,
+		Data: map[string]string{
+			"type":    "event",
+			"eventId": fmt.Sprintf("%d", event.ID),
+		},
+		Message: &models.NotificationMessage{
+			Title:    i18n.NewText("notification.event_created.title", nil),
+			Subtitle: i18n.NewText("notification.event_created.subtitle", map[string]string{"eventName": event.EventName}),
+		},
+		RecipientIds: recipientIds,
+	}
+}
+
+func newEventJoinedNotification(eventId int64, userPreferredName string, recipientIds []int64) *models.OutboxNotification {
+	return &models.OutboxNotification{
+		Kind: models.EventJoinedNotification,
+		Data: map[string]string{
+			"type":    "event",
+			"eventId": fmt.Sprintf("%d", eventId),
+		},
+		Message: &models.NotificationMessage{
+			Title:    i18n.NewText("notification.event_joined.title", nil),
+			Subtitle: i18n.NewText("notification.event_joined.subtitle", map[string]string{"name": userPreferredName}),
+		},
+		RecipientIds: recipientIds,
+	}
+}
+
+func newEventCancelledNotification(eventId int64, eventName string, recipientIds []int64) *models.OutboxNotification {
+	return &models.OutboxNotification{
+		Kind: models.EventCancelledNotification,
+		Data: map[string]string{
+			"type":    "event",
+			"eventId": fmt.Sprintf("%d", eventId),
+		},
+		Message: &models.NotificationMessage{
+			Title:    i18n.NewText("notification.event_cancelled.title", nil),
+			Subtitle: i18n.NewText("notification.event_cancelled.subtitle", map[string]string{"eventName": eventName}),
+		},
+		RecipientIds: recipientIds,
+	}
+}
+
+// notify stores the notification in the outbox and the inboxes of the recipients, then dispatches it right away.
+// Enqueue the notification in the transaction of the change instead when there is one, see EnqueueNotification.
+func (app *Application) notify(r *http.Request, notification *models.OutboxNotification) error {
+	err := app.daos.WithTransaction(func(tx *sql.Tx) error {
+		return app.daos.EnqueueNotification(notification, tx)
+	})
+	if err != nil {
+		return err
+	}
+
+	app.dispatchNotificationsNow(r)
+
+	return nil
+}
+
+// dispatchNotificationsNow sends the notifications just committed, instead of waiting for the scheduled dispatch.
+func (app *Application) dispatchNotificationsNow(r *http.Request) {
+	app.background(func() {
+		err := app.dispatchNotifications(context.Background(), time.Now())
+		if err != nil {
+			app.logError(err, r)
+		}
+	}, r)
+}
+
+// dispatchNotifications sends the notifications in the outbox, up to notificationBatchSize of them. Failed sends are
+// retried with exponential backoff, until notificationMaxAttempts, then the notification is dead-lettered.
+//
+// A notification is leased before sending, so concurrent dispatches never pick the same one. The notifications are
+// claimed one at a time, and the lease is extended before each recipient, so that it never runs out in the middle of
+// a long send. If the process dies after sending but before marking it sent, the notification is sent again after the
+// lease, so every message carries the notification id as the collapse key, and the devices replace the earlier copy
+// instead of showing both.
+func (app *Application) dispatchNotifications(ctx context.Context, now time.Time) error {
+	started := time.Now()
+	// leaseStart is now advanced by the time spent sending so far.
+	leaseStart := func() time.Time {
+		return now.Add(time.Since(started))
+	}
+
+	for i := 0; i < notificationBatchSize; i++ {
+		notifications, err := app.daos.ClaimNotifications(leaseStart(), 1, notificationLease)
+		if err != nil {
+			return err
+		}
+		if len(notifications) == 0 {
+			return nil
+		}
+		notification := notifications[0]
+
+		err = app.sendNotification(ctx, notification, func() error {
+			return app.daos.ExtendNotificationLease(notification.ID, leaseStart().Add(notificationLease))
+		})
+		if err == nil {
+			err = app.daos.CompleteNotification(notification.ID)
+			if err != nil {
+				app.logger.Error(err.Error(), "notificationId", notification.ID)
+			}
+			continue
+		}
+
+		attempts := notification.Attempts + 1
+		app.logger.Error(err.Error(), "notificationId", notification.ID, "attempts", attempts)
+
+		err = app.daos.RetryNotification(notification.ID, err.Error(), now.Add(notificationBackoff(attempts)), attempts >= notificationMaxAttempts)
+		if err != nil {
+			app.logger.Error(err.Error(), "notificationId", notification.ID)
+		}
+	}
+
+	return nil
+}
+
+// notificationBackoff is how long to wait before retrying the notification after the failed attempts, which doubles
+// from notificationBaseBackoff on every attempt, up to notificationMaxBackoff.
+func notificationBackoff(attempts int) time.Duration {
+	return min(notificationBaseBackoff*time.Duration(1<<min(attempts, 20)), notificationMaxBackoff)
+}
+
+// sendNotification sends the notification to the devices of each recipient, with the payload of the notification in
+// the inbox of the recipient. The recipients who turned off the category, muted the event or are in their quiet hours
+// are skipped, and only find the notification in the inbox.
+//
+// A failed recipient does not stop the others. Each recipient is marked pushed once sent to all the devices, and the
+// devices reached are recorded when some others failed, so that the retry of the notification only sends to the
+// devices which failed.
+func (app *Application) sendNotification(ctx context.Context, notification *models.OutboxNotification, extendLease func() error) error {
+	userNotifications, err := app.daos.GetOutboxUserNotifications(notification.ID)
+	if err != nil {
+		return err
+	}
+
+	allowed, err := app.allowedNotificationRecipients(notification.Kind.Category(), notificationEventId(notification.Data), notification.RecipientIds, time.Now())
+	if err != nil {
+		return err
+	}
+
+	tokens, err := app.daos.GetUsersTokens(notification.RecipientIds)
+	if err != nil {
+		return err
+	}
+
+	errs := []error{}
+	for _, userNotification := range userNotifications {
+		setting, ok := allowed[userNotification.UserId]
+		if !ok {
+			continue
+		}
+
+		userTokens := unpushedTokens(tokens[userNotification.UserId], userNotification.PushedTokens)
+		if len(userTokens) == 0 {
+			continue
+		}
+
+		err = extendLease()
+		if err != nil {
+			errs = append(errs, err)
+			break
+		}
+
+		pushed, err := app.pushUserNotification(ctx, userNotification, userTokens, setting.NotificationLocale(i18n.DefaultLocale))
+		if err != nil {
+			errs = append(errs, fmt.Errorf("user %d: %w", userNotification.UserId, err))
+			if len(pushed) > 0 {
+				err = app.daos.MarkUserNotificationTokensPushed(userNotification.ID, pushed)
+				if err != nil {
+					errs = append(errs, err)
+				}
+			}
+			continue
+		}
+
+		err = app.daos.MarkUserNotificationPushed(userNotification.ID, time.Now())
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// unpushedTokens returns the tokens which the push message has not reached yet.
+func unpushedTokens(tokens []string, pushedTokens []string) []string {
+	pushed := map[string]bool{}
+	for _, token := range pushedTokens {
+		pushed[token] = true
+	}
+
+	unpushed := []string{}
+	for _, token := range tokens {
+		if !pushed[token] {
+			unpushed = append(unpushed, token)
+		}
+	}
+	return unpushed
+}
+
+// pushUserNotification sends to the tokens in the multicasts of at most push.MaxTokensPerMessage tokens each. It
+// returns the tokens the message has reached, or which are not worth retrying, along with the failures of the others.
+func (app *Application) pushUserNotification(ctx context.Context, userNotification *models.UserNotification, tokens []string, locale i18n.Locale) ([]string, error) {
+	notificationId := fmt.Sprintf("%d", userNotification.ID)
+	pushed := []string{}
+	errs := []error{}
+	for start := 0; start < len(tokens); start += push.MaxTokensPerMessage {
+		chunk := tokens[start:min(start+push.MaxTokensPerMessage, len(tokens))]
+		message := &messaging.MulticastMessage{
+			Data:    userNotification.Payload(locale),
+			Tokens:  chunk,
+			Android: &messaging.AndroidConfig{CollapseKey: notificationId},
+			APNS: &messaging.APNSConfig{
+				Headers: map[string]string{"apns-collapse-id": notificationId},
+			},
+		}
+
+		sendCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+		err := app.sendMulticast(sendCtx, message)
+		cancel()
+
+		failed := &pushFailedError{}
+		switch {
+		case err == nil:
+			pushed = append(pushed, chunk...)
+		case errors.As(err, &failed):
+			pushed = append(pushed, unpushedTokens(chunk, failed.Tokens)...)
+			errs = append(errs, err)
+		default:
+			errs = append(errs, err)
+		}
+	}
+
+	return pushed, errors.Join(errs...)
+}
@@ -0,0 +1,217 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"sportgether/internal/models"
+	"testing"
+	"time"
+)
+
+func TestNotificationBackoff(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{attempts: 1, want: time.Minute},
+		{attempts: 2, want: 2 * time.Minute},
+		{attempts: 6, want: 32 * time.Minute},
+		{attempts: 7, want: time.Hour},
+		{attempts: notificationMaxAttempts, want: time.Hour},
+		// The shift is capped, so that it never overflows.
+		{attempts: 100, want: time.Hour},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("attempt %d", test.attempts), func(t *testing.T) {
+			if got := notificationBackoff(test.attempts); got != test.want {
+				t.Errorf("got backoff %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestUnpushedTokens(t *testing.T) {
+	tests := []struct {
+		name   string
+		tokens []string
+		pushed []string
+		want   []string
+	}{
+		{name: "none pushed", tokens: []string{"a", "b"}, pushed: nil, want: []string{"a", "b"}},
+		{name: "some pushed", tokens: []string{"a", "b", "c"}, pushed: []string{"b"}, want: []string{"a", "c"}},
+		{name: "all pushed", tokens: []string{"a", "b"}, pushed: []string{"b", "a"}, want: []string{}},
+		// The device registered after the first attempt is pushed on the retry, while the one deleted is not.
+		{name: "devices changed", tokens: []string{"b", "new"}, pushed: []string{"a", "b"}, want: []string{"new"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := unpushedTokens(test.tokens, test.pushed); fmt.Sprint(got) != fmt.Sprint(test.want) {
+				t.Errorf("got tokens %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+type outboxState struct {
+	status        string
+	attempts      int
+	nextAttemptAt time.Time
+}
+
+func enqueueTestNotification(t *testing.T, app *testApplication, recipientIds ...int64) int64 {
+	t.Helper()
+
+	notification := newEventCreatedNotification(&models.Event{ID: 1, EventName: "Futsal"}, recipientIds)
+	err := app.daos.WithTransaction(func(tx *sql.Tx) error {
+		return app.daos.EnqueueNotification(notification, tx)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return notification.ID
+}
+
+func readOutboxState(t *testing.T, app *testApplication, notificationId int64) outboxState {
+	t.Helper()
+
+	state := outboxState{}
+	err := app.db.QueryRow(`
+		SELECT status, attempts, next_attempt_at FROM sportgether_schema.notification_outbox WHERE id = $1
+	`, notificationId).Scan(&state.status, &state.attempts, &state.nextAttemptAt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return state
+}
+
+// pushedTokens returns the tokens of the messages sent so far, in order.
+func pushedTokens(app *testApplication) []string {
+	tokens := []string{}
+	for _, message := range app.sender.Messages() {
+		tokens = append(tokens, message.Tokens...)
+	}
+	return tokens
+}
+
+func dispatchAt(t *testing.T, app *testApplication, now time.Time) {
+	t.Helper()
+
+	err := app.dispatchNotifications(context.Background(), now)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDispatchNotificationsBacksOffAndDeadLetters(t *testing.T) {
+	now := time.Date(2100, time.January, 1, 0, 0, 0, 0, time.UTC)
+	app := newTestApplication(t, now)
+	app.sender.Err = errors.New("unavailable")
+
+	userId := insertTestUser(t, app, "user")
+	notificationId := enqueueTestNotification(t, app, userId)
+
+	// 1m, 2m, 4m, 8m, 16m, 32m, then capped at 1h.
+	backoffs := []time.Duration{
+		time.Minute, 2 * time.Minute, 4 * time.Minute, 8 * time.Minute, 16 * time.Minute, 32 * time.Minute, time.Hour,
+	}
+	for attempt, backoff := range backoffs {
+		dispatchAt(t, app, now)
+
+		want := outboxState{status: "PENDING", attempts: attempt + 1, nextAttemptAt: now.Add(backoff)}
+		got := readOutboxState(t, app, notificationId)
+		if got.status != want.status || got.attempts != want.attempts || !got.nextAttemptAt.Equal(want.nextAttemptAt) {
+			t.Fatalf("got %+v after attempt %d, want %+v", got, attempt+1, want)
+		}
+
+		// Not due before the backoff.
+		dispatchAt(t, app, now.Add(backoff-time.Second))
+		if got := readOutboxState(t, app, notificationId); got.attempts != attempt+1 {
+			t.Fatalf("got attempts %d before the backoff, want %d", got.attempts, attempt+1)
+		}
+
+		now = now.Add(backoff)
+	}
+
+	dispatchAt(t, app, now)
+	if got := readOutboxState(t, app, notificationId); got.status != "DEAD" || got.attempts != notificationMaxAttempts {
+		t.Fatalf("got %+v, want dead after %d attempts", got, notificationMaxAttempts)
+	}
+
+	// Dead-lettered, so never sent again, even once FCM is back.
+	app.sender.Err = nil
+	dispatchAt(t, app, now.Add(24*time.Hour))
+	if tokens := pushedTokens(app); len(tokens) != 0 {
+		t.Errorf("got tokens %v pushed for the dead notification", tokens)
+	}
+}
+
+func TestDispatchNotificationsReclaimsExpiredLease(t *testing.T) {
+	now := time.Date(2100, time.January, 1, 0, 0, 0, 0, time.UTC)
+	app := newTestApplication(t, now)
+
+	userId := insertTestUser(t, app, "user")
+	notificationId := enqueueTestNotification(t, app, userId)
+
+	// Claimed by an instance which dies before sending.
+	claimed, err := app.daos.ClaimNotifications(now, notificationBatchSize, notificationLease)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(claimed) != 1 {
+		t.Fatalf("got %d notifications claimed, want 1", len(claimed))
+	}
+
+	dispatchAt(t, app, now.Add(notificationLease-time.Second))
+	if tokens := pushedTokens(app); len(tokens) != 0 {
+		t.Fatalf("got tokens %v pushed within the lease", tokens)
+	}
+
+	dispatchAt(t, app, now.Add(notificationLease))
+	if tokens := pushedTokens(app); len(tokens) != 1 || tokens[0] != "token-user" {
+		t.Fatalf("got tokens %v pushed after the lease, want [token-user]", tokens)
+	}
+	if got := readOutboxState(t, app, notificationId); got.status != "SENT" || got.attempts != 0 {
+		t.Errorf("got %+v, want sent with no failed attempt", got)
+	}
+}
+
+func TestDispatchNotificationsRetriesFailedTokensOnly(t *testing.T) {
+	now := time.Date(2100, time.January, 1, 0, 0, 0, 0, time.UTC)
+	app := newTestApplication(t, now)
+	// FCM reports the failures by the token in the responses, with no error for the whole send.
+	app.sender.FailedTokens = map[string]error{"token-failing": errors.New("unavailable")}
+
+	sentId := insertTestUser(t, app, "sent")
+	failingId := insertTestUser(t, app, "failing")
+	// The other device of the user is reached on the first attempt, so it is not sent again.
+	err := app.daos.UpdateFCMToken(failingId, "token-failing-tablet", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	notificationId := enqueueTestNotification(t, app, sentId, failingId)
+
+	dispatchAt(t, app, now)
+	tokens := pushedTokens(app)
+	sort.Strings(tokens)
+	if want := []string{"token-failing", "token-failing-tablet", "token-sent"}; fmt.Sprint(tokens) != fmt.Sprint(want) {
+		t.Fatalf("got tokens %v pushed, want %v", tokens, want)
+	}
+	want := outboxState{status: "PENDING", attempts: 1, nextAttemptAt: now.Add(time.Minute)}
+	if got := readOutboxState(t, app, notificationId); got.status != want.status || got.attempts != want.attempts || !got.nextAttemptAt.Equal(want.nextAttemptAt) {
+		t.Fatalf("got %+v, want %+v for the retry", got, want)
+	}
+
+	app.sender.FailedTokens = nil
+	dispatchAt(t, app, now.Add(time.Minute))
+	if tokens := pushedTokens(app)[3:]; len(tokens) != 1 || tokens[0] != "token-failing" {
+		t.Fatalf("got tokens %v pushed on the retry, want [token-failing]", tokens)
+	}
+	if got := readOutboxState(t, app, notificationId); got.status != "SENT" || got.attempts != 1 {
+		t.Errorf("got %+v, want sent", got)
+	}
+}
@@ -0,0 +1,258 @@
+package main
+
+import (
+	"net/http"
+	"sportgether/internal/i18n"
+	"sportgether/internal/models"
+	"sportgether/tools"
+	"strconv"
+	"time"
+)
+
+const minutesPerDay = 24 * 60
+
+func (app *Application) getNotificationSetting(w http.ResponseWriter, r *http.Request) {
+	user, ok := app.GetUserContext(r)
+	if !ok {
+		app.writeInvalidAuthenticationErrorResponse(w, r)
+		return
+	}
+
+	setting, err := app.daos.GetNotificationSetting(user.ID)
+	if err != nil {
+		app.logError(err, r)
+		app.writeInternalServerErrorResponse(w, r)
+		return
+	}
+
+	err = app.writeResponse(w, responseData{"notificationSetting": setting}, http.StatusOK, nil)
+	if err != nil {
+		app.logError(err, r)
+		app.writeInternalServerErrorResponse(w, r)
+	}
+}
+
+func (app *Application) updateNotificationSetting(w http.ResponseWriter, r *http.Request) {
+	input := struct {
+		EventReminderEnabled *bool   `json:"eventReminderEnabled"`
+		JoinEnabled          *bool   `json:"joinEnabled"`
+		UpdateEnabled        *bool   `json:"updateEnabled"`
+		CancellationEnabled  *bool   `json:"cancellationEnabled"`
+		ChatEnabled          *bool   `json:"chatEnabled"`
+		MarketingEnabled     *bool   `json:"marketingEnabled"`
+		QuietHoursEnabled    *bool   `json:"quietHoursEnabled"`
+		QuietHoursStartInMin *int    `json:"quietHoursStartInMin"`
+		QuietHoursEndInMin   *int    `json:"quietHoursEndInMin"`
+		Timezone             *string `json:"timezone"`
+		Locale               *string `json:"locale"`
+	}{}
+	err := app.readRequest(r, &input)
+	if err != nil {
+		app.logError(err, r)
+		app.writeBadRequestResponse(w, r)
+		return
+	}
+
+	validator := tools.NewRequestValidator()
+	if input.QuietHoursStartInMin != nil {
+		validator.Check(*input.QuietHoursStartInMin >= 0 && *input.QuietHoursStartInMin < minutesPerDay, "quietHoursStartInMin", "must be between 0 and 1439")
+	}
+	if input.QuietHoursEndInMin != nil {
+		validator.Check(*input.QuietHoursEndInMin >= 0 && *input.QuietHoursEndInMin < minutesPerDay, "quietHoursEndInMin", "must be between 0 and 1439")
+	}
+	if input.Timezone != nil {
+		_, err = time.LoadLocation(*input.Timezone)
+		validator.Check(*input.Timezone != "" && err == nil, "timezone", "must be a valid IANA timezone")
+	}
+	var locale i18n.Locale
+	if input.Locale != nil {
+		var supported bool
+		locale, supported = i18n.ParseLocale(*input.Locale)
+		validator.Check(supported, "locale", "must be one of en, ms, zh")
+	}
+	if !validator.Valid() {
+		app.writeError(w, r, http.StatusBadRequest, http.StatusBadRequest, validator.Errors)
+		return
+	}
+
+	user, ok := app.GetUserContext(r)
+	if !ok {
+		app.writeInvalidAuthenticationErrorResponse(w, r)
+		return
+	}
+
+	setting, err := app.daos.GetNotificationSetting(user.ID)
+	if err != nil {
+		app.logError(err, r)
+		app.writeInternalServerErrorResponse(w, r)
+		return
+	}
+
+	if input.EventReminderEnabled != nil {
+		setting.EventReminderEnabled = *input.EventReminderEnabled
+	}
+	if input.JoinEnabled != nil {
+		setting.JoinEnabled = *input.JoinEnabled
+	}
+	if input.UpdateEnabled != nil {
+		setting.UpdateEnabled = *input.UpdateEnabled
+	}
+	if input.CancellationEnabled != nil {
+		setting.CancellationEnabled = *input.CancellationEnabled
+	}
+	if input.ChatEnabled != nil {
+		setting.ChatEnabled = *input.ChatEnabled
+	}
+	if input.MarketingEnabled != nil {
+		setting.MarketingEnabled = *input.MarketingEnabled
+	}
+	if input.QuietHoursEnabled != nil {
+		setting.QuietHoursEnabled = *input.QuietHoursEnabled
+	}
+	if input.QuietHoursStartInMin != nil {
+		setting.QuietHoursStartInMin = *input.QuietHoursStartInMin
+	}
+	if input.QuietHoursEndInMin != nil {
+		setting.QuietHoursEndInMin = *input.QuietHoursEndInMin
+	}
+	if input.Timezone != nil {
+		setting.Timezone = *input.Timezone
+	}
+	if input.Locale != nil {
+		setting.Locale = &locale
+	}
+
+	err = app.daos.UpdateNotificationSetting(user.ID, setting)
+	if err != nil {
+		app.logError(err, r)
+		app.writeInternalServerErrorResponse(w, r)
+	}
+}
+
+func (app *Application) muteEventNotification(w http.ResponseWriter, r *http.Request) {
+	input := struct {
+		EventId int64 `json:"eventId"`
+	}{}
+	err := app.readRequest(r, &input)
+	if err != nil {
+		app.logError(err, r)
+		app.writeBadRequestResponse(w, r)
+		return
+	}
+
+	user, ok := app.GetUserContext(r)
+	if !ok {
+		app.writeInvalidAuthenticationErrorResponse(w, r)
+		return
+	}
+
+	_, err = app.daos.GetEventById(input.EventId, user.ID)
+	if err != nil {
+		app.logError(err, r)
+		app.writeBadRequestResponse(w, r)
+		return
+	}
+
+	err = app.daos.MuteEventNotification(user.ID, input.EventId)
+	if err != nil {
+		app.logError(err, r)
+		app.writeInternalServerErrorResponse(w, r)
+	}
+}
+
+func (app *Application) unmuteEventNotification(w http.ResponseWriter, r *http.Request) {
+	eventId, err := app.readParam("eventId", r)
+	if err != nil {
+		app.logError(err, r)
+		app.writeBadRequestResponse(w, r)
+		return
+	}
+
+	user, ok := app.GetUserContext(r)
+	if !ok {
+		app.writeInvalidAuthenticationErrorResponse(w, r)
+		return
+	}
+
+	err = app.daos.UnmuteEventNotification(user.ID, *eventId)
+	if err != nil {
+		app.logError(err, r)
+		app.writeInternalServerErrorResponse(w, r)
+	}
+}
+
+// allowedNotificationRecipients returns the settings of the users who accept a notification of the category now,
+// according to their notification settings, and to their muted events when the notification is about an event. Every
+// push and notification email goes through it before being sent.
+func (app *Application) allowedNotificationRecipients(category models.NotificationCategory, eventId *int64, userIds []int64, now time.Time) (map[int64]*models.NotificationSetting, error) {
+	settings, err := app.daos.GetNotificationSettings(userIds)
+	if err != nil {
+		return nil, err
+	}
+
+	muted := map[int64]bool{}
+	if eventId != nil {
+		muted, err = app.daos.GetEventMutedUserIds(*eventId, userIds)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return filterNotificationRecipients(category, userIds, settings, muted, now), nil
+}
+
+// filterNotificationRecipients returns the setting of each user who allows the notification of the category now, and
+// has not muted the event of the notification.
+func filterNotificationRecipients(category models.NotificationCategory, userIds []int64, settings map[int64]*models.NotificationSetting, muted map[int64]bool, now time.Time) map[int64]*models.NotificationSetting {
+	allowed := map[int64]*models.NotificationSetting{}
+	for _, userId := range userIds {
+		setting, ok := settings[userId]
+		if ok && !muted[userId] && setting.Allows(category, now) {
+			allowed[userId] = setting
+		}
+	}
+
+	return allowed
+}
+
+// userLocale is the locale of the user, which falls back to the Accept-Language of the request when the user never
+// chooses one.
+func (app *Application) userLocale(userId int64, r *http.Request) i18n.Locale {
+	fallback := i18n.FromAcceptLanguage(r.Header.Get("Accept-Language"))
+	setting, err := app.daos.GetNotificationSetting(userId)
+	if err != nil {
+		app.logError(err, r)
+		return fallback
+	}
+
+	return setting.NotificationLocale(fallback)
+}
+
+// initUserLocale keeps the locale of the Accept-Language of the request for the user, until the user chooses one, so
+// that the notifications sent later without a request are in the language of the app.
+func (app *Application) initUserLocale(userId int64, r *http.Request) {
+	locale, ok := i18n.MatchAcceptLanguage(r.Header.Get("Accept-Language"))
+	if !ok {
+		return
+	}
+
+	err := app.daos.InitNotificationLocale(userId, locale)
+	if err != nil {
+		app.logError(err, r)
+	}
+}
+
+// notificationEventId returns the event of the notification, or nil when it is not about an event.
+func notificationEventId(data map[string]string) *int64 {
+	value, ok := data["eventId"]
+	if !ok {
+		return nil
+	}
+
+	eventId, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return nil
+	}
+
+	return &eventId
+}
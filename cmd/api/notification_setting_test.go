@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"sportgether/internal/models"
+	"testing"
+	"time"
+)
+
+func TestFilterNotificationRecipients(t *testing.T) {
+	// 23:00 in Kuala Lumpur.
+	now := time.Date(2100, time.January, 1, 15, 0, 0, 0, time.UTC)
+
+	enabled := func() *models.NotificationSetting {
+		return &models.NotificationSetting{
+			JoinEnabled:          true,
+			UpdateEnabled:        true,
+			CancellationEnabled:  true,
+			EventReminderEnabled: true,
+			QuietHoursStartInMin: 22 * 60,
+			QuietHoursEndInMin:   7 * 60,
+			Timezone:             models.DefaultNotificationTimezone,
+		}
+	}
+	remindersOff := enabled()
+	remindersOff.EventReminderEnabled = false
+	quiet := enabled()
+	quiet.QuietHoursEnabled = true
+	// The quiet hours are in the timezone of the user, where it is 15:00.
+	quietInLondon := enabled()
+	quietInLondon.QuietHoursEnabled = true
+	quietInLondon.Timezone = "Europe/London"
+
+	settings := map[int64]*models.NotificationSetting{
+		1: enabled(),
+		2: remindersOff,
+		3: quiet,
+		4: quietInLondon,
+		5: enabled(),
+	}
+
+	tests := []struct {
+		name     string
+		category models.NotificationCategory
+		muted    map[int64]bool
+		want     []int64
+	}{
+		{name: "reminders", category: models.ReminderNotificationCategory, want: []int64{1, 4, 5}},
+		{name: "joins", category: models.JoinNotificationCategory, want: []int64{1, 2, 4, 5}},
+		{name: "muted event", category: models.JoinNotificationCategory, muted: map[int64]bool{5: true}, want: []int64{1, 2, 4}},
+		{name: "off by default", category: models.MarketingNotificationCategory, want: []int64{}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// The user without a setting is skipped, instead of panicking.
+			allowed := filterNotificationRecipients(test.category, []int64{1, 2, 3, 4, 5, 6}, settings, test.muted, now)
+
+			got := []int64{}
+			for userId, setting := range allowed {
+				if setting != settings[userId] {
+					t.Errorf("got setting %+v for user %d, want %+v", setting, userId, settings[userId])
+				}
+				got = append(got, userId)
+			}
+			sort.Slice(got, func(i, j int) bool { return got[i] < got[j] })
+			if fmt.Sprint(got) != fmt.Sprint(test.want) {
+				t.Errorf("got recipients %v, want %v", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"net/http"
+	"sportgether/internal/models"
+	"sportgether/tools"
+)
+
+const maxNotificationPageSize = 50
+
+func (app *Application) getUserNotifications(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	pageSize, err := app.readInt(query, "pageSize", 20)
+	if err != nil {
+		app.logError(err, r)
+		app.writeBadRequestResponse(w, r)
+		return
+	}
+
+	validator := tools.NewRequestValidator()
+	validator.Check(pageSize >= 1 && pageSize <= maxNotificationPageSize, "pageSize", "must be between 1 and 50")
+	if !validator.Valid() {
+		app.writeError(w, r, http.StatusBadRequest, http.StatusBadRequest, validator.Errors)
+		return
+	}
+
+	var cursor *models.UserNotificationCursor
+	if cursorId := query.Get("cursor"); cursorId != "" {
+		cursor = &models.UserNotificationCursor{}
+		err = tools.DecodeToBase32(cursor, cursorId)
+		if err != nil {
+			app.logError(err, r)
+			app.writeBadRequestResponse(w, r)
+			return
+		}
+	}
+
+	user, ok := app.GetUserContext(r)
+	if !ok {
+		app.writeInvalidAuthenticationErrorResponse(w, r)
+		return
+	}
+
+	res, err := app.daos.GetUserNotifications(user.ID, cursor, int(pageSize))
+	if err != nil {
+		app.logError(err, r)
+		app.writeInternalServerErrorResponse(w, r)
+		return
+	}
+
+	locale := app.userLocale(user.ID, r)
+	for _, notification := range res.Notifications {
+		notification.Localize(locale)
+	}
+
+	err = app.writeResponse(w, responseData{"notifications": res.Notifications, "nextCursorId": res.NextCursorId}, http.StatusOK, nil)
+	if err != nil {
+		app.logError(err, r)
+		app.writeInternalServerErrorResponse(w, r)
+	}
+}
+
+func (app *Application) getUnreadNotificationCount(w http.ResponseWriter, r *http.Request) {
+	user, ok := app.GetUserContext(r)
+	if !ok {
+		app.writeInvalidAuthenticationErrorResponse(w, r)
+		return
+	}
+
+	app.writeUnreadNotificationCount(w, r, user.ID)
+}
+
+// markNotificationsRead marks the notifications as read, or back to unread when read is false.
+func (app *Application) markNotificationsRead(w http.ResponseWriter, r *http.Request) {
+	input := struct {
+		NotificationIds []int64 `json:"notificationIds"`
+		Read            *bool   `json:"read"`
+	}{}
+	err := app.readRequest(r, &input)
+	if err != nil {
+		app.logError(err, r)
+		app.writeBadRequestResponse(w, r)
+		return
+	}
+
+	validator := tools.NewRequestValidator()
+	validator.Check(len(input.NotificationIds) > 0, "notificationIds", "must not be empty")
+	validator.Check(len(input.NotificationIds) <= maxNotificationPageSize, "notificationIds", "must not be more than 50")
+	if !validator.Valid() {
+		app.writeError(w, r, http.StatusBadRequest, http.StatusBadRequest, validator.Errors)
+		return
+	}
+
+	user, ok := app.GetUserContext(r)
+	if !ok {
+		app.writeInvalidAuthenticationErrorResponse(w, r)
+		return
+	}
+
+	read := input.Read == nil || *input.Read
+	err = app.daos.MarkUserNotificationsRead(user.ID, input.NotificationIds, read)
+	if err != nil {
+		app.logError(err, r)
+		app.writeInternalServerErrorResponse(w, r)
+		return
+	}
+
+	app.writeUnreadNotificationCount(w, r, user.ID)
+}
+
+func (app *Application) markAllNotificationsRead(w http.ResponseWriter, r *http.Request) {
+	user, ok := app.GetUserContext(r)
+	if !ok {
+		app.writeInvalidAuthenticationErrorResponse(w, r)
+		return
+	}
+
+	err := app.daos.MarkAllUserNotificationsRead(user.ID)
+	if err != nil {
+		app.logError(err, r)
+		app.writeInternalServerErrorResponse(w, r)
+		return
+	}
+
+	app.writeUnreadNotificationCount(w, r, user.ID)
+}
+
+// writeUnreadNotificationCount responds with the unread count, which the app shows as the badge.
+func (app *Application) writeUnreadNotificationCount(w http.ResponseWriter, r *http.Request, userId int64) {
+	count, err := app.daos.CountUnreadUserNotifications(userId)
+	if err != nil {
+		app.logError(err, r)
+		app.writeInternalServerErrorResponse(w, r)
+		return
+	}
+
+	err = app.writeResponse(w, responseData{"unreadCount": count}, http.StatusOK, nil)
+	if err != nil {
+		app.logError(err, r)
+		app.writeInternalServerErrorResponse(w, r)
+	}
+}
@@ -1,14 +1,10 @@
 package main
 
 import (
-	"context"
-	"errors"
+	"database/sql"
 	"net/http"
 	"sportgether/internal/models"
 	"sportgether/tools"
-	"time"
-
-	"github.com/cloudinary/cloudinary-go/v2/api/uploader"
 )
 
 func (app *Application) checkIfUserOnboarded(w http.ResponseWriter, r *http.Request) {
@@ -81,6 +77,8 @@ func (app *Application) updateUserProfile(w http.ResponseWriter, r *http.Request
 		ProfileIconUrl      *string `json:"profileIconUrl"`
 		ProfileIconPublicId *string `json:"profileIconPublicId"`
 		Gender              *string `json:"gender"`
+		// SportSkills replaces all the skill levels of the user when given.
+		SportSkills *[]models.UserSportSkill `json:"sportSkills"`
 	}{}
 	err := app.readRequest(r, input)
 	if err != nil {
@@ -88,14 +86,30 @@ func (app *Application) updateUserProfile(w http.ResponseWriter, r *http.Request
 		return
 	}
 
+	// Profile icon must be uploaded with the ticket from /v1/user/profile/icon/upload-ticket, so that it can be verified.
+	validator := tools.NewRequestValidator()
+	validator.Check(input.ProfileIconUrl == nil, "profileIconUrl", "Use /v1/user/profile/icon to update profile icon")
+	validator.Check(input.ProfileIconPublicId == nil, "profileIconPublicId", "Use /v1/user/profile/icon to update profile icon")
+	if input.SportSkills != nil {
+		eventTypes := map[string]bool{}
+		for _, skill := range *input.SportSkills {
+			validator.Check(skill.EventType != "", "sportSkills", "eventType must be provided")
+			validator.Check(!eventTypes[skill.EventType], "sportSkills", "eventType must not be repeated")
+			validator.Check(skill.SkillLevel.IsValid(), "sportSkills", "skillLevel must be BEGINNER, INTERMEDIATE or ADVANCED")
+			eventTypes[skill.EventType] = true
+		}
+	}
+	if !validator.Valid() {
+		app.writeError(w, r, http.StatusBadRequest, http.StatusBadRequest, validator.Errors)
+		return
+	}
+
 	userProfileDetail := models.UserProfileDetail{
-		PreferredName:       input.PreferredName,
-		BirthDate:           input.BirthDate,
-		Signature:           input.Signature,
-		Memo:                input.Memo,
-		ProfileIconUrl:      input.ProfileIconUrl,
-		ProfileIconPublicId: input.ProfileIconPublicId,
-		Gender:              input.Gender,
+		PreferredName: input.PreferredName,
+		BirthDate:     input.BirthDate,
+		Signature:     input.Signature,
+		Memo:          input.Memo,
+		Gender:        input.Gender,
 	}
 
 	user, ok := app.GetUserContext(r)
@@ -104,39 +118,23 @@ func (app *Application) updateUserProfile(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	// If nothing needed to update, return
-	if input.PreferredName == nil && input.BirthDate == nil && input.Signature == nil && input.Memo == nil && input.ProfileIconPublicId == nil && input.ProfileIconUrl == nil && input.Gender == nil {
-		app.logWarning("User userId = %d no need to update profile since nothing is changed", user.ID)
-		return
-	}
-
-	// This is to delete old profile icon url after performing and update.
-	// This is fail safe. If fails, no need to do anything.
-	if userProfileDetail.ProfileIconUrl != nil && userProfileDetail.ProfileIconPublicId != nil {
-		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-		defer cancel()
-
-		userProfileIconUrl, publicId, err := app.daos.GetUserProfileIconUrl(user.ID)
+	if input.SportSkills != nil {
+		err = app.daos.WithTransaction(func(tx *sql.Tx) error {
+			return app.daos.ReplaceUserSportSkills(user.ID, *input.SportSkills, tx)
+		})
 		if err != nil {
 			app.logError(err, r)
 			app.writeInternalServerErrorResponse(w, r)
 			return
 		}
+	}
 
-		// Delete only if existed.
-		if userProfileIconUrl != nil && publicId != nil {
-			res, err := app.cloudinaryApp.Upload.Destroy(ctx, uploader.DestroyParams{PublicID: *publicId})
-			if err != nil {
-				app.logError(err, r)
-				app.writeInternalServerErrorResponse(w, r)
-				return
-			}
-			if res.Result != "ok" {
-				app.logError(errors.New(res.Error.Message), r)
-				app.writeInternalServerErrorResponse(w, r)
-				return
-			}
+	// If nothing needed to update, return
+	if input.PreferredName == nil && input.BirthDate == nil && input.Signature == nil && input.Memo == nil && input.Gender == nil {
+		if input.SportSkills == nil {
+			app.logWarning("User userId = %d no need to update profile since nothing is changed", user.ID)
 		}
+		return
 	}
 
 	err = app.daos.UpdateUserProfile(user.ID, userProfileDetail)
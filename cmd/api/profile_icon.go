@@ -0,0 +1,184 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"sportgether/constants"
+	"sportgether/internal/media"
+	"strings"
+	"time"
+)
+
+const (
+	maxProfileIconSize       = 5 << 20
+	maxProfileIconDimension  = 4096
+	profileIconThumbnailSize = 200
+)
+
+var (
+	supportedProfileIconFormats = []string{"jpg", "png", "webp"}
+)
+
+func profileIconFolder(userId int64) string {
+	return fmt.Sprintf("sportgether/profile/%d", userId)
+}
+
+// requestProfileIconUpload issues a ticket for the client to upload the profile icon directly into the user's folder.
+func (app *Application) requestProfileIconUpload(w http.ResponseWriter, r *http.Request) {
+	user, ok := app.GetUserContext(r)
+	if !ok {
+		app.writeInvalidAuthenticationErrorResponse(w, r)
+		return
+	}
+
+	ticket, err := app.mediaStorage.SignUpload(profileIconFolder(user.ID), supportedProfileIconFormats, time.Now())
+	if err != nil {
+		app.logError(err, r)
+		app.writeInternalServerErrorResponse(w, r)
+		return
+	}
+
+	err = app.writeResponse(w, responseData{"uploadTicket": ticket, "maxSize": maxProfileIconSize}, http.StatusCreated, nil)
+	if err != nil {
+		app.logError(err, r)
+		app.writeInternalServerErrorResponse(w, r)
+	}
+}
+
+// updateProfileIcon verifies the uploaded image before using it as the profile icon. The old icon, or the upload
+// which is rejected, is deleted in background.
+func (app *Application) updateProfileIcon(w http.ResponseWriter, r *http.Request) {
+	input := struct {
+		PublicId string `json:"publicId"`
+	}{}
+	err := app.readRequest(r, &input)
+	if err != nil {
+		app.logError(err, r)
+		app.writeBadRequestResponse(w, r)
+		return
+	}
+
+	user, ok := app.GetUserContext(r)
+	if !ok {
+		app.writeInvalidAuthenticationErrorResponse(w, r)
+		return
+	}
+
+	if !strings.HasPrefix(input.PublicId, profileIconFolder(user.ID)+"/") {
+		app.writeError(w, r, http.StatusUnprocessableEntity, constants.MediaNotUploadedError.Code, constants.MediaNotUploadedError.Error())
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	info, err := app.mediaStorage.Inspect(ctx, input.PublicId)
+	if err != nil {
+		switch {
+		case errors.Is(err, media.MediaNotFoundError):
+			app.writeError(w, r, http.StatusUnprocessableEntity, constants.MediaNotUploadedError.Code, constants.MediaNotUploadedError.Error())
+		default:
+			app.logError(err, r)
+			app.writeInternalServerErrorResponse(w, r)
+		}
+		return
+	}
+
+	var rejection *constants.ErrorCode
+	switch {
+	case !slices.Contains(supportedProfileIconFormats, info.Format):
+		rejection = &constants.UnsupportedMediaError
+	case info.Bytes > maxProfileIconSize || info.Width > maxProfileIconDimension || info.Height > maxProfileIconDimension:
+		rejection = &constants.MediaTooLargeError
+	}
+	if rejection != nil {
+		err = app.daos.EnqueueMediaCleanup(input.PublicId, nil)
+		if err != nil {
+			app.logError(err, r)
+		}
+		app.writeError(w, r, http.StatusUnprocessableEntity, rejection.Code, rejection.Error())
+		return
+	}
+
+	thumbnailUrl, err := app.mediaStorage.Thumbnail(ctx, input.PublicId, profileIconThumbnailSize)
+	if err != nil {
+		app.logError(err, r)
+		app.writeInternalServerErrorResponse(w, r)
+		return
+	}
+
+	err = app.daos.WithTransaction(func(tx *sql.Tx) error {
+		oldPublicId, err := app.daos.UpdateUserProfileIcon(user.ID, info.Url, info.PublicId, thumbnailUrl, tx)
+		if err != nil {
+			return err
+		}
+
+		if oldPublicId == nil || *oldPublicId == info.PublicId {
+			return nil
+		}
+
+		return app.daos.EnqueueMediaCleanup(*oldPublicId, tx)
+	})
+	if err != nil {
+		app.logError(err, r)
+		app.writeInternalServerErrorResponse(w, r)
+		return
+	}
+
+	err = app.writeResponse(w, responseData{"profileIconUrl": info.Url, "profileIconThumbnailUrl": thumbnailUrl}, http.StatusOK, nil)
+	if err != nil {
+		app.logError(err, r)
+		app.writeInternalServerErrorResponse(w, r)
+	}
+}
+
+// uploadToLocalStorage takes the place of the Cloudinary upload api when media is kept locally, so that the client
+// uploads in the same way. The ticket in the form is verified instead of the bearer token.
+func (app *Application) uploadToLocalStorage(w http.ResponseWriter, r *http.Request) {
+	localStorage, ok := app.mediaStorage.(*media.LocalStorage)
+	if !ok {
+		app.notFound(w, r)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxEventPhotoSize+(1<<20))
+	err := r.ParseMultipartForm(maxEventPhotoSize)
+	if err != nil {
+		app.logError(err, r)
+		app.writeBadRequestResponse(w, r)
+		return
+	}
+
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		app.logError(err, r)
+		app.writeBadRequestResponse(w, r)
+		return
+	}
+	defer file.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	stored, err := localStorage.UploadWithTicket(ctx, r.MultipartForm.Value, file, time.Now())
+	if err != nil {
+		switch {
+		case errors.Is(err, media.BadUploadSignatureError):
+			app.writeInvalidAuthenticationErrorResponse(w, r)
+		default:
+			app.logError(err, r)
+			app.writeBadRequestResponse(w, r)
+		}
+		return
+	}
+
+	err = app.writeResponse(w, map[string]string{"public_id": stored.PublicId, "secure_url": stored.Url}, http.StatusOK, nil)
+	if err != nil {
+		app.logError(err, r)
+		app.writeInternalServerErrorResponse(w, r)
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"slices"
+	"sportgether/internal/i18n"
+	"sportgether/internal/models"
+	"time"
+)
+
+type reminderConfig struct {
+	Interval     time.Duration
+	OffsetsInMin []int
+}
+
+func (app *Application) registerScheduledJobs() {
+	app.scheduler.Every("event-reminder", app.config.reminder.Interval, app.sendEventReminders)
+	app.scheduler.Every("media-cleanup", time.Minute, app.cleanupMedia)
+	app.scheduler.Every("notification-dispatch", 15*time.Second, app.dispatchNotifications)
+	app.scheduler.Every("email-dispatch", 30*time.Second, app.dispatchEmails)
+	app.scheduler.Every("weekly-digest", time.Hour, app.sendWeeklyDigests)
+}
+
+// sendEventReminders goes through the offsets from the nearest one, so that the participant who joins late
+// only gets the reminder of the nearest offset, e.g. only the 1 hour reminder when joining 30 minutes before the event.
+func (app *Application) sendEventReminders(ctx context.Context, now time.Time) error {
+	for _, window := range reminderWindows(now, app.config.reminder.OffsetsInMin) {
+		reminders, err := app.daos.ClaimDueReminders(now, window)
+		if err != nil {
+			return err
+		}
+
+		notifications := map[int64]*models.OutboxNotification{}
+		for _, reminder := range reminders {
+			notification, ok := notifications[reminder.EventId]
+			if !ok {
+				notification = &models.OutboxNotification{
+					Kind: models.EventReminderNotification,
+					Data: map[string]string{
+						"type":    "event",
+						"eventId": fmt.Sprintf("%d", reminder.EventId),
+					},
+					Message: &models.NotificationMessage{
+						Title:    i18n.NewText("notification.event_reminder.title", nil),
+						Subtitle: reminderSubtitle(reminder.EventName, reminder.StartTime.Sub(now)),
+					},
+				}
+				notifications[reminder.EventId] = notification
+			}
+			notification.RecipientIds = append(notification.RecipientIds, reminder.ParticipantId)
+		}
+
+		for _, notification := range notifications {
+			err = app.daos.WithTransaction(func(tx *sql.Tx) error {
+				return app.daos.EnqueueNotification(notification, tx)
+			})
+			if err != nil {
+				app.logger.Error(err.Error(), "eventId", notification.Data["eventId"])
+			}
+		}
+	}
+
+	return app.dispatchNotifications(ctx, now)
+}
+
+// reminderWindows returns the windows of the offsets from the nearest one, where each window starts at the offset
+// before it, so that an event is only in the window of one offset at a time.
+func reminderWindows(now time.Time, offsetsInMin []int) []models.ReminderWindow {
+	offsets := slices.Clone(offsetsInMin)
+	slices.Sort(offsets)
+
+	windows := make([]models.ReminderWindow, 0, len(offsets))
+	from := now
+	for _, offsetInMin := range offsets {
+		to := now.Add(time.Duration(offsetInMin) * time.Minute)
+		windows = append(windows, models.ReminderWindow{OffsetInMin: offsetInMin, From: from, To: to})
+		from = to
+	}
+
+	return windows
+}
+
+func reminderSubtitle(eventName string, duration time.Duration) i18n.Text {
+	args := map[string]string{"eventName": eventName}
+	if duration < time.Hour {
+		return i18n.NewPluralText("notification.event_reminder.subtitle_minutes", int(duration.Round(time.Minute).Minutes()), args)
+	}
+	return i18n.NewPluralText("notification.event_reminder.subtitle_hours", int(duration.Round(time.Hour).Hours()), args)
+}
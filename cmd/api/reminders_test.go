@@ -0,0 +1,185 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"sort"
+	"sportgether/internal/models"
+	"sportgether/internal/scheduler"
+	"testing"
+	"time"
+)
+
+func TestReminderWindows(t *testing.T) {
+	start := time.Date(2100, time.January, 1, 0, 0, 0, 0, time.UTC)
+	clock := &fakeClock{now: start}
+	jobs := scheduler.New(clock, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	var windows []models.ReminderWindow
+	jobs.Every("event-reminder", time.Minute, func(ctx context.Context, now time.Time) error {
+		// The offsets are not given in order.
+		windows = reminderWindows(now, []int{24 * 60, 60})
+		return nil
+	})
+
+	// dueOffset is the offset of the window with the event, which ClaimDueReminders checks by start_time > From AND
+	// start_time <= To, or 0 for none.
+	dueOffset := func(eventStart time.Time) int {
+		for _, window := range windows {
+			if eventStart.After(window.From) && !eventStart.After(window.To) {
+				return window.OffsetInMin
+			}
+		}
+		return 0
+	}
+
+	tests := []struct {
+		name       string
+		tick       time.Duration
+		eventStart time.Duration
+		want       int
+	}{
+		{name: "more than 24 hours before", tick: 0, eventStart: 24*time.Hour + time.Minute, want: 0},
+		{name: "24 hours before", tick: 0, eventStart: 24 * time.Hour, want: 24 * 60},
+		{name: "just over 1 hour before", tick: 0, eventStart: time.Hour + time.Minute, want: 24 * 60},
+		{name: "1 hour before", tick: 0, eventStart: time.Hour, want: 60},
+		{name: "joined 30 minutes before", tick: 0, eventStart: 30 * time.Minute, want: 60},
+		{name: "starting now", tick: 0, eventStart: 0, want: 0},
+		{name: "started", tick: 0, eventStart: -time.Minute, want: 0},
+		{name: "later tick", tick: 23 * time.Hour, eventStart: 24 * time.Hour, want: 60},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			clock.Set(start.Add(test.tick))
+			jobs.RunOnce(context.Background(), "event-reminder")
+
+			if got := dueOffset(start.Add(test.eventStart)); got != test.want {
+				t.Errorf("got offset %d for the event, want %d", got, test.want)
+			}
+		})
+	}
+}
+
+type sentReminder struct {
+	eventId       int64
+	participantId int64
+	offsetInMin   int
+}
+
+func readSentReminders(t *testing.T, app *testApplication) []sentReminder {
+	t.Helper()
+
+	rows, err := app.db.Query(`SELECT event_id, participant_id, offset_in_min FROM sportgether_schema.event_reminder`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+
+	reminders := []sentReminder{}
+	for rows.Next() {
+		reminder := sentReminder{}
+		err = rows.Scan(&reminder.eventId, &reminder.participantId, &reminder.offsetInMin)
+		if err != nil {
+			t.Fatal(err)
+		}
+		reminders = append(reminders, reminder)
+	}
+	if err = rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	sort.Slice(reminders, func(i, j int) bool {
+		a, b := reminders[i], reminders[j]
+		if a.eventId != b.eventId {
+			return a.eventId < b.eventId
+		}
+		if a.offsetInMin != b.offsetInMin {
+			return a.offsetInMin > b.offsetInMin
+		}
+		return a.participantId < b.participantId
+	})
+	return reminders
+}
+
+// pushedReminders counts the reminder pushes by the event.
+func pushedReminders(app *testApplication) map[string]int {
+	counts := map[string]int{}
+	for _, message := range app.sender.Messages() {
+		counts[message.Data["eventId"]] += len(message.Tokens)
+	}
+	return counts
+}
+
+func TestSendEventReminders(t *testing.T) {
+	start := time.Date(2100, time.January, 1, 0, 0, 0, 0, time.UTC)
+	app := newTestApplication(t, start)
+
+	hostId := insertTestUser(t, app, "host")
+	participantId := insertTestUser(t, app, "participant")
+	// Starts in 25 hours, so that it is reminded 24 hours and 1 hour before.
+	eventId := insertTestEvent(t, app, hostId, start.Add(25*time.Hour), participantId)
+	// Starts in 90 minutes, which is joined too late for the 24 hour reminder.
+	lateEventId := insertTestEvent(t, app, hostId, start.Add(90*time.Minute), participantId)
+
+	runAt := func(now time.Time) {
+		app.clock.Set(now)
+		app.scheduler.RunOnce(context.Background(), "event-reminder")
+	}
+
+	runAt(start)
+	if reminders := readSentReminders(t, app); len(reminders) != 0 {
+		t.Fatalf("got reminders %v before any offset is due", reminders)
+	}
+
+	// 24 hours before the event, and 30 minutes before the late event, which only gets the nearest offset.
+	runAt(start.Add(time.Hour))
+	want := []sentReminder{
+		{eventId, hostId, 24 * 60},
+		{eventId, participantId, 24 * 60},
+		{lateEventId, hostId, 60},
+		{lateEventId, participantId, 60},
+	}
+	assertSentReminders(t, app, want)
+
+	// Each reminder is only claimed once per offset, however many times the job runs within the window, e.g. by
+	// another instance.
+	runAt(start.Add(time.Hour))
+	runAt(start.Add(time.Hour + 30*time.Second))
+	assertSentReminders(t, app, want)
+
+	// 50 minutes before the event.
+	runAt(start.Add(24*time.Hour + 10*time.Minute))
+	want = []sentReminder{
+		{eventId, hostId, 24 * 60},
+		{eventId, participantId, 24 * 60},
+		{eventId, hostId, 60},
+		{eventId, participantId, 60},
+		{lateEventId, hostId, 60},
+		{lateEventId, participantId, 60},
+	}
+	assertSentReminders(t, app, want)
+
+	// Started, so nothing more is due.
+	runAt(start.Add(25*time.Hour + time.Minute))
+	assertSentReminders(t, app, want)
+
+	pushed := pushedReminders(app)
+	if got := pushed[fmt.Sprintf("%d", eventId)]; got != 4 {
+		t.Errorf("got %d reminders pushed for the event, want 4", got)
+	}
+	if got := pushed[fmt.Sprintf("%d", lateEventId)]; got != 2 {
+		t.Errorf("got %d reminders pushed for the late event, want 2", got)
+	}
+}
+
+func assertSentReminders(t *testing.T, app *testApplication, want []sentReminder) {
+	t.Helper()
+
+	got := readSentReminders(t, app)
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("got reminders %v, want %v", got, want)
+	}
+}
@@ -2,25 +2,20 @@ package main
 
 import (
 	"net/http"
-	remoteConfig "sportgether/remote_config"
+	"strings"
+	"time"
 )
 
-type MainMessage struct {
-	Title      string `json:"title"`
-	Subtitle   string `json:"subtitle"`
-	ButtonText string `json:"buttonText"`
-}
+// How often the config files are checked for changes, see remoteConfig.Store.
+const remoteConfigWatchInterval = 5 * time.Second
 
 func (app *Application) getMainMessage(w http.ResponseWriter, r *http.Request) {
-	mainMessage := MainMessage{}
-	err := readJsonFromFile("./data/main_message_config.json", &mainMessage)
-	if err != nil {
-		app.logError(err, r)
-		app.writeInternalServerErrorResponse(w, r)
+	mainMessage := app.remoteConfig.Current().MainMessage
+	if app.notModified(w, r, mainMessage.ETag) {
 		return
 	}
 
-	err = app.writeResponse(w, responseData{"message": mainMessage}, http.StatusOK, nil)
+	err := app.writeResponse(w, responseData{"message": mainMessage.Value}, http.StatusOK, nil)
 	if err != nil {
 		app.logError(err, r)
 		app.writeInternalServerErrorResponse(w, r)
@@ -28,19 +23,19 @@ func (app *Application) getMainMessage(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-func (app *Application) getSportDetails(w http.ResponseWriter, r *http.Request) {
-	sportDetails := remoteConfig.SportDetails{}
-	err := readJsonFromFile("./data/available_sports_detail.json", &sportDetails)
-	if err != nil {
-		app.logError(err, r)
-		app.writeInternalServerErrorResponse(w, r)
-		return
-	}
+// notModified sets the ETag of the response, and writes 304 Not Modified instead when the client has cached the same
+// version, so that the clients only download the config again after it changes.
+func (app *Application) notModified(w http.ResponseWriter, r *http.Request, etag string) bool {
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "no-cache")
 
-	err = app.writeResponse(w, sportDetails, http.StatusOK, nil)
-	if err != nil {
-		app.logError(err, r)
-		app.writeInternalServerErrorResponse(w, r)
-		return
+	for _, candidate := range strings.Split(r.Header.Get("If-None-Match"), ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == etag || candidate == "*" {
+			w.WriteHeader(http.StatusNotModified)
+			return true
+		}
 	}
+
+	return false
 }
@@ -3,12 +3,14 @@ package main
 import (
 	"fmt"
 	"net/http"
+	"sportgether/internal/media"
+	"strings"
 
 	"github.com/julienschmidt/httprouter"
 )
 
 func (app *Application) routes() http.Handler {
-	httpRouter := httprouter.New()
+	httpRouter := &router{httprouter.New()}
 
 	httpRouter.NotFound = http.HandlerFunc(app.notFound)
 	httpRouter.MethodNotAllowed = http.HandlerFunc(app.methodNotAllowed)
@@ -18,11 +20,43 @@ func (app *Application) routes() http.Handler {
 	eventHandlerFunc(app, httpRouter)
 	profileHandlerFunc(app, httpRouter)
 	messageCentreHandlerFunc(app, httpRouter)
+	calendarHandlerFunc(app, httpRouter)
+	galleryHandlerFunc(app, httpRouter)
+	clubHandlerFunc(app, httpRouter)
+	tournamentHandlerFunc(app, httpRouter)
+	notificationHandlerFunc(app, httpRouter)
+	adminHandlerFunc(app, httpRouter)
 
-	return app.recoverPanic(app.requiredMinAppVersion(app.authenticationHandler(httpRouter)))
+	return app.metricRequests(app.recoverPanic(app.requiredMinAppVersion(app.authenticationHandler(httpRouter))))
 	//return httpRouter
 }
 
+// router records the pattern of the route matched, so that the metrics are labelled by the route instead of the path.
+type router struct {
+	*httprouter.Router
+}
+
+func (r *router) HandlerFunc(method string, path string, handler http.HandlerFunc) {
+	r.Router.HandlerFunc(method, path, func(w http.ResponseWriter, req *http.Request) {
+		setRoute(req, path)
+		handler(w, req)
+	})
+}
+
+// ServeFiles is the one of httprouter, with the route recorded.
+func (r *router) ServeFiles(path string, root http.FileSystem) {
+	if !strings.HasSuffix(path, "/*filepath") {
+		panic("path must end with /*filepath in path '" + path + "'")
+	}
+
+	fileServer := http.FileServer(root)
+	r.Router.GET(path, func(w http.ResponseWriter, req *http.Request, params httprouter.Params) {
+		setRoute(req, path)
+		req.URL.Path = params.ByName("filepath")
+		fileServer.ServeHTTP(w, req)
+	})
+}
+
 // Custom method not allow handler
 func (app *Application) methodNotAllowed(w http.ResponseWriter, r *http.Request) {
 	message := fmt.Sprintf("The method '%s' is not supported!", r.Method)
@@ -35,27 +69,31 @@ func (app *Application) notFound(w http.ResponseWriter, r *http.Request) {
 	app.writeError(w, r, http.StatusNotFound, http.StatusNotFound, message)
 }
 
-func userHandlerFunc(app *Application, httpRouter *httprouter.Router) {
+func userHandlerFunc(app *Application, httpRouter *router) {
 	httpRouter.HandlerFunc(http.MethodPost, "/v1/user/register", app.registerUser)
 	httpRouter.HandlerFunc(http.MethodPost, "/v1/user/login", app.loginUser)
+	httpRouter.HandlerFunc(http.MethodPost, "/v1/user/logout", app.requiredAuthenticatedUser(app.logoutUser))
 	httpRouter.HandlerFunc(http.MethodPut, "/v1/user/activate", app.activateUser)
 	httpRouter.HandlerFunc(http.MethodPut, "/v1/user/deregister-request", app.deregisterUserRequest)
 	httpRouter.HandlerFunc(http.MethodDelete, "/v1/user/deregister", app.deactivateUser)
 }
 
-func profileHandlerFunc(app *Application, httpRouter *httprouter.Router) {
+func profileHandlerFunc(app *Application, httpRouter *router) {
 	httpRouter.HandlerFunc(http.MethodGet, "/v1/user/profile/onboard-status", app.requiredActivatedUser(app.checkIfUserOnboarded))
 	httpRouter.HandlerFunc(http.MethodPost, "/v1/user/profile/setup", app.requiredActivatedUser(app.onboardUser))
 	httpRouter.HandlerFunc(http.MethodGet, "/v1/user/profile", app.requiredActivatedUser(app.getUserProfileDetail))
 	httpRouter.HandlerFunc(http.MethodGet, "/v1/user/profile/other/:userId", app.requiredActivatedUser(app.getOtherUserProfileDetail))
 	httpRouter.HandlerFunc(http.MethodPatch, "/v1/user/profile/update", app.requiredActivatedUser(app.updateUserProfile))
+	httpRouter.HandlerFunc(http.MethodPost, "/v1/user/profile/icon/upload-ticket", app.requiredActivatedUser(app.requestProfileIconUpload))
+	httpRouter.HandlerFunc(http.MethodPatch, "/v1/user/profile/icon", app.requiredActivatedUser(app.updateProfileIcon))
 	httpRouter.HandlerFunc(http.MethodGet, "/v1/user/profile/mutual-info/:userId", app.requiredActivatedUser(app.getMutualEventInfo))
 }
 
-func eventHandlerFunc(app *Application, httpRouter *httprouter.Router) {
+func eventHandlerFunc(app *Application, httpRouter *router) {
 	httpRouter.HandlerFunc(http.MethodPost, "/v1/event/all", app.requiredActivatedUser(app.getAllEvents))
 	httpRouter.HandlerFunc(http.MethodPost, "/v1/user/event", app.requiredActivatedUser(app.getUserEvents))
 	httpRouter.HandlerFunc(http.MethodGet, "/v1/event/:eventId", app.requiredActivatedUser(app.getEventById))
+	httpRouter.HandlerFunc(http.MethodGet, "/v1/event/:eventId/ics", app.requiredActivatedUser(app.downloadEventCalendar))
 	httpRouter.HandlerFunc(http.MethodPost, "/v1/event/create", app.requiredActivatedUser(app.createEvent))
 	httpRouter.HandlerFunc(http.MethodPatch, "/v1/event/update/:eventId", app.requiredActivatedUser(app.updateEvent))
 	httpRouter.HandlerFunc(http.MethodPost, "/v1/event/join", app.requiredActivatedUser(app.joinEvent))
@@ -64,14 +102,100 @@ func eventHandlerFunc(app *Application, httpRouter *httprouter.Router) {
 	httpRouter.HandlerFunc(http.MethodDelete, "/v1/event/delete/:eventId", app.requiredActivatedUser(app.deleteEvent))
 	httpRouter.HandlerFunc(http.MethodPatch, "/v1/event/host/config/update", app.requiredActivatedUser(app.updateUserHostingConfigInfo))
 	httpRouter.HandlerFunc(http.MethodPost, "/v1/event/host/config-init/", app.requiredActivatedUser(app.initHostingConfig))
+	httpRouter.HandlerFunc(http.MethodPatch, "/v1/event/host/transfer/:eventId", app.requiredActivatedUser(app.transferEventHost))
+	httpRouter.HandlerFunc(http.MethodPost, "/v1/event/co-host/add", app.requiredActivatedUser(app.addEventCoHost))
+	httpRouter.HandlerFunc(http.MethodDelete, "/v1/event/co-host/remove/:eventId/:userId", app.requiredActivatedUser(app.removeEventCoHost))
+	httpRouter.HandlerFunc(http.MethodPost, "/v1/event/participant/kick", app.requiredActivatedUser(app.kickEventParticipant))
+	httpRouter.HandlerFunc(http.MethodGet, "/v1/event/:eventId/teams", app.requiredActivatedUser(app.getEventTeams))
+	httpRouter.HandlerFunc(http.MethodPost, "/v1/event/team/generate", app.requiredActivatedUser(app.generateEventTeams))
+	httpRouter.HandlerFunc(http.MethodPost, "/v1/event/team/reshuffle", app.requiredActivatedUser(app.reshuffleEventTeams))
+	httpRouter.HandlerFunc(http.MethodPost, "/v1/event/team/publish", app.requiredActivatedUser(app.publishEventTeams))
 }
 
-func messageCentreHandlerFunc(app *Application, httpRouter *httprouter.Router) {
+func messageCentreHandlerFunc(app *Application, httpRouter *router) {
 	httpRouter.HandlerFunc(http.MethodGet, "/v1/message-centre/sports/all", app.requiredActivatedUser(app.getSportDetails))
 	httpRouter.HandlerFunc(http.MethodGet, "/v1/message-centre/main", app.requiredActivatedUser(app.getMainMessage))
+	// Public, as the app checks the flags before logging in too
+	httpRouter.HandlerFunc(http.MethodGet, "/v1/message-centre/flags", app.getFeatureFlags)
 	httpRouter.HandlerFunc(http.MethodPost, "/v1/message-centre/register", app.requiredActivatedUser(app.registerFirebaseToken))
+	httpRouter.HandlerFunc(http.MethodGet, "/v1/message-centre/setting", app.requiredActivatedUser(app.getNotificationSetting))
+	httpRouter.HandlerFunc(http.MethodPatch, "/v1/message-centre/setting", app.requiredActivatedUser(app.updateNotificationSetting))
+	httpRouter.HandlerFunc(http.MethodPost, "/v1/message-centre/event-mute", app.requiredActivatedUser(app.muteEventNotification))
+	httpRouter.HandlerFunc(http.MethodDelete, "/v1/message-centre/event-mute/:eventId", app.requiredActivatedUser(app.unmuteEventNotification))
+	httpRouter.HandlerFunc(http.MethodGet, "/v1/message-centre/digest", app.requiredActivatedUser(app.getDigestSetting))
+	httpRouter.HandlerFunc(http.MethodPatch, "/v1/message-centre/digest", app.requiredActivatedUser(app.updateDigestSetting))
+	// Public, as the unsubscribe link in the digest email identifies the user by the signed token
+	httpRouter.HandlerFunc(http.MethodGet, "/v1/digest/unsubscribe", app.unsubscribeDigest)
+	httpRouter.HandlerFunc(http.MethodPost, "/v1/digest/unsubscribe", app.unsubscribeDigest)
+}
+
+func calendarHandlerFunc(app *Application, httpRouter *router) {
+	httpRouter.HandlerFunc(http.MethodPost, "/v1/user/calendar/feed", app.requiredActivatedUser(app.createCalendarFeed))
+	httpRouter.HandlerFunc(http.MethodDelete, "/v1/user/calendar/feed", app.requiredActivatedUser(app.deleteCalendarFeed))
+	// Public, as calendar apps identify the user by the secret token in the url
+	httpRouter.HandlerFunc(http.MethodGet, "/v1/calendar/:token", app.getCalendarFeed)
+}
+
+func galleryHandlerFunc(app *Application, httpRouter *router) {
+	httpRouter.HandlerFunc(http.MethodGet, "/v1/event-gallery/:eventId", app.requiredActivatedUser(app.getEventPhotos))
+	httpRouter.HandlerFunc(http.MethodPost, "/v1/event-gallery/:eventId", app.allowSlowUpload(app.requiredActivatedUser(app.uploadEventPhoto)))
+	httpRouter.HandlerFunc(http.MethodDelete, "/v1/event-gallery/:eventId/:photoId", app.requiredActivatedUser(app.deleteEventPhoto))
+	httpRouter.HandlerFunc(http.MethodPatch, "/v1/event-gallery/:eventId/:photoId/visibility", app.requiredActivatedUser(app.updateEventPhotoVisibility))
+
+	// Only when media is kept locally, which is during development.
+	if localStorage, ok := app.mediaStorage.(*media.LocalStorage); ok {
+		httpRouter.ServeFiles(localStorage.UrlPath+"*filepath", http.Dir(localStorage.Root))
+		httpRouter.HandlerFunc(http.MethodPost, localStorage.UploadUrl, app.allowSlowUpload(app.uploadToLocalStorage))
+	}
+}
+
+func clubHandlerFunc(app *Application, httpRouter *router) {
+	httpRouter.HandlerFunc(http.MethodPost, "/v1/club/create", app.requiredActivatedUser(app.createClub))
+	httpRouter.HandlerFunc(http.MethodGet, "/v1/user/club", app.requiredActivatedUser(app.getUserClubs))
+	httpRouter.HandlerFunc(http.MethodGet, "/v1/club/:clubId", app.requiredActivatedUser(app.getClubDetail))
+	httpRouter.HandlerFunc(http.MethodGet, "/v1/club/:clubId/members", app.requiredActivatedUser(app.getClubMembers))
+	httpRouter.HandlerFunc(http.MethodGet, "/v1/club/:clubId/events", app.requiredActivatedUser(app.getClubEvents))
+	httpRouter.HandlerFunc(http.MethodGet, "/v1/club/:clubId/join-requests", app.requiredActivatedUser(app.getClubJoinRequests))
+	httpRouter.HandlerFunc(http.MethodPatch, "/v1/club/update/:clubId", app.requiredActivatedUser(app.updateClub))
+	httpRouter.HandlerFunc(http.MethodDelete, "/v1/club/delete/:clubId", app.requiredActivatedUser(app.deleteClub))
+	httpRouter.HandlerFunc(http.MethodPost, "/v1/club/join", app.requiredActivatedUser(app.joinClub))
+	httpRouter.HandlerFunc(http.MethodDelete, "/v1/club/quit/:clubId", app.requiredActivatedUser(app.quitClub))
+	httpRouter.HandlerFunc(http.MethodDelete, "/v1/club/join-request/cancel/:clubId", app.requiredActivatedUser(app.cancelClubJoinRequest))
+	httpRouter.HandlerFunc(http.MethodPost, "/v1/club/join-request/review", app.requiredActivatedUser(app.reviewClubJoinRequest))
+	httpRouter.HandlerFunc(http.MethodPatch, "/v1/club/member/role", app.requiredActivatedUser(app.updateClubMemberRole))
+	httpRouter.HandlerFunc(http.MethodDelete, "/v1/club/member/remove/:clubId/:userId", app.requiredActivatedUser(app.removeClubMember))
+}
+
+func tournamentHandlerFunc(app *Application, httpRouter *router) {
+	httpRouter.HandlerFunc(http.MethodPost, "/v1/tournament/create", app.requiredActivatedUser(app.createTournament))
+	httpRouter.HandlerFunc(http.MethodGet, "/v1/tournament/:tournamentId", app.requiredActivatedUser(app.getTournament))
+	httpRouter.HandlerFunc(http.MethodGet, "/v1/tournament/:tournamentId/standings", app.requiredActivatedUser(app.getTournamentStandings))
+	httpRouter.HandlerFunc(http.MethodPost, "/v1/tournament/register", app.requiredActivatedUser(app.registerTournament))
+	httpRouter.HandlerFunc(http.MethodDelete, "/v1/tournament/withdraw/:tournamentId", app.requiredActivatedUser(app.withdrawTournament))
+	httpRouter.HandlerFunc(http.MethodPost, "/v1/tournament/start", app.requiredActivatedUser(app.startTournament))
+	httpRouter.HandlerFunc(http.MethodDelete, "/v1/tournament/cancel/:tournamentId", app.requiredActivatedUser(app.cancelTournament))
+	httpRouter.HandlerFunc(http.MethodPost, "/v1/tournament/match/schedule", app.requiredActivatedUser(app.scheduleTournamentMatch))
+	httpRouter.HandlerFunc(http.MethodPost, "/v1/tournament/match/score", app.requiredActivatedUser(app.reportTournamentMatchScore))
 }
 
-func websiteHandlerFunc(app *Application, httpRouter *httprouter.Router) {
+func websiteHandlerFunc(app *Application, httpRouter *router) {
 	httpRouter.ServeFiles("/Sport-Gether/*filepath", http.Dir("static"))
 }
+
+func notificationHandlerFunc(app *Application, httpRouter *router) {
+	httpRouter.HandlerFunc(http.MethodGet, "/v1/user/notification", app.requiredActivatedUser(app.getUserNotifications))
+	httpRouter.HandlerFunc(http.MethodGet, "/v1/user/notification/unread-count", app.requiredActivatedUser(app.getUnreadNotificationCount))
+	httpRouter.HandlerFunc(http.MethodPatch, "/v1/user/notification/read", app.requiredActivatedUser(app.markNotificationsRead))
+	httpRouter.HandlerFunc(http.MethodPatch, "/v1/user/notification/read-all", app.requiredActivatedUser(app.markAllNotificationsRead))
+}
+
+func adminHandlerFunc(app *Application, httpRouter *router) {
+	httpRouter.HandlerFunc(http.MethodGet, "/v1/admin/email", app.requiredAdminUser(app.getQueuedEmails))
+	httpRouter.HandlerFunc(http.MethodGet, "/v1/admin/email/:emailId/attempts", app.requiredAdminUser(app.getEmailDeliveryAttempts))
+	httpRouter.HandlerFunc(http.MethodGet, "/v1/admin/sport", app.requiredAdminUser(app.getAdminSports))
+	httpRouter.HandlerFunc(http.MethodPost, "/v1/admin/sport", app.requiredAdminUser(app.createSport))
+	httpRouter.HandlerFunc(http.MethodPatch, "/v1/admin/sport/update/:sport", app.requiredAdminUser(app.updateSport))
+	httpRouter.HandlerFunc(http.MethodDelete, "/v1/admin/sport/retire/:sport", app.requiredAdminUser(app.retireSport))
+	httpRouter.HandlerFunc(http.MethodGet, "/v1/admin/hosting-quota/:userId", app.requiredAdminUser(app.getHostingQuota))
+	httpRouter.HandlerFunc(http.MethodPatch, "/v1/admin/hosting-quota/update/:userId", app.requiredAdminUser(app.updateHostingQuota))
+}
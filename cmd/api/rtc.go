@@ -4,13 +4,17 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"sportgether/internal/i18n"
+	"sportgether/internal/models"
+	"sportgether/tools"
 
 	"firebase.google.com/go/v4/messaging"
 )
 
 func (app *Application) registerFirebaseToken(w http.ResponseWriter, r *http.Request) {
 	input := struct {
-		Token string `json:"token"`
+		Token    string                 `json:"token"`
+		Platform *models.DevicePlatform `json:"platform"`
 	}{}
 
 	err := app.readRequest(r, &input)
@@ -20,13 +24,21 @@ func (app *Application) registerFirebaseToken(w http.ResponseWriter, r *http.Req
 		return
 	}
 
+	validator := tools.NewRequestValidator()
+	validator.Check(input.Token != "", "token", "must be provided")
+	validator.Check(input.Platform == nil || input.Platform.IsValid(), "platform", "must be IOS, ANDROID or WEB")
+	if !validator.Valid() {
+		app.writeError(w, r, http.StatusBadRequest, http.StatusBadRequest, validator.Errors)
+		return
+	}
+
 	user, ok := app.GetUserContext(r)
 	if !ok {
 		app.writeInvalidAuthenticationErrorResponse(w, r)
 		return
 	}
 
-	err = app.daos.UpdateFCMToken(user.ID, input.Token)
+	err = app.daos.UpdateFCMToken(user.ID, input.Token, input.Platform)
 	if err != nil {
 		app.logError(err, r)
 		app.writeInternalServerErrorResponse(w, r)
@@ -34,93 +46,219 @@ func (app *Application) registerFirebaseToken(w http.ResponseWriter, r *http.Req
 }
 
 func (app *Application) broadCastEventUpdatedMessage(r *http.Request, eventId int64) error {
-	tokens, err := app.daos.GetEventParticipantTokens(eventId)
+	participantIds, err := app.daos.GetEventParticipantIds(eventId, nil)
 	if err != nil {
 		return err
 	}
 
-	// Create a list containing up to 500 registration tokens.
-	// This registration tokens come from the client FCM SDKs.
-	message := &messaging.MulticastMessage{
+	return app.notify(r, &models.OutboxNotification{
+		Kind: models.EventUpdatedNotification,
 		Data: map[string]string{
-			"type":     "event",
-			"eventId":  fmt.Sprintf("%d", eventId),
-			"title":    "The event you participated had been updated",
-			"subtitle": "Click here to view the updated details",
+			"type":    "event",
+			"eventId": fmt.Sprintf("%d", eventId),
 		},
-		Tokens: *tokens,
-	}
-
-	app.fcmSend(r, context.Background(), message)
-
-	return nil
+		Message: &models.NotificationMessage{
+			Title:    i18n.NewText("notification.event_updated.title", nil),
+			Subtitle: i18n.NewText("notification.event_updated.subtitle", nil),
+		},
+		RecipientIds: participantIds,
+	})
 }
 
-func (app *Application) broadCastEventJoinedMessage(r *http.Request, eventId int64, userPreferredName string) error {
-	tokens, err := app.daos.GetEventParticipantTokens(eventId)
+func (app *Application) broadCastEventHostTransferredMessage(r *http.Request, eventId int64, newHostPreferredName string) error {
+	participantIds, err := app.daos.GetEventParticipantIds(eventId, nil)
 	if err != nil {
 		return err
 	}
 
-	// Create a list containing up to 500 registration tokens.
-	// This registration tokens come from the client FCM SDKs.
-	message := &messaging.MulticastMessage{
+	return app.notify(r, &models.OutboxNotification{
+		Kind: models.EventHostTransferredNotification,
 		Data: map[string]string{
-			"type":     "event",
-			"eventId":  fmt.Sprintf("%d", eventId),
-			"title":    "Welcome your partner!",
-			"subtitle": fmt.Sprintf("%s has joined the event!", userPreferredName),
+			"type":    "event",
+			"eventId": fmt.Sprintf("%d", eventId),
 		},
-		Tokens: *tokens,
+		Message: &models.NotificationMessage{
+			Title:    i18n.NewText("notification.event_host_transferred.title", nil),
+			Subtitle: i18n.NewText("notification.event_host_transferred.subtitle", map[string]string{"name": newHostPreferredName}),
+		},
+		RecipientIds: participantIds,
+	})
+}
+
+func (app *Application) sendEventKickedMessage(r *http.Request, eventId int64, userId int64, eventName string, reason *string) error {
+	subtitle := i18n.NewText("notification.event_kicked.subtitle", map[string]string{"eventName": eventName})
+	if reason != nil && *reason != "" {
+		subtitle = i18n.NewText("notification.event_kicked.subtitle_with_reason", map[string]string{"eventName": eventName, "reason": *reason})
 	}
 
-	app.fcmSend(r, context.Background(), message)
+	return app.notify(r, &models.OutboxNotification{
+		Kind: models.EventKickedNotification,
+		Data: map[string]string{
+			"type":    "event",
+			"eventId": fmt.Sprintf("%d", eventId),
+		},
+		Message: &models.NotificationMessage{
+			Title:    i18n.NewText("notification.event_kicked.title", nil),
+			Subtitle: subtitle,
+		},
+		RecipientIds: []int64{userId},
+	})
+}
+
+func (app *Application) sendClubJoinRequestReviewedMessage(r *http.Request, clubId int64, userId int64, clubName string, approved bool) error {
+	key := "notification.club_join_approved"
+	if !approved {
+		key = "notification.club_join_declined"
+	}
 
-	return nil
+	return app.notify(r, &models.OutboxNotification{
+		Kind: models.ClubJoinReviewedNotification,
+		Data: map[string]string{
+			"type":   "club",
+			"clubId": fmt.Sprintf("%d", clubId),
+		},
+		Message: &models.NotificationMessage{
+			Title:    i18n.NewText(key+".title", nil),
+			Subtitle: i18n.NewText(key+".subtitle", map[string]string{"clubName": clubName}),
+		},
+		RecipientIds: []int64{userId},
+	})
 }
 
-func (app *Application) broadcastEventDeletedMessage(r *http.Request, eventId int64, userId int64) error {
-	// Get event detail
-	event, err := app.daos.GetEventById(eventId, userId)
+func (app *Application) broadcastTournamentStartedMessage(r *http.Request, tournamentId int64) error {
+	entries, err := app.daos.GetTournamentEntries(tournamentId)
 	if err != nil {
 		return err
 	}
+	entryIds := make([]int64, 0, len(entries))
+	for _, entry := range entries {
+		entryIds = append(entryIds, entry.ID)
+	}
 
-	// Obtain a messaging.Client from the App.
-	tokens, err := app.daos.GetEventParticipantTokens(eventId)
+	memberIds, err := app.daos.GetTournamentEntryMemberIds(entryIds)
 	if err != nil {
 		return err
 	}
 
-	// Create a list containing up to 500 registration tokens.
-	// This registration tokens come from the client FCM SDKs.
-	message := &messaging.MulticastMessage{
+	return app.notify(r, &models.OutboxNotification{
+		Kind: models.TournamentStartedNotification,
 		Data: map[string]string{
-			"type":     "event",
-			"eventId":  fmt.Sprintf("%d", eventId),
-			"title":    "Event cancelled",
-			"subtitle": fmt.Sprintf("The host had cancelled the event: %s", event.EventName),
+			"type":         "tournament",
+			"tournamentId": fmt.Sprintf("%d", tournamentId),
+		},
+		Message: &models.NotificationMessage{
+			Title:    i18n.NewText("notification.tournament_started.title", nil),
+			Subtitle: i18n.NewText("notification.tournament_started.subtitle", nil),
 		},
-		Tokens: *tokens,
+		RecipientIds: memberIds,
+	})
+}
+
+func (app *Application) sendTournamentMatchScheduledMessage(r *http.Request, eventId int64, eventName string, entryIds []int64) error {
+	memberIds, err := app.daos.GetTournamentEntryMemberIds(entryIds)
+	if err != nil {
+		return err
 	}
 
-	app.fcmSend(r, context.Background(), message)
+	return app.notify(r, &models.OutboxNotification{
+		Kind: models.TournamentMatchScheduledNotification,
+		Data: map[string]string{
+			"type":    "event",
+			"eventId": fmt.Sprintf("%d", eventId),
+		},
+		Message: &models.NotificationMessage{
+			Title:    i18n.NewText("notification.tournament_match_scheduled.title", nil),
+			Subtitle: i18n.NewText("notification.tournament_match_scheduled.subtitle", map[string]string{"eventName": eventName}),
+		},
+		RecipientIds: memberIds,
+	})
+}
 
-	return nil
+func (app *Application) broadcastEventTeamsPublishedMessage(r *http.Request, eventId int64, eventName string) error {
+	participantIds, err := app.daos.GetEventParticipantIds(eventId, nil)
+	if err != nil {
+		return err
+	}
+
+	return app.notify(r, &models.OutboxNotification{
+		Kind: models.EventTeamsPublishedNotification,
+		Data: map[string]string{
+			"type":    "event",
+			"eventId": fmt.Sprintf("%d", eventId),
+		},
+		Message: &models.NotificationMessage{
+			Title:    i18n.NewText("notification.event_teams_published.title", nil),
+			Subtitle: i18n.NewText("notification.event_teams_published.subtitle", map[string]string{"eventName": eventName}),
+		},
+		RecipientIds: participantIds,
+	})
 }
 
-func (app *Application) fcmSend(r *http.Request, context context.Context, message *messaging.MulticastMessage) {
-	app.background(func() {
-		client, err := app.firebaseApp.Messaging(context)
-		if err != nil {
-			app.logError(err, r)
-			return
-		}
+// pushFailedError is returned by sendMulticast when FCM failed the message for some of the tokens for a reason other
+// than the token itself, e.g. unavailable or quota exceeded, so that only these tokens are retried.
+type pushFailedError struct {
+	Tokens []string
+	Err    error
+}
 
-		num, err := client.SendEachForMulticast(context, message)
+func (e *pushFailedError) Error() string {
+	return fmt.Sprintf("push failed for %d of the tokens: %v", len(e.Tokens), e.Err)
+}
+
+func (e *pushFailedError) Unwrap() error {
+	return e.Err
+}
+
+// sendMulticast sends the message right away. Use notify instead, so that the notification is kept in the inbox
+// and retried when the send fails.
+//
+// FCM only fails the whole send for the invalid input, and reports every other failure by the token in the
+// responses, so these are returned as *pushFailedError.
+func (app *Application) sendMulticast(context context.Context, message *messaging.MulticastMessage) error {
+	if len(message.Tokens) == 0 {
+		return nil
+	}
+
+	num, err := app.pushSender.SendEachForMulticast(context, message)
+	if err != nil {
+		app.metrics.pushSendErrors.Inc()
+		return err
+	}
+	app.metrics.pushMessages.Add(float64(num.SuccessCount), "success")
+	app.metrics.pushMessages.Add(float64(num.FailureCount), "failure")
+	app.logInfo("fcm multicast sent", "successCount", num.SuccessCount, "failureCount", num.FailureCount)
+
+	// The responses are in the order of the tokens. Invalid argument can also be caused by the message itself, so
+	// the tokens are only blamed for it when the same message reached some other device. It is not retried either way,
+	// as the same message would fail again.
+	invalidTokens := []string{}
+	failed := &pushFailedError{}
+	for index, response := range num.Responses {
+		if response.Success || index >= len(message.Tokens) {
+			continue
+		}
+		switch {
+		case messaging.IsUnregistered(response.Error) || (num.SuccessCount > 0 && messaging.IsInvalidArgument(response.Error)):
+			invalidTokens = append(invalidTokens, message.Tokens[index])
+		case messaging.IsInvalidArgument(response.Error):
+		default:
+			failed.Tokens = append(failed.Tokens, message.Tokens[index])
+			failed.Err = response.Error
+		}
+	}
+	// The message has been sent, so failing to delete the tokens does not fail it, and they are deleted on their next
+	// send instead.
+	if len(invalidTokens) > 0 {
+		err = app.daos.DeleteInvalidFCMTokens(invalidTokens)
 		if err != nil {
-			app.logError(err, r)
+			app.logger.Error(err.Error(), "count", len(invalidTokens))
+		} else {
+			app.logInfo("invalid fcm tokens deleted", "count", len(invalidTokens))
 		}
-		app.logInfo("success count: %d, failure count: %d, response: %s", num.SuccessCount, num.FailureCount, num.Responses)
-	}, r)
+	}
+
+	if len(failed.Tokens) > 0 {
+		return failed
+	}
+	return nil
 }
@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"sportgether/internal/tlscert"
 	"syscall"
 	"time"
 )
@@ -20,6 +21,38 @@ func (app *Application) serve() error {
 		WriteTimeout: 10 * time.Second,
 	}
 
+	var certReloader *tlscert.Reloader
+	var redirectServer *http.Server
+	if app.config.tls.enabled() {
+		var err error
+		certReloader, err = tlscert.NewReloader(app.config.tls.CertFile, app.config.tls.KeyFile, app.logger)
+		if err != nil {
+			return err
+		}
+		server.TLSConfig = app.config.tls.newServerConfig(certReloader.GetCertificate)
+
+		if app.config.tls.RedirectPort != 0 {
+			redirectServer = &http.Server{
+				Addr:         fmt.Sprintf(":%d", app.config.tls.RedirectPort),
+				Handler:      http.HandlerFunc(app.redirectToHttps),
+				IdleTimeout:  time.Minute,
+				ReadTimeout:  5 * time.Second,
+				WriteTimeout: 10 * time.Second,
+			}
+		}
+	}
+
+	var metricsServer *http.Server
+	if app.config.metricsPort != 0 {
+		metricsServer = &http.Server{
+			Addr:         fmt.Sprintf(":%d", app.config.metricsPort),
+			Handler:      app.metricsHandler(),
+			IdleTimeout:  time.Minute,
+			ReadTimeout:  5 * time.Second,
+			WriteTimeout: 10 * time.Second,
+		}
+	}
+
 	shutdownError := make(chan error, 1)
 
 	go func() {
@@ -36,6 +69,26 @@ func (app *Application) serve() error {
 		if err != nil {
 			shutdownError <- err
 		}
+		if redirectServer != nil {
+			err = redirectServer.Shutdown(ctx)
+			if err != nil {
+				app.logger.Error(err.Error())
+			}
+		}
+		// Stopped after the API, so that the requests in flight are still scraped.
+		if metricsServer != nil {
+			err = metricsServer.Shutdown(ctx)
+			if err != nil {
+				app.logger.Error(err.Error())
+			}
+		}
+		if certReloader != nil {
+			certReloader.Stop()
+		}
+
+		app.logInfo("stopping scheduled jobs...")
+		app.scheduler.Stop()
+		app.remoteConfig.Stop()
 
 		app.logInfo("completing background task...")
 
@@ -44,12 +97,41 @@ func (app *Application) serve() error {
 	}()
 
 	app.logInfo(fmt.Sprintf("Starting server in env=%s", app.config.env))
-	//certConfig := app.config.getCertConfig()
+
+	app.scheduler.Start()
+	app.remoteConfig.Watch(remoteConfigWatchInterval)
+
+	if redirectServer != nil {
+		go func() {
+			err := redirectServer.ListenAndServe()
+			if !errors.Is(err, http.ErrServerClosed) {
+				app.logger.Error("redirect server stopped", "error", err.Error())
+			}
+		}()
+	}
+
+	if metricsServer != nil {
+		app.logger.Info("Serving metrics", "port", app.config.metricsPort)
+		go func() {
+			err := metricsServer.ListenAndServe()
+			if !errors.Is(err, http.ErrServerClosed) {
+				app.logger.Error("metrics server stopped", "error", err.Error())
+			}
+		}()
+	}
 
 	// Calling Shutdown() on our server will cause ListenAndServe() to immediately
 	// return a http.ErrServerClosed error. So if we see this error, it is actually a
 	// good thing and an indication that the graceful shutdown has started. So we check // specifically for this, only returning the error if it is NOT http.ErrServerClosed.
-	err := server.ListenAndServe()
+	var err error
+	if certReloader != nil {
+		app.logger.Info("Serving HTTPS", "notAfter", certReloader.NotAfter(), "redirectPort", app.config.tls.RedirectPort)
+		certReloader.Watch(app.config.tls.ReloadInterval)
+		// The certificate comes from the TLS config, so that it can be reloaded.
+		err = server.ListenAndServeTLS("", "")
+	} else {
+		err = server.ListenAndServe()
+	}
 	if !errors.Is(err, http.ErrServerClosed) {
 		return err
 	}
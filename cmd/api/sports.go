@@ -0,0 +1,266 @@
+package main
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"sportgether/constants"
+	"sportgether/internal/i18n"
+	"sportgether/internal/models"
+	"sportgether/tools"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+// The sport code is kept in the event type of the events, so it cannot be changed once added.
+var sportCodeRX = regexp.MustCompile(`^[A-Z][A-Z0-9_]*$`)
+
+type sportDetail struct {
+	Index                      int64   `json:"sportIndex"`
+	Sport                      string  `json:"sport"`
+	Name                       string  `json:"name"`
+	ImageUrl                   string  `json:"imageUrl"`
+	IconUrl                    *string `json:"iconUrl"`
+	DefaultMaxParticipantCount int     `json:"defaultMaxParticipantCount"`
+}
+
+// getSportDetails lists the active sports, with the names in the locale of the user.
+func (app *Application) getSportDetails(w http.ResponseWriter, r *http.Request) {
+	user, ok := app.GetUserContext(r)
+	if !ok {
+		app.writeInvalidAuthenticationErrorResponse(w, r)
+		return
+	}
+
+	sports, err := app.daos.GetSports(false)
+	if err != nil {
+		app.logError(err, r)
+		app.writeInternalServerErrorResponse(w, r)
+		return
+	}
+
+	locale := app.userLocale(user.ID, r)
+	details := make([]sportDetail, 0, len(sports))
+	for _, sport := range sports {
+		details = append(details, sportDetail{
+			Index:                      sport.Index,
+			Sport:                      sport.Sport,
+			Name:                       sport.Name(locale),
+			ImageUrl:                   sport.ImageUrl,
+			IconUrl:                    sport.IconUrl,
+			DefaultMaxParticipantCount: sport.DefaultMaxParticipantCount,
+		})
+	}
+
+	// The catalog rarely changes, so the ETag is made from the response, for the clients to keep their cache.
+	content, err := json.Marshal(details)
+	if err != nil {
+		app.logError(err, r)
+		app.writeInternalServerErrorResponse(w, r)
+		return
+	}
+	sum := sha256.Sum256(append(content, locale...))
+	if app.notModified(w, r, fmt.Sprintf(`"%s"`, hex.EncodeToString(sum[:8]))) {
+		return
+	}
+
+	err = app.writeResponse(w, responseData{"sports": details}, http.StatusOK, nil)
+	if err != nil {
+		app.logError(err, r)
+		app.writeInternalServerErrorResponse(w, r)
+		return
+	}
+}
+
+func (app *Application) getAdminSports(w http.ResponseWriter, r *http.Request) {
+	sports, err := app.daos.GetSports(true)
+	if err != nil {
+		app.logError(err, r)
+		app.writeInternalServerErrorResponse(w, r)
+		return
+	}
+
+	err = app.writeResponse(w, responseData{"sports": sports}, http.StatusOK, nil)
+	if err != nil {
+		app.logError(err, r)
+		app.writeInternalServerErrorResponse(w, r)
+	}
+}
+
+func (app *Application) createSport(w http.ResponseWriter, r *http.Request) {
+	input := struct {
+		Sport                      string                 `json:"sport"`
+		Index                      int64                  `json:"sportIndex"`
+		Names                      map[i18n.Locale]string `json:"names"`
+		ImageUrl                   string                 `json:"imageUrl"`
+		IconUrl                    *string                `json:"iconUrl"`
+		DefaultMaxParticipantCount int                    `json:"defaultMaxParticipantCount"`
+	}{}
+	err := app.readRequest(r, &input)
+	if err != nil {
+		app.logError(err, r)
+		app.writeBadRequestResponse(w, r)
+		return
+	}
+
+	sport := &models.Sport{
+		Sport:                      input.Sport,
+		Index:                      input.Index,
+		Names:                      input.Names,
+		ImageUrl:                   input.ImageUrl,
+		IconUrl:                    input.IconUrl,
+		DefaultMaxParticipantCount: input.DefaultMaxParticipantCount,
+		Status:                     models.ActiveSport,
+	}
+
+	validator := tools.NewRequestValidator()
+	validator.Check(sportCodeRX.MatchString(sport.Sport), "sport", "must be upper case letters, digits or _, e.g. TABLE_TENNIS")
+	validateSport(validator, sport)
+	if !validator.Valid() {
+		app.writeError(w, r, http.StatusBadRequest, http.StatusBadRequest, validator.Errors)
+		return
+	}
+
+	err = app.daos.InsertSport(sport)
+	if err != nil {
+		switch {
+		case errors.Is(err, constants.SportExistError):
+			app.writeError(w, r, http.StatusConflict, constants.SportExistError.Code, constants.SportExistError.Error())
+		default:
+			app.logError(err, r)
+			app.writeInternalServerErrorResponse(w, r)
+		}
+		return
+	}
+
+	err = app.writeResponse(w, responseData{"sport": sport}, http.StatusCreated, nil)
+	if err != nil {
+		app.logError(err, r)
+		app.writeInternalServerErrorResponse(w, r)
+	}
+}
+
+// updateSport changes the fields given, where the version must be the one the admin has seen.
+func (app *Application) updateSport(w http.ResponseWriter, r *http.Request) {
+	input := struct {
+		Index                      *int64                 `json:"sportIndex"`
+		Names                      map[i18n.Locale]string `json:"names"`
+		ImageUrl                   *string                `json:"imageUrl"`
+		IconUrl                    *string                `json:"iconUrl"`
+		DefaultMaxParticipantCount *int                   `json:"defaultMaxParticipantCount"`
+		Status                     *models.SportStatus    `json:"status"`
+		Version                    int                    `json:"version"`
+	}{}
+	err := app.readRequest(r, &input)
+	if err != nil {
+		app.logError(err, r)
+		app.writeBadRequestResponse(w, r)
+		return
+	}
+
+	sport, ok := app.readSport(w, r)
+	if !ok {
+		return
+	}
+
+	if input.Index != nil {
+		sport.Index = *input.Index
+	}
+	if input.Names != nil {
+		sport.Names = input.Names
+	}
+	if input.ImageUrl != nil {
+		sport.ImageUrl = *input.ImageUrl
+	}
+	if input.IconUrl != nil {
+		sport.IconUrl = input.IconUrl
+	}
+	if input.DefaultMaxParticipantCount != nil {
+		sport.DefaultMaxParticipantCount = *input.DefaultMaxParticipantCount
+	}
+	if input.Status != nil {
+		sport.Status = *input.Status
+	}
+	sport.Version = input.Version
+
+	validator := tools.NewRequestValidator()
+	validateSport(validator, sport)
+	if !validator.Valid() {
+		app.writeError(w, r, http.StatusBadRequest, http.StatusBadRequest, validator.Errors)
+		return
+	}
+
+	app.saveSport(w, r, sport)
+}
+
+// retireSport keeps the sport for the past events, but it cannot be hosted or searched anymore.
+func (app *Application) retireSport(w http.ResponseWriter, r *http.Request) {
+	sport, ok := app.readSport(w, r)
+	if !ok {
+		return
+	}
+
+	sport.Status = models.RetiredSport
+	app.saveSport(w, r, sport)
+}
+
+// readSport reads the sport of the :sport param, and writes 404 when there is no such sport.
+func (app *Application) readSport(w http.ResponseWriter, r *http.Request) (*models.Sport, bool) {
+	code := httprouter.ParamsFromContext(r.Context()).ByName("sport")
+	sport, err := app.daos.GetSport(code)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			app.writeError(w, r, http.StatusNotFound, constants.SportConfigNotFoundError.Code, constants.SportConfigNotFoundError.Error())
+		default:
+			app.logError(err, r)
+			app.writeInternalServerErrorResponse(w, r)
+		}
+		return nil, false
+	}
+
+	return sport, true
+}
+
+func (app *Application) saveSport(w http.ResponseWriter, r *http.Request, sport *models.Sport) {
+	err := app.daos.UpdateSport(sport)
+	if err != nil {
+		switch {
+		case errors.Is(err, constants.StaleInfoError):
+			app.writeError(w, r, http.StatusConflict, constants.StaleInfoError.Code, "The sport is staled. Please refresh")
+		default:
+			app.logError(err, r)
+			app.writeInternalServerErrorResponse(w, r)
+		}
+		return
+	}
+
+	err = app.writeResponse(w, responseData{"sport": sport}, http.StatusOK, nil)
+	if err != nil {
+		app.logError(err, r)
+		app.writeInternalServerErrorResponse(w, r)
+	}
+}
+
+func validateSport(validator *tools.RequestValidator, sport *models.Sport) {
+	validator.Check(sport.Names[i18n.English] != "", "names", "must have the en name")
+	for locale := range sport.Names {
+		validator.Check(slices.Contains(i18n.SupportedLocales, locale), "names", fmt.Sprintf("must only have the names in %v", i18n.SupportedLocales))
+	}
+	validator.Check(isHttpUrl(sport.ImageUrl), "imageUrl", "must be a http url")
+	validator.Check(sport.IconUrl == nil || isHttpUrl(*sport.IconUrl), "iconUrl", "must be a http url")
+	validator.Check(sport.DefaultMaxParticipantCount >= 2, "defaultMaxParticipantCount", "must be at least 2")
+	validator.Check(sport.Status.IsValid(), "status", "must be ACTIVE or RETIRED")
+}
+
+func isHttpUrl(value string) bool {
+	parsed, err := url.Parse(value)
+	return err == nil && (parsed.Scheme == "http" || parsed.Scheme == "https") && parsed.Host != ""
+}
@@ -0,0 +1,297 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"math/rand"
+	"net/http"
+	"slices"
+	"sportgether/constants"
+	"sportgether/internal/models"
+	"sportgether/internal/teams"
+	"sportgether/tools"
+	"time"
+)
+
+const maxTeamCount = 10
+
+func (app *Application) generateEventTeams(w http.ResponseWriter, r *http.Request) {
+	input := struct {
+		EventId         int64             `json:"eventId"`
+		TeamCount       int               `json:"teamCount"`
+		BalanceGender   bool              `json:"balanceGender"`
+		KeepTogether    [][2]int64        `json:"keepTogether"`
+		ExcludedUserIds []int64           `json:"excludedUserIds"`
+		Ratings         map[int64]float64 `json:"ratings"`
+	}{}
+	err := app.readRequest(r, &input)
+	if err != nil {
+		app.logError(err, r)
+		app.writeBadRequestResponse(w, r)
+		return
+	}
+
+	validator := tools.NewRequestValidator()
+	validator.Check(input.TeamCount >= 2 && input.TeamCount <= maxTeamCount, "teamCount", "must be between 2 and 10")
+	for _, rating := range input.Ratings {
+		validator.Check(rating >= 0, "ratings", "must not be negative")
+	}
+	if !validator.Valid() {
+		app.writeError(w, r, http.StatusBadRequest, http.StatusBadRequest, validator.Errors)
+		return
+	}
+
+	detail, ok := app.readManagedEvent(w, r, input.EventId)
+	if !ok {
+		return
+	}
+
+	draw := &models.EventTeamDraw{
+		EventId:         input.EventId,
+		TeamCount:       input.TeamCount,
+		BalanceGender:   input.BalanceGender,
+		KeepTogether:    input.KeepTogether,
+		ExcludedUserIds: input.ExcludedUserIds,
+	}
+	if draw.KeepTogether == nil {
+		draw.KeepTogether = [][2]int64{}
+	}
+	if draw.ExcludedUserIds == nil {
+		draw.ExcludedUserIds = []int64{}
+	}
+
+	app.drawEventTeams(w, r, detail, draw, input.Ratings)
+}
+
+// reshuffleEventTeams draws the teams again with the options of the latest draw, and the ratings the host gave then.
+func (app *Application) reshuffleEventTeams(w http.ResponseWriter, r *http.Request) {
+	input := struct {
+		EventId int64 `json:"eventId"`
+	}{}
+	err := app.readRequest(r, &input)
+	if err != nil {
+		app.logError(err, r)
+		app.writeBadRequestResponse(w, r)
+		return
+	}
+
+	detail, ok := app.readManagedEvent(w, r, input.EventId)
+	if !ok {
+		return
+	}
+
+	latest, err := app.daos.GetLatestTeamDraw(input.EventId, false)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			app.writeError(w, r, http.StatusUnprocessableEntity, constants.TeamDrawNotFoundError.Code, constants.TeamDrawNotFoundError.Error())
+		default:
+			app.logError(err, r)
+			app.writeInternalServerErrorResponse(w, r)
+		}
+		return
+	}
+
+	ratings := map[int64]float64{}
+	for _, team := range latest.Teams {
+		for _, member := range team.Members {
+			ratings[member.ParticipantId] = member.SkillRating
+		}
+	}
+
+	draw := &models.EventTeamDraw{
+		EventId:         latest.EventId,
+		TeamCount:       latest.TeamCount,
+		BalanceGender:   latest.BalanceGender,
+		KeepTogether:    latest.KeepTogether,
+		ExcludedUserIds: latest.ExcludedUserIds,
+	}
+
+	app.drawEventTeams(w, r, detail, draw, ratings)
+}
+
+func (app *Application) publishEventTeams(w http.ResponseWriter, r *http.Request) {
+	input := struct {
+		EventId int64 `json:"eventId"`
+	}{}
+	err := app.readRequest(r, &input)
+	if err != nil {
+		app.logError(err, r)
+		app.writeBadRequestResponse(w, r)
+		return
+	}
+
+	detail, ok := app.readManagedEvent(w, r, input.EventId)
+	if !ok {
+		return
+	}
+
+	draw, err := app.daos.GetLatestTeamDraw(input.EventId, false)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			app.writeError(w, r, http.StatusUnprocessableEntity, constants.TeamDrawNotFoundError.Code, constants.TeamDrawNotFoundError.Error())
+		default:
+			app.logError(err, r)
+			app.writeInternalServerErrorResponse(w, r)
+		}
+		return
+	}
+
+	err = app.daos.PublishTeamDraw(draw.ID)
+	if err != nil {
+		app.logError(err, r)
+		app.writeInternalServerErrorResponse(w, r)
+		return
+	}
+
+	err = app.broadcastEventTeamsPublishedMessage(r, detail.ID, detail.EventName)
+	if err != nil {
+		app.logError(err, r)
+	}
+
+	now := time.Now()
+	draw.PublishedAt = &now
+	err = app.writeResponse(w, responseData{"teams": draw}, http.StatusOK, nil)
+	if err != nil {
+		app.logError(err, r)
+		app.writeInternalServerErrorResponse(w, r)
+	}
+}
+
+// getEventTeams shows the latest teams to the host and co-hosts, while the participants only see the published ones.
+func (app *Application) getEventTeams(w http.ResponseWriter, r *http.Request) {
+	eventId, err := app.readParam("eventId", r)
+	if err != nil {
+		app.logError(err, r)
+		app.writeBadRequestResponse(w, r)
+		return
+	}
+
+	user, ok := app.GetUserContext(r)
+	if !ok {
+		app.writeInvalidAuthenticationErrorResponse(w, r)
+		return
+	}
+
+	detail, err := app.daos.GetEventById(*eventId, user.ID)
+	if err != nil {
+		app.logError(err, r)
+		app.writeBadRequestResponse(w, r)
+		return
+	}
+
+	if !detail.CanManage() && !detail.IsJoined {
+		app.writeError(w, r, http.StatusForbidden, constants.NotEventParticipantError.Code, constants.NotEventParticipantError.Error())
+		return
+	}
+
+	draw, err := app.daos.GetLatestTeamDraw(*eventId, !detail.CanManage())
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			app.writeError(w, r, http.StatusNotFound, constants.TeamDrawNotFoundError.Code, constants.TeamDrawNotFoundError.Error())
+		default:
+			app.logError(err, r)
+			app.writeInternalServerErrorResponse(w, r)
+		}
+		return
+	}
+
+	err = app.writeResponse(w, responseData{"teams": draw}, http.StatusOK, nil)
+	if err != nil {
+		app.logError(err, r)
+		app.writeInternalServerErrorResponse(w, r)
+	}
+}
+
+// drawEventTeams generates the teams from the current participants and stores them as the latest draw. The ratings
+// override the skill ratings of the players, e.g. when the host knows better.
+func (app *Application) drawEventTeams(w http.ResponseWriter, r *http.Request, detail *models.EventDetail, draw *models.EventTeamDraw, ratings map[int64]float64) {
+	user, ok := app.GetUserContext(r)
+	if !ok {
+		app.writeInvalidAuthenticationErrorResponse(w, r)
+		return
+	}
+
+	participants, err := app.daos.GetEventPlayers(detail.ID, detail.EventType)
+	if err != nil {
+		app.logError(err, r)
+		app.writeInternalServerErrorResponse(w, r)
+		return
+	}
+
+	players := make([]teams.Player, 0, len(participants))
+	for _, player := range participants {
+		if slices.Contains(draw.ExcludedUserIds, player.UserId) {
+			continue
+		}
+		if rating, ok := ratings[player.UserId]; ok {
+			player.Rating = rating
+		}
+		players = append(players, player)
+	}
+
+	options := teams.Options{
+		TeamCount:     draw.TeamCount,
+		BalanceGender: draw.BalanceGender,
+		KeepTogether:  draw.KeepTogether,
+	}
+	random := rand.New(rand.NewSource(time.Now().UnixNano()))
+	generated, err := teams.Generate(players, options, random)
+	if err != nil {
+		app.writeError(w, r, http.StatusUnprocessableEntity, constants.InvalidTeamOptionError.Code, err.Error())
+		return
+	}
+
+	draw.CreatedBy = user.ID
+	err = app.daos.WithTransaction(func(tx *sql.Tx) error {
+		return app.daos.InsertTeamDraw(draw, generated, tx)
+	})
+	if err != nil {
+		app.logError(err, r)
+		app.writeInternalServerErrorResponse(w, r)
+		return
+	}
+
+	result, err := app.daos.GetLatestTeamDraw(detail.ID, false)
+	if err != nil {
+		app.logError(err, r)
+		app.writeInternalServerErrorResponse(w, r)
+		return
+	}
+
+	err = app.writeResponse(w, responseData{"teams": result}, http.StatusCreated, nil)
+	if err != nil {
+		app.logError(err, r)
+		app.writeInternalServerErrorResponse(w, r)
+	}
+}
+
+// readManagedEvent reads the event which the user hosts or co-hosts, and writes the error response otherwise.
+func (app *Application) readManagedEvent(w http.ResponseWriter, r *http.Request, eventId int64) (*models.EventDetail, bool) {
+	user, ok := app.GetUserContext(r)
+	if !ok {
+		app.writeInvalidAuthenticationErrorResponse(w, r)
+		return nil, false
+	}
+
+	detail, err := app.daos.GetEventById(eventId, user.ID)
+	if err != nil {
+		app.logError(err, r)
+		app.writeBadRequestResponse(w, r)
+		return nil, false
+	}
+
+	if !detail.CanManage() {
+		app.writeError(w, r, http.StatusForbidden, constants.NotEventHostError.Code, constants.NotEventHostError.Error())
+		return nil, false
+	}
+
+	if detail.IsCancelled() {
+		app.writeError(w, r, http.StatusUnprocessableEntity, constants.EventNotEditableError.Code, constants.EventNotEditableError.Error())
+		return nil, false
+	}
+
+	return detail, true
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"sportgether/tools"
+	"strconv"
+	"time"
+)
+
+var tlsVersions = map[string]uint16{
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// HTTP/2 requires one of the cipher suites for TLS 1.2.
+var http2CipherSuites = []uint16{
+	tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+}
+
+// tlsConfig serves HTTPS when the certificate is given. Otherwise HTTP is served, e.g. behind a proxy terminating TLS.
+type tlsConfig struct {
+	CertFile   string
+	KeyFile    string
+	MinVersion string
+	// Ciphers are the names of the cipher suites, e.g. TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256, see tls.CipherSuites.
+	Ciphers        []string
+	RedirectPort   int
+	ReloadInterval time.Duration
+}
+
+func (c tlsConfig) enabled() bool {
+	return c.CertFile != "" || c.KeyFile != ""
+}
+
+func (c tlsConfig) validate(validator *tools.RequestValidator, port int) {
+	validator.Check((c.CertFile == "") == (c.KeyFile == ""), "tls.key-file", "must be given along with tls.cert-file")
+	_, ok := tlsVersions[c.MinVersion]
+	validator.Check(ok, "tls.min-version", "must be 1.2 or 1.3")
+
+	suites, err := c.cipherSuites()
+	if err != nil {
+		validator.AppendError("tls.ciphers", err.Error())
+	}
+	validator.Check(len(suites) == 0 || c.MinVersion != "1.3", "tls.ciphers", "must not be given for TLS 1.3, whose cipher suites are not configurable")
+	validator.Check(len(suites) == 0 || slices.ContainsFunc(suites, func(id uint16) bool {
+		return slices.Contains(http2CipherSuites, id)
+	}), "tls.ciphers", "must have TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256 or TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256 for HTTP/2")
+
+	validator.Check(c.RedirectPort == 0 || c.enabled(), "tls.redirect-port", "must only be given along with tls.cert-file")
+	validator.Check(c.RedirectPort >= 0 && c.RedirectPort <= 65535 && c.RedirectPort != port, "tls.redirect-port", "must be between 0 and 65535, other than port")
+	validator.Check(c.ReloadInterval > 0, "tls.reload-interval", "must be positive")
+}
+
+// cipherSuites only accepts the secure cipher suites, i.e. the ones of tls.CipherSuites.
+func (c tlsConfig) cipherSuites() ([]uint16, error) {
+	suites := make([]uint16, 0, len(c.Ciphers))
+	for _, name := range c.Ciphers {
+		index := slices.IndexFunc(tls.CipherSuites(), func(suite *tls.CipherSuite) bool {
+			return suite.Name == name
+		})
+		if index < 0 {
+			return nil, fmt.Errorf("must be secure cipher suites, not %s", name)
+		}
+		suites = append(suites, tls.CipherSuites()[index].ID)
+	}
+	return suites, nil
+}
+
+// newServerConfig serves HTTP/2 along with HTTP/1.1, with the certificate from getCertificate on every handshake, so
+// that the certificate can be reloaded without restart.
+func (c tlsConfig) newServerConfig(getCertificate func(*tls.ClientHelloInfo) (*tls.Certificate, error)) *tls.Config {
+	config := &tls.Config{
+		MinVersion:     tlsVersions[c.MinVersion],
+		GetCertificate: getCertificate,
+		NextProtos:     []string{"h2", "http/1.1"},
+	}
+
+	suites, _ := c.cipherSuites()
+	if len(suites) > 0 {
+		config.CipherSuites = suites
+	}
+
+	return config
+}
+
+// redirectToHttps redirects to the same url on the HTTPS port, with 308 so that the method and the body are kept.
+func (app *Application) redirectToHttps(w http.ResponseWriter, r *http.Request) {
+	host, _, err := net.SplitHostPort(r.Host)
+	if err != nil {
+		host = r.Host
+	}
+	if app.config.port != 443 {
+		host = net.JoinHostPort(host, strconv.Itoa(app.config.port))
+	}
+
+	target := url.URL{Scheme: "https", Host: host, Path: r.URL.Path, RawQuery: r.URL.RawQuery}
+	http.Redirect(w, r, target.String(), http.StatusPermanentRedirect)
+}
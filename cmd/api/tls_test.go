@@ -0,0 +1,37 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRedirectToHttps(t *testing.T) {
+	tests := []struct {
+		name   string
+		port   int
+		target string
+		want   string
+	}{
+		{name: "default port", port: 443, target: "http://sportgether.app:8080/v1/event/1?x=1", want: "https://sportgether.app/v1/event/1?x=1"},
+		{name: "other port", port: 8443, target: "http://sportgether.app/v1/event/create", want: "https://sportgether.app:8443/v1/event/create"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			app := &Application{}
+			app.config.port = test.port
+
+			recorder := httptest.NewRecorder()
+			app.redirectToHttps(recorder, httptest.NewRequest(http.MethodPost, test.target, nil))
+
+			// 308 keeps the method and the body, unlike 301.
+			if recorder.Code != http.StatusPermanentRedirect {
+				t.Errorf("got status %d, want %d", recorder.Code, http.StatusPermanentRedirect)
+			}
+			if location := recorder.Header().Get("Location"); location != test.want {
+				t.Errorf("got location %s, want %s", location, test.want)
+			}
+		})
+	}
+}
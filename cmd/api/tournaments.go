@@ -0,0 +1,633 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"sportgether/constants"
+	"sportgether/internal/models"
+	"sportgether/internal/tournament"
+	"sportgether/tools"
+	"strings"
+)
+
+func (app *Application) createTournament(w http.ResponseWriter, r *http.Request) {
+	input := struct {
+		TournamentName   string            `json:"tournamentName"`
+		EventType        string            `json:"eventType"`
+		Format           tournament.Format `json:"format"`
+		RegistrationType string            `json:"registrationType"`
+		TeamSize         int               `json:"teamSize"`
+		MaxEntryCount    int               `json:"maxEntryCount"`
+		Description      *string           `json:"description"`
+	}{}
+	err := app.readRequest(r, &input)
+	if err != nil {
+		app.logError(err, r)
+		app.writeBadRequestResponse(w, r)
+		return
+	}
+
+	input.TournamentName = strings.TrimSpace(input.TournamentName)
+	if input.RegistrationType == models.TournamentIndividualRegistration {
+		input.TeamSize = 1
+	}
+
+	validator := tools.NewRequestValidator()
+	validator.Check(input.TournamentName != "", "tournamentName", "must be provided")
+	validator.Check(input.EventType != "", "eventType", "must be provided")
+	validator.Check(input.Format.IsValid(), "format", "must be SINGLE_ELIMINATION or ROUND_ROBIN")
+	validator.Check(input.RegistrationType == models.TournamentIndividualRegistration || input.RegistrationType == models.TournamentTeamRegistration, "registrationType", "must be INDIVIDUAL or TEAM")
+	validator.Check(input.TeamSize >= 1 && input.TeamSize <= 20, "teamSize", "must be between 1 and 20")
+	validator.Check(input.MaxEntryCount >= 2 && input.MaxEntryCount <= 64, "maxEntryCount", "must be between 2 and 64")
+	if !validator.Valid() {
+		app.writeError(w, r, http.StatusBadRequest, http.StatusBadRequest, validator.Errors)
+		return
+	}
+
+	user, ok := app.GetUserContext(r)
+	if !ok {
+		app.writeInvalidAuthenticationErrorResponse(w, r)
+		return
+	}
+
+	t := &models.Tournament{
+		HostId:           user.ID,
+		TournamentName:   input.TournamentName,
+		EventType:        input.EventType,
+		Format:           input.Format,
+		RegistrationType: input.RegistrationType,
+		TeamSize:         input.TeamSize,
+		MaxEntryCount:    input.MaxEntryCount,
+		Description:      input.Description,
+	}
+	err = app.daos.CreateTournament(t)
+	if err != nil {
+		app.logError(err, r)
+		app.writeInternalServerErrorResponse(w, r)
+		return
+	}
+
+	err = app.writeResponse(w, responseData{"tournament": t}, http.StatusCreated, nil)
+	if err != nil {
+		app.logError(err, r)
+		app.writeInternalServerErrorResponse(w, r)
+	}
+}
+
+func (app *Application) getTournament(w http.ResponseWriter, r *http.Request) {
+	t, ok := app.readTournament(w, r)
+	if !ok {
+		return
+	}
+
+	entries, err := app.daos.GetTournamentEntries(t.ID)
+	if err != nil {
+		app.logError(err, r)
+		app.writeInternalServerErrorResponse(w, r)
+		return
+	}
+
+	matches, err := app.daos.GetTournamentMatches(t.ID, nil)
+	if err != nil {
+		app.logError(err, r)
+		app.writeInternalServerErrorResponse(w, r)
+		return
+	}
+
+	err = app.writeResponse(w, responseData{"tournament": t, "entries": entries, "matches": matches}, http.StatusOK, nil)
+	if err != nil {
+		app.logError(err, r)
+		app.writeInternalServerErrorResponse(w, r)
+	}
+}
+
+func (app *Application) getTournamentStandings(w http.ResponseWriter, r *http.Request) {
+	t, ok := app.readTournament(w, r)
+	if !ok {
+		return
+	}
+
+	entries, err := app.daos.GetTournamentEntries(t.ID)
+	if err != nil {
+		app.logError(err, r)
+		app.writeInternalServerErrorResponse(w, r)
+		return
+	}
+
+	matches, err := app.daos.GetTournamentMatches(t.ID, nil)
+	if err != nil {
+		app.logError(err, r)
+		app.writeInternalServerErrorResponse(w, r)
+		return
+	}
+
+	entryIds := make([]int64, 0, len(entries))
+	for _, entry := range entries {
+		entryIds = append(entryIds, entry.ID)
+	}
+
+	results := []tournament.Result{}
+	for _, match := range matches {
+		if match.Status != models.MatchCompleted || match.HomeEntryId == nil || match.AwayEntryId == nil {
+			continue
+		}
+		results = append(results, tournament.Result{
+			Round:     match.Round,
+			Home:      *match.HomeEntryId,
+			Away:      *match.AwayEntryId,
+			HomeScore: *match.HomeScore,
+			AwayScore: *match.AwayScore,
+			Winner:    match.WinnerEntryId,
+		})
+	}
+
+	err = app.writeResponse(w, responseData{"standings": tournament.Standings(t.Format, entryIds, results)}, http.StatusOK, nil)
+	if err != nil {
+		app.logError(err, r)
+		app.writeInternalServerErrorResponse(w, r)
+	}
+}
+
+// registerTournament registers the user, or the team led by the user, as an entry of the tournament. The members are
+// limited to the users in a club with the captain or who have joined an event with the captain.
+func (app *Application) registerTournament(w http.ResponseWriter, r *http.Request) {
+	input := struct {
+		TournamentId int64   `json:"tournamentId"`
+		EntryName    string  `json:"entryName"`
+		MemberIds    []int64 `json:"memberIds"`
+	}{}
+	err := app.readRequest(r, &input)
+	if err != nil {
+		app.logError(err, r)
+		app.writeBadRequestResponse(w, r)
+		return
+	}
+
+	user, ok := app.GetUserContext(r)
+	if !ok {
+		app.writeInvalidAuthenticationErrorResponse(w, r)
+		return
+	}
+
+	// The captain is always in the team.
+	if !slices.Contains(input.MemberIds, user.ID) {
+		input.MemberIds = append(input.MemberIds, user.ID)
+	}
+	input.EntryName = strings.TrimSpace(input.EntryName)
+	if input.EntryName == "" {
+		input.EntryName = user.UserName
+	}
+
+	t, err := app.daos.GetTournament(input.TournamentId, nil)
+	if err != nil {
+		app.writeTournamentError(w, r, err)
+		return
+	}
+
+	uniqueMemberIds := map[int64]bool{}
+	for _, memberId := range input.MemberIds {
+		uniqueMemberIds[memberId] = true
+	}
+	validator := tools.NewRequestValidator()
+	validator.Check(len(input.MemberIds) == t.TeamSize, "memberIds", fmt.Sprintf("must have %d members including the captain", t.TeamSize))
+	validator.Check(len(uniqueMemberIds) == len(input.MemberIds), "memberIds", "must not have duplicated members")
+	if !validator.Valid() {
+		app.writeError(w, r, http.StatusBadRequest, http.StatusBadRequest, validator.Errors)
+		return
+	}
+
+	// The captain can only enlist the users they know, so that no one is put in a team of a stranger.
+	connected, err := app.daos.GetCaptainConnectedUserIds(user.ID, input.MemberIds)
+	if err != nil {
+		app.logError(err, r)
+		app.writeInternalServerErrorResponse(w, r)
+		return
+	}
+	for _, memberId := range input.MemberIds {
+		if memberId != user.ID && !connected[memberId] {
+			validator.AppendError("memberIds", "must be in a club with the captain, or have joined an event with the captain")
+			break
+		}
+	}
+	if !validator.Valid() {
+		app.writeError(w, r, http.StatusBadRequest, http.StatusBadRequest, validator.Errors)
+		return
+	}
+
+	entry := &models.TournamentEntry{
+		EntryName: input.EntryName,
+		CaptainId: user.ID,
+	}
+	err = app.daos.WithTransaction(func(tx *sql.Tx) error {
+		// Read again with the lock, as the tournament might have started since.
+		t, err := app.daos.GetTournament(input.TournamentId, tx)
+		if err != nil {
+			return err
+		}
+
+		return app.daos.RegisterTournamentEntry(t, entry, input.MemberIds, tx)
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, constants.TournamentFullError), errors.Is(err, constants.TournamentRegisteredError):
+			var errorCode constants.ErrorCode
+			errors.As(err, &errorCode)
+			app.writeError(w, r, http.StatusConflict, errorCode.Code, errorCode.Error())
+		default:
+			app.writeTournamentError(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeResponse(w, responseData{"entry": entry}, http.StatusCreated, nil)
+	if err != nil {
+		app.logError(err, r)
+		app.writeInternalServerErrorResponse(w, r)
+	}
+}
+
+func (app *Application) withdrawTournament(w http.ResponseWriter, r *http.Request) {
+	tournamentId, err := app.readParam("tournamentId", r)
+	if err != nil {
+		app.logError(err, r)
+		app.writeBadRequestResponse(w, r)
+		return
+	}
+
+	user, ok := app.GetUserContext(r)
+	if !ok {
+		app.writeInvalidAuthenticationErrorResponse(w, r)
+		return
+	}
+
+	err = app.daos.WithdrawTournamentEntry(*tournamentId, user.ID)
+	if err != nil {
+		app.writeTournamentError(w, r, err)
+	}
+}
+
+// startTournament closes the registration and generates the bracket, seeded by the registration order.
+func (app *Application) startTournament(w http.ResponseWriter, r *http.Request) {
+	input := struct {
+		TournamentId int64 `json:"tournamentId"`
+	}{}
+	err := app.readRequest(r, &input)
+	if err != nil {
+		app.logError(err, r)
+		app.writeBadRequestResponse(w, r)
+		return
+	}
+
+	user, ok := app.GetUserContext(r)
+	if !ok {
+		app.writeInvalidAuthenticationErrorResponse(w, r)
+		return
+	}
+
+	err = app.daos.WithTransaction(func(tx *sql.Tx) error {
+		t, err := app.daos.GetTournament(input.TournamentId, tx)
+		if err != nil {
+			return err
+		}
+		if t.HostId != user.ID {
+			return constants.NotTournamentHostError
+		}
+
+		entries, err := app.daos.GetTournamentEntries(t.ID)
+		if err != nil {
+			return err
+		}
+		entryIds := make([]int64, 0, len(entries))
+		for _, entry := range entries {
+			entryIds = append(entryIds, entry.ID)
+		}
+
+		matches, err := tournament.Generate(t.Format, entryIds)
+		if err != nil {
+			return err
+		}
+
+		return app.daos.StartTournament(t.ID, matches, tx)
+	})
+	if err != nil {
+		app.writeTournamentError(w, r, err)
+		return
+	}
+
+	err = app.broadcastTournamentStartedMessage(r, input.TournamentId)
+	if err != nil {
+		app.logError(err, r)
+	}
+}
+
+func (app *Application) cancelTournament(w http.ResponseWriter, r *http.Request) {
+	t, ok := app.readTournament(w, r)
+	if !ok {
+		return
+	}
+
+	user, _ := app.GetUserContext(r)
+	if t.HostId != user.ID {
+		app.writeError(w, r, http.StatusForbidden, constants.NotTournamentHostError.Code, constants.NotTournamentHostError.Error())
+		return
+	}
+
+	err := app.daos.CancelTournament(t.ID)
+	if err != nil {
+		app.writeTournamentError(w, r, err)
+	}
+}
+
+// scheduleTournamentMatch creates the event of the match, with the players of both entries joined already, so that
+// they get reminded like any other event. No one else can join the event, see CanJoinMatchEvent.
+func (app *Application) scheduleTournamentMatch(w http.ResponseWriter, r *http.Request) {
+	input := struct {
+		TournamentId int64          `json:"tournamentId"`
+		MatchId      int64          `json:"matchId"`
+		StartTime    string         `json:"startTime"`
+		EndTime      string         `json:"endTime"`
+		Destination  string         `json:"destination"`
+		LongLat      models.GeoType `json:"longLat"`
+	}{}
+	err := app.readRequest(r, &input)
+	if err != nil {
+		app.logError(err, r)
+		app.writeBadRequestResponse(w, r)
+		return
+	}
+
+	user, ok := app.GetUserContext(r)
+	if !ok {
+		app.writeInvalidAuthenticationErrorResponse(w, r)
+		return
+	}
+
+	entries, err := app.daos.GetTournamentEntries(input.TournamentId)
+	if err != nil {
+		app.logError(err, r)
+		app.writeInternalServerErrorResponse(w, r)
+		return
+	}
+	entryNames := map[int64]string{}
+	for _, entry := range entries {
+		entryNames[entry.ID] = entry.EntryName
+	}
+
+	var match *models.TournamentMatch
+	event := &models.Event{}
+	err = app.daos.WithTransaction(func(tx *sql.Tx) error {
+		t, err := app.daos.GetTournament(input.TournamentId, tx)
+		if err != nil {
+			return err
+		}
+		if t.HostId != user.ID {
+			return constants.NotTournamentHostError
+		}
+		if t.Status != models.TournamentOngoing {
+			return constants.TournamentNotEditableError
+		}
+
+		match, err = app.findTournamentMatch(t.ID, input.MatchId, tx)
+		if err != nil {
+			return err
+		}
+		if match.Status != models.MatchReady {
+			return constants.MatchNotReadyError
+		}
+		if match.EventId != nil {
+			return constants.EventNotEditableError
+		}
+
+		*event = models.Event{
+			EventName:   fmt.Sprintf("%s: %s vs %s", t.TournamentName, entryNames[*match.HomeEntryId], entryNames[*match.AwayEntryId]),
+			HostId:      user.ID,
+			StartTime:   input.StartTime,
+			EndTime:     input.EndTime,
+			Destination: input.Destination,
+			LongLat:     input.LongLat,
+			EventType:   t.EventType,
+			// Both teams, and the host as the referee.
+			MaxParticipantCount: 2*t.TeamSize + 1,
+			Description:         fmt.Sprintf("Round %d match of %s", match.Round, t.TournamentName),
+			Visibility:          models.PublicEvent,
+		}
+		// The match events do not take up the hosting quota of the tournament host.
+		err = app.daos.CreateEvent(event, tx)
+		if err != nil {
+			return err
+		}
+
+		err = app.daos.JoinEventByOwner(event.ID, user.ID, tx)
+		if err != nil {
+			return err
+		}
+
+		err = app.daos.JoinMatchEventByEntries(event.ID, []int64{*match.HomeEntryId, *match.AwayEntryId}, tx)
+		if err != nil {
+			return err
+		}
+
+		return app.daos.LinkMatchEvent(match.ID, event.ID, tx)
+	})
+	if err != nil {
+		app.writeTournamentError(w, r, err)
+		return
+	}
+
+	err = app.sendTournamentMatchScheduledMessage(r, event.ID, event.EventName, []int64{*match.HomeEntryId, *match.AwayEntryId})
+	if err != nil {
+		app.logError(err, r)
+	}
+
+	err = app.writeResponse(w, responseData{"eventId": event.ID}, http.StatusCreated, nil)
+	if err != nil {
+		app.logError(err, r)
+		app.writeInternalServerErrorResponse(w, r)
+	}
+}
+
+// reportTournamentMatchScore saves the score reported by the host, and advances the winner to the next round in
+// single elimination. The score can be corrected as long as the next round match has not been played.
+func (app *Application) reportTournamentMatchScore(w http.ResponseWriter, r *http.Request) {
+	input := struct {
+		TournamentId int64 `json:"tournamentId"`
+		MatchId      int64 `json:"matchId"`
+		HomeScore    int   `json:"homeScore"`
+		AwayScore    int   `json:"awayScore"`
+	}{}
+	err := app.readRequest(r, &input)
+	if err != nil {
+		app.logError(err, r)
+		app.writeBadRequestResponse(w, r)
+		return
+	}
+
+	validator := tools.NewRequestValidator()
+	validator.Check(input.HomeScore >= 0, "homeScore", "must not be negative")
+	validator.Check(input.AwayScore >= 0, "awayScore", "must not be negative")
+	if !validator.Valid() {
+		app.writeError(w, r, http.StatusBadRequest, http.StatusBadRequest, validator.Errors)
+		return
+	}
+
+	user, ok := app.GetUserContext(r)
+	if !ok {
+		app.writeInvalidAuthenticationErrorResponse(w, r)
+		return
+	}
+
+	var completed bool
+	err = app.daos.WithTransaction(func(tx *sql.Tx) error {
+		t, err := app.daos.GetTournament(input.TournamentId, tx)
+		if err != nil {
+			return err
+		}
+		if t.HostId != user.ID {
+			return constants.NotTournamentHostError
+		}
+		if t.Status != models.TournamentOngoing {
+			return constants.TournamentNotEditableError
+		}
+
+		matches, err := app.daos.GetTournamentMatches(t.ID, tx)
+		if err != nil {
+			return err
+		}
+
+		index := slices.IndexFunc(matches, func(match *models.TournamentMatch) bool { return match.ID == input.MatchId })
+		if index < 0 {
+			return sql.ErrNoRows
+		}
+		match := matches[index]
+		if match.Status == models.MatchBye || match.HomeEntryId == nil || match.AwayEntryId == nil {
+			return constants.MatchNotReadyError
+		}
+
+		var winner *int64
+		switch {
+		case input.HomeScore > input.AwayScore:
+			winner = match.HomeEntryId
+		case input.AwayScore > input.HomeScore:
+			winner = match.AwayEntryId
+		case t.Format == tournament.SingleElimination:
+			return constants.MatchDrawError
+		}
+
+		if t.Format == tournament.SingleElimination {
+			entryCount := 0
+			for _, match := range matches {
+				if match.Round == 1 {
+					entryCount += 2
+				}
+			}
+			nextRound, nextIndex, home, ok := tournament.NextMatch(match.Round, match.MatchIndex, tournament.EliminationRoundCount(entryCount))
+			if ok {
+				nextMatchIndex := slices.IndexFunc(matches, func(match *models.TournamentMatch) bool {
+					return match.Round == nextRound && match.MatchIndex == nextIndex
+				})
+				if nextMatchIndex < 0 {
+					return errors.New("next round match is not found")
+				}
+				next := matches[nextMatchIndex]
+				if next.Status == models.MatchCompleted {
+					return constants.MatchAdvancedError
+				}
+
+				if home {
+					next.HomeEntryId = winner
+				} else {
+					next.AwayEntryId = winner
+				}
+				err = app.daos.UpdateMatchEntries(next, tx)
+				if err != nil {
+					return err
+				}
+			}
+		}
+
+		match.HomeScore = &input.HomeScore
+		match.AwayScore = &input.AwayScore
+		match.WinnerEntryId = winner
+		err = app.daos.UpdateMatchResult(match, user.ID, tx)
+		if err != nil {
+			return err
+		}
+
+		completed = !slices.ContainsFunc(matches, func(match *models.TournamentMatch) bool {
+			return match.Status != models.MatchCompleted && match.Status != models.MatchBye
+		})
+		if completed {
+			return app.daos.CompleteTournament(t.ID, tx)
+		}
+
+		return nil
+	})
+	if err != nil {
+		app.writeTournamentError(w, r, err)
+		return
+	}
+
+	err = app.writeResponse(w, responseData{"tournamentCompleted": completed}, http.StatusOK, nil)
+	if err != nil {
+		app.logError(err, r)
+		app.writeInternalServerErrorResponse(w, r)
+	}
+}
+
+func (app *Application) findTournamentMatch(tournamentId int64, matchId int64, tx *sql.Tx) (*models.TournamentMatch, error) {
+	matches, err := app.daos.GetTournamentMatches(tournamentId, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	index := slices.IndexFunc(matches, func(match *models.TournamentMatch) bool { return match.ID == matchId })
+	if index < 0 {
+		return nil, sql.ErrNoRows
+	}
+
+	return matches[index], nil
+}
+
+// readTournament looks up the tournament from the url params. The error response is written when it is not ok.
+func (app *Application) readTournament(w http.ResponseWriter, r *http.Request) (*models.Tournament, bool) {
+	tournamentId, err := app.readParam("tournamentId", r)
+	if err != nil {
+		app.logError(err, r)
+		app.writeBadRequestResponse(w, r)
+		return nil, false
+	}
+
+	t, err := app.daos.GetTournament(*tournamentId, nil)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			app.notFound(w, r)
+		default:
+			app.logError(err, r)
+			app.writeInternalServerErrorResponse(w, r)
+		}
+		return nil, false
+	}
+
+	return t, true
+}
+
+func (app *Application) writeTournamentError(w http.ResponseWriter, r *http.Request, err error) {
+	var errorCode constants.ErrorCode
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		app.notFound(w, r)
+	case errors.Is(err, tournament.NotEnoughEntriesError):
+		app.writeError(w, r, http.StatusUnprocessableEntity, http.StatusUnprocessableEntity, err.Error())
+	case errors.Is(err, constants.NotTournamentHostError):
+		app.writeError(w, r, http.StatusForbidden, constants.NotTournamentHostError.Code, constants.NotTournamentHostError.Error())
+	case errors.As(err, &errorCode):
+		app.writeError(w, r, http.StatusUnprocessableEntity, errorCode.Code, errorCode.Error())
+	default:
+		app.logError(err, r)
+		app.writeInternalServerErrorResponse(w, r)
+	}
+}
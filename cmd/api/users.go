@@ -66,6 +66,8 @@ func (app *Application) registerUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	app.initUserLocale(user.ID, r)
+
 	if !user.ActivatedUser() {
 		err = app.sendActivationRequest(&user, w, r)
 		if err != nil {
@@ -126,6 +128,8 @@ func (app *Application) loginUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	app.initUserLocale(user.ID, r)
+
 	// Check if user is activated, if not, send back client requesting activation
 	if !user.ActivatedUser() {
 		err = app.sendActivationRequest(user, w, r)
@@ -151,6 +155,37 @@ func (app *Application) loginUser(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// logoutUser unregisters the push token of the device, so that the device stops receiving the pushes of the user.
+// The jwt is dropped by the client.
+func (app *Application) logoutUser(w http.ResponseWriter, r *http.Request) {
+	input := struct {
+		FcmToken *string `json:"fcmToken"`
+	}{}
+
+	err := app.readRequest(r, &input)
+	if err != nil {
+		app.logError(err, r)
+		app.writeBadRequestResponse(w, r)
+		return
+	}
+
+	user, ok := app.GetUserContext(r)
+	if !ok {
+		app.writeInvalidAuthenticationErrorResponse(w, r)
+		return
+	}
+
+	if input.FcmToken == nil || *input.FcmToken == "" {
+		return
+	}
+
+	err = app.daos.DeleteFCMToken(user.ID, *input.FcmToken)
+	if err != nil {
+		app.logError(err, r)
+		app.writeInternalServerErrorResponse(w, r)
+	}
+}
+
 func (app *Application) sendActivationRequest(user *models.User, w http.ResponseWriter, r *http.Request) error {
 	token, err := app.daos.TokenDao.New(user.ID, 3*time.Minute, models.AccountActivationScope)
 	fmt.Printf("activate code: %s, hash: %s", token.PlainText, token.Hash)
@@ -158,17 +193,15 @@ func (app *Application) sendActivationRequest(user *models.User, w http.Response
 		return err
 	}
 
-	app.background(func() {
-		data := map[string]any{
-			"userId":         user.UserName,
-			"activationCode": token.PlainText,
-		}
-		err = app.mailer.Send(user.Email, "welcome_user.tmpl", data)
-		if err != nil {
-			// Just log error. We don't want to return error to client
-			app.logError(err, r)
-		}
-	}, r)
+	data := map[string]any{
+		"userId":         user.UserName,
+		"activationCode": token.PlainText,
+	}
+	err = app.sendEmail(r, user.Email, app.userLocale(user.ID, r), "welcome_user.tmpl", data)
+	if err != nil {
+		// Just log error. We don't want to return error to client
+		app.logError(err, r)
+	}
 
 	// Send back unauthorised error so that client can trigger activation code sending
 	err = app.writeUserActivationRequiredResponse(w, r)
@@ -207,17 +240,15 @@ func (app *Application) deregisterUserRequest(w http.ResponseWriter, r *http.Req
 		return
 	}
 
-	app.background(func() {
-		data := map[string]any{
-			"userId":           user.UserName,
-			"deactivationCode": token.PlainText,
-		}
-		err = app.mailer.Send(user.Email, "deactivate_user.tmpl", data)
-		if err != nil {
-			// Just log error. We don't want to return error to client
-			app.logError(err, r)
-		}
-	}, r)
+	data := map[string]any{
+		"userId":           user.UserName,
+		"deactivationCode": token.PlainText,
+	}
+	err = app.sendEmail(r, user.Email, app.userLocale(user.ID, r), "deactivate_user.tmpl", data)
+	if err != nil {
+		// Just log error. We don't want to return error to client
+		app.logError(err, r)
+	}
 }
 
 func (app *Application) activateUser(w http.ResponseWriter, r *http.Request) {
@@ -4,19 +4,17 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"io"
 	"net/http"
 	"net/url"
-	"os"
+	remoteConfig "sportgether/remote_config"
 	"strconv"
-	"strings"
 
 	"github.com/julienschmidt/httprouter"
 )
 
 // Logging
 func (app *Application) logInfo(message string, args ...any) {
-	app.logger.Info(message, args)
+	app.logger.Info(message, args...)
 }
 
 func (app *Application) logError(error error, r *http.Request) {
@@ -24,7 +22,7 @@ func (app *Application) logError(error error, r *http.Request) {
 }
 
 func (app *Application) logWarning(message string, args ...any) {
-	app.logger.Error(message, args)
+	app.logger.Error(message, args...)
 }
 
 type responseData map[string]any
@@ -109,27 +107,6 @@ func (app *Application) readInt(args url.Values, key string, defaultValue int64)
 	return valInInt, nil
 }
 
-func readJsonFromFile(filePath string, item interface{}) error {
-	file, err := os.Open(filePath)
-	if err != nil {
-		return err
-	}
-	defer file.Close()
-
-	// Read the file content
-	content, err := io.ReadAll(file)
-	if err != nil {
-		return err
-	}
-
-	err = json.Unmarshal(content, &item)
-	if err != nil {
-		return err
-	}
-
-	return nil
-}
-
 func (app *Application) background(fn func(), r *http.Request) {
 	// increment before start
 	app.wg.Add(1)
@@ -148,29 +125,12 @@ func (app *Application) background(fn func(), r *http.Request) {
 	}()
 }
 
+// isValidVersion tells whether the current version is the target version or newer, by the semantic versioning.
 func isValidVersion(currentVersion string, targetVersion string) (bool, error) {
-	curr := strings.Split(currentVersion, ".")
-	target := strings.Split(targetVersion, ".")
-
-	if len(curr) != len(target) {
-		return false, nil
-	}
-
-	for index, val := range curr {
-		currVal, err := strconv.Atoi(val)
-		if err != nil {
-			return false, nil
-		}
-
-		targetVal, err := strconv.Atoi(target[index])
-		if err != nil {
-			return false, nil
-		}
-
-		if currVal < targetVal {
-			return false, nil
-		}
+	order, err := remoteConfig.CompareVersions(currentVersion, targetVersion)
+	if err != nil {
+		return false, err
 	}
 
-	return true, nil
+	return order >= 0, nil
 }
@@ -10,10 +10,40 @@ type ErrorCode struct {
 }
 
 var (
-	UserNotFoundError        = ErrorCode{Code: 10004, error: errors.New("username not found")}
-	WrongPasswordError       = ErrorCode{Code: 10003, error: errors.New("wrong password")}
-	RegisteredEmailError     = ErrorCode{Code: 10002, error: errors.New("email is registered")}
-	RegisteredUsernameError  = ErrorCode{Code: 10001, error: errors.New("username is registered")}
-	SportConfigNotFoundError = ErrorCode{Code: 20000, error: errors.New("Sport config is not found")}
-	StaleInfoError           = ErrorCode{Code: 22222, error: errors.New("Stale info")}
+	UserNotFoundError          = ErrorCode{Code: 10004, error: errors.New("username not found")}
+	WrongPasswordError         = ErrorCode{Code: 10003, error: errors.New("wrong password")}
+	RegisteredEmailError       = ErrorCode{Code: 10002, error: errors.New("email is registered")}
+	RegisteredUsernameError    = ErrorCode{Code: 10001, error: errors.New("username is registered")}
+	SportConfigNotFoundError   = ErrorCode{Code: 20000, error: errors.New("Sport config is not found")}
+	StaleInfoError             = ErrorCode{Code: 22222, error: errors.New("Stale info")}
+	NotEventHostError          = ErrorCode{Code: 20001, error: errors.New("user is not the host of the event")}
+	NotEventParticipantError   = ErrorCode{Code: 20002, error: errors.New("user is not a participant of the event")}
+	HostingQuotaExceedError    = ErrorCode{Code: 20003, error: errors.New("hosting quota exceeded")}
+	EventNotEditableError      = ErrorCode{Code: 20004, error: errors.New("event had started or been cancelled")}
+	EventBannedError           = ErrorCode{Code: 20005, error: errors.New("user is banned from the event")}
+	EventNotEndedError         = ErrorCode{Code: 20006, error: errors.New("event has not ended yet")}
+	TeamDrawNotFoundError      = ErrorCode{Code: 20007, error: errors.New("teams have not been generated for the event")}
+	InvalidTeamOptionError     = ErrorCode{Code: 20008, error: errors.New("teams cannot be generated with the given options")}
+	SkillLevelOutOfRangeError  = ErrorCode{Code: 20009, error: errors.New("skill level is out of the range of the event")}
+	SkillLevelWarningError     = ErrorCode{Code: 20010, error: errors.New("skill level is out of the range of the event, join again to confirm")}
+	SportExistError            = ErrorCode{Code: 20011, error: errors.New("sport exists already")}
+	UnsupportedMediaError      = ErrorCode{Code: 30001, error: errors.New("media format is not supported")}
+	MediaTooLargeError         = ErrorCode{Code: 30002, error: errors.New("media is too large")}
+	MediaNotUploadedError      = ErrorCode{Code: 30003, error: errors.New("media is not found in the upload folder")}
+	NotClubAdminError          = ErrorCode{Code: 40001, error: errors.New("user is not an admin of the club")}
+	NotClubMemberError         = ErrorCode{Code: 40002, error: errors.New("user is not a member of the club")}
+	ClubMembersOnlyError       = ErrorCode{Code: 40003, error: errors.New("event is only open to the club members")}
+	ClubJoinRequestExistError  = ErrorCode{Code: 40004, error: errors.New("join request is pending already")}
+	ClubMemberExistError       = ErrorCode{Code: 40005, error: errors.New("user is a member of the club already")}
+	ClubOwnerError             = ErrorCode{Code: 40006, error: errors.New("club owner cannot be removed or changed")}
+	TournamentNotEditableError = ErrorCode{Code: 50001, error: errors.New("tournament is not open for this action")}
+	TournamentFullError        = ErrorCode{Code: 50002, error: errors.New("tournament is full")}
+	TournamentRegisteredError  = ErrorCode{Code: 50003, error: errors.New("user registered for the tournament already")}
+	MatchNotReadyError         = ErrorCode{Code: 50004, error: errors.New("opponents of the match are not decided yet")}
+	MatchDrawError             = ErrorCode{Code: 50005, error: errors.New("elimination match cannot end in a draw")}
+	NotTournamentHostError     = ErrorCode{Code: 50006, error: errors.New("user is not the host of the tournament")}
+	MatchAdvancedError         = ErrorCode{Code: 50007, error: errors.New("next round match had been played")}
+	MatchEntryMembersOnlyError = ErrorCode{Code: 50008, error: errors.New("match is only open to the players of the entries")}
+	NotAdminError              = ErrorCode{Code: 60001, error: errors.New("user is not an admin")}
+	MaintenanceError           = ErrorCode{Code: 60002, error: errors.New("app is under maintenance")}
 )
@@ -0,0 +1,12 @@
+-- Deploy sportgether:09_create_event_co_host_table to pg
+
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS sportgether_schema.event_co_host(
+    event_id bigint NOT NULL REFERENCES sportgether_schema.events ON DELETE CASCADE,
+    co_host_id bigint NOT NULL REFERENCES sportgether_schema.users ON DELETE CASCADE,
+    created_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (event_id, co_host_id)
+);
+
+COMMIT;
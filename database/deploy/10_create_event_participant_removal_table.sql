@@ -0,0 +1,20 @@
+-- Deploy sportgether:10_create_event_participant_removal_table to pg
+
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS sportgether_schema.event_participant_removal(
+    id bigserial PRIMARY KEY,
+    event_id bigint NOT NULL REFERENCES sportgether_schema.events ON DELETE CASCADE,
+    participant_id bigint NOT NULL REFERENCES sportgether_schema.users ON DELETE CASCADE,
+    removed_by bigint REFERENCES sportgether_schema.users ON DELETE SET NULL,
+    removal_type text NOT NULL,
+    reason text,
+    created_at timestamp(0) with time zone NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS event_participant_removal_participant_idx ON sportgether_schema.event_participant_removal (participant_id, removal_type);
+
+COMMIT;
+
+
+-- removal_type can be QUIT, KICKED
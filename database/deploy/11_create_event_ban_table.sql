@@ -0,0 +1,19 @@
+-- Deploy sportgether:11_create_event_ban_table to pg
+
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS sportgether_schema.event_ban(
+    id bigserial PRIMARY KEY,
+    host_id bigint NOT NULL REFERENCES sportgether_schema.users ON DELETE CASCADE,
+    banned_user_id bigint NOT NULL REFERENCES sportgether_schema.users ON DELETE CASCADE,
+    event_id bigint REFERENCES sportgether_schema.events ON DELETE CASCADE,
+    reason text,
+    created_at timestamp(0) with time zone NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS event_ban_banned_user_idx ON sportgether_schema.event_ban (banned_user_id);
+
+COMMIT;
+
+
+-- event_id is NULL when the user is banned from all the events of the host
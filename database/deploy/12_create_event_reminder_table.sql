@@ -0,0 +1,13 @@
+-- Deploy sportgether:12_create_event_reminder_table to pg
+
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS sportgether_schema.event_reminder(
+    event_id bigint NOT NULL REFERENCES sportgether_schema.events ON DELETE CASCADE,
+    participant_id bigint NOT NULL REFERENCES sportgether_schema.users ON DELETE CASCADE,
+    offset_in_min int NOT NULL,
+    sent_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (event_id, participant_id, offset_in_min)
+);
+
+COMMIT;
@@ -0,0 +1,11 @@
+-- Deploy sportgether:13_create_user_notification_setting_table to pg
+
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS sportgether_schema.user_notification_setting(
+    user_id bigint PRIMARY KEY NOT NULL REFERENCES sportgether_schema.users ON DELETE CASCADE,
+    event_reminder_enabled bool NOT NULL DEFAULT true,
+    version int NOT NULL DEFAULT 1
+);
+
+COMMIT;
@@ -0,0 +1,20 @@
+-- Deploy sportgether:14_create_event_photo_table to pg
+
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS sportgether_schema.event_photo(
+    id bigserial PRIMARY KEY,
+    event_id bigint NOT NULL REFERENCES sportgether_schema.events ON DELETE CASCADE,
+    uploader_id bigint NOT NULL REFERENCES sportgether_schema.users ON DELETE CASCADE,
+    public_id text NOT NULL,
+    url text NOT NULL,
+    status text NOT NULL DEFAULT 'VISIBLE',
+    created_at timestamp(0) with time zone NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS event_photo_event_idx ON sportgether_schema.event_photo (event_id, created_at DESC);
+
+COMMIT;
+
+
+-- status can be VISIBLE, HIDDEN
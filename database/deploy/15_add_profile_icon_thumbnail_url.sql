@@ -0,0 +1,7 @@
+-- Deploy sportgether:15_add_profile_icon_thumbnail_url to pg
+
+BEGIN;
+
+ALTER TABLE sportgether_schema.user_profile ADD COLUMN IF NOT EXISTS profile_icon_thumbnail_url text;
+
+COMMIT;
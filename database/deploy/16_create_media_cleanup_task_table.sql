@@ -0,0 +1,20 @@
+-- Deploy sportgether:16_create_media_cleanup_task_table to pg
+
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS sportgether_schema.media_cleanup_task(
+    id bigserial PRIMARY KEY,
+    public_id text NOT NULL,
+    status text NOT NULL DEFAULT 'PENDING',
+    attempts int NOT NULL DEFAULT 0,
+    last_error text,
+    next_attempt_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+    created_at timestamp(0) with time zone NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS media_cleanup_task_pending_idx ON sportgether_schema.media_cleanup_task (next_attempt_at) WHERE status = 'PENDING';
+
+COMMIT;
+
+
+-- status can be PENDING, FAILED
@@ -0,0 +1,46 @@
+-- Deploy sportgether:17_create_club_table to pg
+
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS sportgether_schema.club(
+    id bigserial PRIMARY KEY,
+    club_name text NOT NULL,
+    description text,
+    icon_url text,
+    join_policy text NOT NULL DEFAULT 'REQUEST',
+    created_by bigint NOT NULL REFERENCES sportgether_schema.users ON DELETE CASCADE,
+    created_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+    deleted bool NOT NULL DEFAULT false,
+    version int NOT NULL DEFAULT 1
+);
+
+CREATE TABLE IF NOT EXISTS sportgether_schema.club_member(
+    club_id bigint NOT NULL REFERENCES sportgether_schema.club ON DELETE CASCADE,
+    user_id bigint NOT NULL REFERENCES sportgether_schema.users ON DELETE CASCADE,
+    role text NOT NULL DEFAULT 'MEMBER',
+    joined_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (club_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS club_member_user_idx ON sportgether_schema.club_member (user_id);
+
+CREATE TABLE IF NOT EXISTS sportgether_schema.club_join_request(
+    id bigserial PRIMARY KEY,
+    club_id bigint NOT NULL REFERENCES sportgether_schema.club ON DELETE CASCADE,
+    user_id bigint NOT NULL REFERENCES sportgether_schema.users ON DELETE CASCADE,
+    message text,
+    status text NOT NULL DEFAULT 'PENDING',
+    reviewed_by bigint REFERENCES sportgether_schema.users ON DELETE SET NULL,
+    created_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+    reviewed_at timestamp(0) with time zone
+);
+
+-- Only one pending request per user per club
+CREATE UNIQUE INDEX IF NOT EXISTS club_join_request_pending_idx ON sportgether_schema.club_join_request (club_id, user_id) WHERE status = 'PENDING';
+
+COMMIT;
+
+
+-- join_policy can be OPEN, REQUEST
+-- role can be OWNER, ADMIN, MEMBER
+-- status can be PENDING, APPROVED, REJECTED, CANCELLED
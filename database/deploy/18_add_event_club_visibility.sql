@@ -0,0 +1,15 @@
+-- Deploy sportgether:18_add_event_club_visibility to pg
+
+BEGIN;
+
+ALTER TABLE sportgether_schema.events ADD COLUMN IF NOT EXISTS club_id bigint REFERENCES sportgether_schema.club ON DELETE SET NULL;
+ALTER TABLE sportgether_schema.events ADD COLUMN IF NOT EXISTS visibility text NOT NULL DEFAULT 'PUBLIC';
+ALTER TABLE sportgether_schema.events ADD COLUMN IF NOT EXISTS public_at timestamp(0) with time zone;
+
+CREATE INDEX IF NOT EXISTS events_club_idx ON sportgether_schema.events (club_id, start_time) WHERE club_id IS NOT NULL;
+
+COMMIT;
+
+
+-- visibility can be PUBLIC, MEMBERS_ONLY, MEMBERS_FIRST
+-- public_at is only used by MEMBERS_FIRST, the time when the event opens to the public
@@ -0,0 +1,60 @@
+-- Deploy sportgether:19_create_tournament_table to pg
+
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS sportgether_schema.tournament(
+    id bigserial PRIMARY KEY,
+    host_id bigint NOT NULL REFERENCES sportgether_schema.users ON DELETE CASCADE,
+    tournament_name text NOT NULL,
+    event_type text NOT NULL,
+    format text NOT NULL,
+    registration_type text NOT NULL,
+    team_size int NOT NULL DEFAULT 1,
+    max_entry_count int NOT NULL,
+    description text,
+    status text NOT NULL DEFAULT 'REGISTRATION',
+    created_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+    version int NOT NULL DEFAULT 1
+);
+
+CREATE TABLE IF NOT EXISTS sportgether_schema.tournament_entry(
+    id bigserial PRIMARY KEY,
+    tournament_id bigint NOT NULL REFERENCES sportgether_schema.tournament ON DELETE CASCADE,
+    entry_name text NOT NULL,
+    captain_id bigint NOT NULL REFERENCES sportgether_schema.users ON DELETE CASCADE,
+    created_at timestamp(0) with time zone NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS sportgether_schema.tournament_entry_member(
+    entry_id bigint NOT NULL REFERENCES sportgether_schema.tournament_entry ON DELETE CASCADE,
+    tournament_id bigint NOT NULL REFERENCES sportgether_schema.tournament ON DELETE CASCADE,
+    user_id bigint NOT NULL REFERENCES sportgether_schema.users ON DELETE CASCADE,
+    PRIMARY KEY (entry_id, user_id),
+    -- A user can only be in one entry of the same tournament
+    UNIQUE (tournament_id, user_id)
+);
+
+CREATE TABLE IF NOT EXISTS sportgether_schema.tournament_match(
+    id bigserial PRIMARY KEY,
+    tournament_id bigint NOT NULL REFERENCES sportgether_schema.tournament ON DELETE CASCADE,
+    round int NOT NULL,
+    match_index int NOT NULL,
+    home_entry_id bigint REFERENCES sportgether_schema.tournament_entry ON DELETE SET NULL,
+    away_entry_id bigint REFERENCES sportgether_schema.tournament_entry ON DELETE SET NULL,
+    home_score int,
+    away_score int,
+    winner_entry_id bigint REFERENCES sportgether_schema.tournament_entry ON DELETE SET NULL,
+    status text NOT NULL DEFAULT 'PENDING',
+    event_id bigint REFERENCES sportgether_schema.events ON DELETE SET NULL,
+    reported_by bigint REFERENCES sportgether_schema.users ON DELETE SET NULL,
+    reported_at timestamp(0) with time zone,
+    UNIQUE (tournament_id, round, match_index)
+);
+
+COMMIT;
+
+
+-- format can be SINGLE_ELIMINATION, ROUND_ROBIN
+-- registration_type can be INDIVIDUAL, TEAM
+-- tournament status can be REGISTRATION, ONGOING, COMPLETED, CANCELLED
+-- match status can be PENDING, READY, COMPLETED, BYE
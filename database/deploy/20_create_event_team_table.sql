@@ -0,0 +1,39 @@
+-- Deploy sportgether:20_create_event_team_table to pg
+
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS sportgether_schema.user_sport_skill(
+    user_id bigint NOT NULL REFERENCES sportgether_schema.users ON DELETE CASCADE,
+    event_type text NOT NULL,
+    skill_rating real NOT NULL,
+    updated_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, event_type)
+);
+
+CREATE TABLE IF NOT EXISTS sportgether_schema.event_team_draw(
+    id bigserial PRIMARY KEY,
+    event_id bigint NOT NULL REFERENCES sportgether_schema.events ON DELETE CASCADE,
+    created_by bigint NOT NULL REFERENCES sportgether_schema.users ON DELETE CASCADE,
+    team_count int NOT NULL,
+    balance_gender bool NOT NULL DEFAULT false,
+    keep_together jsonb NOT NULL DEFAULT '[]',
+    excluded_user_ids jsonb NOT NULL DEFAULT '[]',
+    published_at timestamp(0) with time zone,
+    created_at timestamp(0) with time zone NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS event_team_draw_event_idx ON sportgether_schema.event_team_draw (event_id, id DESC);
+
+CREATE TABLE IF NOT EXISTS sportgether_schema.event_team_member(
+    draw_id bigint NOT NULL REFERENCES sportgether_schema.event_team_draw ON DELETE CASCADE,
+    team_index int NOT NULL,
+    user_id bigint NOT NULL REFERENCES sportgether_schema.users ON DELETE CASCADE,
+    skill_rating real NOT NULL,
+    PRIMARY KEY (draw_id, user_id)
+);
+
+COMMIT;
+
+
+-- skill_rating is higher for the stronger players
+-- keep_together is the list of user id pairs, e.g. [[1, 2], [3, 4]]
@@ -0,0 +1,16 @@
+-- Deploy sportgether:21_add_sport_skill_level to pg
+
+BEGIN;
+
+ALTER TABLE sportgether_schema.user_sport_skill ADD COLUMN IF NOT EXISTS skill_level text NOT NULL DEFAULT 'INTERMEDIATE';
+
+ALTER TABLE sportgether_schema.events ADD COLUMN IF NOT EXISTS min_skill_level text;
+ALTER TABLE sportgether_schema.events ADD COLUMN IF NOT EXISTS max_skill_level text;
+ALTER TABLE sportgether_schema.events ADD COLUMN IF NOT EXISTS skill_range_policy text NOT NULL DEFAULT 'WARN';
+
+COMMIT;
+
+
+-- skill_level can be BEGINNER, INTERMEDIATE, ADVANCED, which is self-declared by the user
+-- min_skill_level and max_skill_level are both optional, NULL means no limit
+-- skill_range_policy can be WARN, BLOCK, for the users joining outside of the skill range
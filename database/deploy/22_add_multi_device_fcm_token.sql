@@ -0,0 +1,26 @@
+-- Deploy sportgether:22_add_multi_device_fcm_token to pg
+
+BEGIN;
+
+-- A device has one token, which moves to the user who logs in on the device last. There is no login time before
+-- last_seen_at, but the token of a user was rewritten on every login, so the row written by the later transaction, i.e.
+-- the younger xmin, is the later login. This runs before the table is altered, which keeps xmin.
+DELETE FROM sportgether_schema.firebase_messaging_token_table a
+USING sportgether_schema.firebase_messaging_token_table b
+WHERE a.token = b.token AND (age(a.xmin), b.user_id) > (age(b.xmin), a.user_id);
+
+ALTER TABLE sportgether_schema.firebase_messaging_token_table DROP CONSTRAINT IF EXISTS firebase_messaging_token_table_pkey;
+ALTER TABLE sportgether_schema.firebase_messaging_token_table ALTER COLUMN user_id DROP DEFAULT;
+ALTER TABLE sportgether_schema.firebase_messaging_token_table ADD COLUMN IF NOT EXISTS platform text;
+ALTER TABLE sportgether_schema.firebase_messaging_token_table ADD COLUMN IF NOT EXISTS created_at timestamp(0) with time zone NOT NULL DEFAULT NOW();
+ALTER TABLE sportgether_schema.firebase_messaging_token_table ADD COLUMN IF NOT EXISTS last_seen_at timestamp(0) with time zone NOT NULL DEFAULT NOW();
+
+ALTER TABLE sportgether_schema.firebase_messaging_token_table ADD PRIMARY KEY (token);
+
+CREATE INDEX IF NOT EXISTS firebase_messaging_token_user_idx ON sportgether_schema.firebase_messaging_token_table (user_id);
+
+COMMIT;
+
+
+-- platform can be IOS, ANDROID, WEB, or NULL for the tokens registered before
+-- last_seen_at is refreshed every time the app registers the token
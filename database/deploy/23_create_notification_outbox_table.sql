@@ -0,0 +1,25 @@
+-- Deploy sportgether:23_create_notification_outbox_table to pg
+
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS sportgether_schema.notification_outbox(
+    id bigserial PRIMARY KEY,
+    kind text NOT NULL,
+    data jsonb NOT NULL,
+    recipient_ids jsonb NOT NULL DEFAULT '[]',
+    status text NOT NULL DEFAULT 'PENDING',
+    attempts int NOT NULL DEFAULT 0,
+    next_attempt_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+    last_error text,
+    created_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+    sent_at timestamp(0) with time zone
+);
+
+CREATE INDEX IF NOT EXISTS notification_outbox_pending_idx ON sportgether_schema.notification_outbox (next_attempt_at) WHERE status = 'PENDING';
+
+COMMIT;
+
+
+-- kind can be EVENT_CREATED, EVENT_JOINED, EVENT_CANCELLED
+-- data is the data payload of the push message
+-- status can be PENDING, SENT, DEAD, where DEAD is given up after too many failed attempts
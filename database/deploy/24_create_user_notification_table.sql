@@ -0,0 +1,25 @@
+-- Deploy sportgether:24_create_user_notification_table to pg
+
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS sportgether_schema.user_notification(
+    id bigserial PRIMARY KEY,
+    user_id bigint NOT NULL REFERENCES sportgether_schema.users ON DELETE CASCADE,
+    outbox_id bigint REFERENCES sportgether_schema.notification_outbox ON DELETE SET NULL,
+    kind text NOT NULL,
+    data jsonb NOT NULL,
+    read_at timestamp(0) with time zone,
+    created_at timestamp(0) with time zone NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS user_notification_user_idx ON sportgether_schema.user_notification (user_id, id DESC);
+CREATE INDEX IF NOT EXISTS user_notification_unread_idx ON sportgether_schema.user_notification (user_id) WHERE read_at IS NULL;
+CREATE INDEX IF NOT EXISTS user_notification_outbox_idx ON sportgether_schema.user_notification (outbox_id);
+
+COMMIT;
+
+
+-- kind is the same as notification_outbox.kind, which can be EVENT_CREATED, EVENT_UPDATED, EVENT_JOINED, EVENT_CANCELLED,
+-- EVENT_HOST_TRANSFERRED, EVENT_KICKED, EVENT_REMINDER, EVENT_TEAMS_PUBLISHED, CLUB_JOIN_REVIEWED, TOURNAMENT_STARTED,
+-- TOURNAMENT_MATCH_SCHEDULED
+-- data is the data payload of the push message, without the notification id
@@ -0,0 +1,29 @@
+-- Deploy sportgether:25_add_notification_preference to pg
+
+BEGIN;
+
+ALTER TABLE sportgether_schema.user_notification_setting ADD COLUMN IF NOT EXISTS join_enabled bool NOT NULL DEFAULT true;
+ALTER TABLE sportgether_schema.user_notification_setting ADD COLUMN IF NOT EXISTS update_enabled bool NOT NULL DEFAULT true;
+ALTER TABLE sportgether_schema.user_notification_setting ADD COLUMN IF NOT EXISTS cancellation_enabled bool NOT NULL DEFAULT true;
+ALTER TABLE sportgether_schema.user_notification_setting ADD COLUMN IF NOT EXISTS chat_enabled bool NOT NULL DEFAULT true;
+ALTER TABLE sportgether_schema.user_notification_setting ADD COLUMN IF NOT EXISTS marketing_enabled bool NOT NULL DEFAULT false;
+ALTER TABLE sportgether_schema.user_notification_setting ADD COLUMN IF NOT EXISTS quiet_hours_enabled bool NOT NULL DEFAULT false;
+ALTER TABLE sportgether_schema.user_notification_setting ADD COLUMN IF NOT EXISTS quiet_hours_start_in_min int NOT NULL DEFAULT 1320;
+ALTER TABLE sportgether_schema.user_notification_setting ADD COLUMN IF NOT EXISTS quiet_hours_end_in_min int NOT NULL DEFAULT 420;
+ALTER TABLE sportgether_schema.user_notification_setting ADD COLUMN IF NOT EXISTS timezone text NOT NULL DEFAULT 'Asia/Kuala_Lumpur';
+
+CREATE TABLE IF NOT EXISTS sportgether_schema.user_event_notification_mute(
+    user_id bigint NOT NULL REFERENCES sportgether_schema.users ON DELETE CASCADE,
+    event_id bigint NOT NULL REFERENCES sportgether_schema.events ON DELETE CASCADE,
+    created_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, event_id)
+);
+
+CREATE INDEX IF NOT EXISTS user_event_notification_mute_event_id_idx ON sportgether_schema.user_event_notification_mute (event_id);
+
+COMMIT;
+
+
+-- quiet_hours_start_in_min and quiet_hours_end_in_min are the minutes of the day in the timezone of the user,
+-- the quiet hours go past midnight when the start is after the end, e.g. 22:00 to 07:00 by default
+-- timezone is the IANA name, e.g. Asia/Kuala_Lumpur
@@ -0,0 +1,15 @@
+-- Deploy sportgether:26_add_notification_locale to pg
+
+BEGIN;
+
+ALTER TABLE sportgether_schema.user_notification_setting ADD COLUMN IF NOT EXISTS locale text;
+
+ALTER TABLE sportgether_schema.notification_outbox ADD COLUMN IF NOT EXISTS message jsonb;
+ALTER TABLE sportgether_schema.user_notification ADD COLUMN IF NOT EXISTS message jsonb;
+
+COMMIT;
+
+
+-- locale can be en, ms, zh, NULL means the user never chooses one, which defaults to the Accept-Language of the app
+-- message is the title and subtitle as keys of the message catalog, rendered in the locale of each recipient,
+-- NULL for the notifications with the title and subtitle in data
@@ -0,0 +1,40 @@
+-- Deploy sportgether:27_create_email_queue_table to pg
+
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS sportgether_schema.email_queue(
+    id bigserial PRIMARY KEY,
+    recipient text NOT NULL,
+    template text NOT NULL,
+    locale text NOT NULL,
+    subject text NOT NULL,
+    plain_body text NOT NULL,
+    html_body text NOT NULL,
+    status text NOT NULL DEFAULT 'PENDING',
+    attempts int NOT NULL DEFAULT 0,
+    next_attempt_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+    last_error text,
+    created_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+    sent_at timestamp(0) with time zone
+);
+
+CREATE INDEX IF NOT EXISTS email_queue_pending_idx ON sportgether_schema.email_queue (next_attempt_at) WHERE status = 'PENDING';
+CREATE INDEX IF NOT EXISTS email_queue_status_idx ON sportgether_schema.email_queue (status, id DESC);
+
+CREATE TABLE IF NOT EXISTS sportgether_schema.email_delivery_attempt(
+    id bigserial PRIMARY KEY,
+    email_id bigint NOT NULL REFERENCES sportgether_schema.email_queue ON DELETE CASCADE,
+    transport text NOT NULL,
+    succeeded bool NOT NULL,
+    error text,
+    attempted_at timestamp(0) with time zone NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS email_delivery_attempt_email_id_idx ON sportgether_schema.email_delivery_attempt (email_id);
+
+COMMIT;
+
+
+-- status can be PENDING, SENT, FAILED, BOUNCED, where FAILED is given up after too many failed attempts,
+-- and BOUNCED is rejected by the mail server for good, e.g. the mailbox does not exist
+-- transport can be smtp, maildir, memory
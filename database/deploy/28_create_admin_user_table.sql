@@ -0,0 +1,13 @@
+-- Deploy sportgether:28_create_admin_user_table to pg
+
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS sportgether_schema.admin_user(
+    user_id bigint PRIMARY KEY NOT NULL REFERENCES sportgether_schema.users ON DELETE CASCADE,
+    created_at timestamp(0) with time zone NOT NULL DEFAULT NOW()
+);
+
+COMMIT;
+
+
+-- The admins of the app, who are granted by inserting into the table directly
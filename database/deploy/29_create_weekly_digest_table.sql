@@ -0,0 +1,32 @@
+-- Deploy sportgether:29_create_weekly_digest_table to pg
+
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS sportgether_schema.user_digest_setting(
+    user_id bigint PRIMARY KEY REFERENCES sportgether_schema.users ON DELETE CASCADE,
+    enabled bool NOT NULL DEFAULT false,
+    home_long_lat geometry(point, 4326),
+    favourite_sports jsonb NOT NULL DEFAULT '[]',
+    updated_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+    version int NOT NULL DEFAULT 1
+);
+
+CREATE INDEX IF NOT EXISTS user_digest_setting_enabled_idx ON sportgether_schema.user_digest_setting (user_id) WHERE enabled;
+
+CREATE TABLE IF NOT EXISTS sportgether_schema.weekly_digest(
+    user_id bigint NOT NULL REFERENCES sportgether_schema.users ON DELETE CASCADE,
+    week_start date NOT NULL,
+    email_id bigint REFERENCES sportgether_schema.email_queue ON DELETE SET NULL,
+    event_count int NOT NULL DEFAULT 0,
+    created_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, week_start)
+);
+
+ALTER TABLE sportgether_schema.email_queue ADD COLUMN IF NOT EXISTS headers jsonb NOT NULL DEFAULT '{}';
+
+COMMIT;
+
+
+-- favourite_sports is the event types of the digest, which falls back to the sports in user_sport_skill when empty
+-- weekly_digest keeps one row per user per week, where week_start is the monday in the timezone of the user
+-- email_queue.headers are the extra email headers, e.g. List-Unsubscribe
@@ -0,0 +1,35 @@
+-- Deploy sportgether:30_create_sport_table to pg
+
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS sportgether_schema.sport(
+    sport text PRIMARY KEY,
+    sport_index int NOT NULL DEFAULT 0,
+    names jsonb NOT NULL DEFAULT '{}',
+    image_url text NOT NULL,
+    icon_url text,
+    default_max_participant_count int NOT NULL DEFAULT 10,
+    status text NOT NULL DEFAULT 'ACTIVE',
+    created_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+    updated_at timestamp(0) with time zone NOT NULL DEFAULT NOW(),
+    version int NOT NULL DEFAULT 1
+);
+
+INSERT INTO sportgether_schema.sport (sport, sport_index, names, image_url, default_max_participant_count)
+VALUES
+    ('BADMINTON', 1, '{"en": "Badminton", "ms": "Badminton", "zh": "羽毛球"}',
+        'https://cdn.pixabay.com/photo/2016/05/31/23/21/badminton-1428046_1280.jpg', 4),
+    ('HIKING', 2, '{"en": "Hiking", "ms": "Mendaki", "zh": "徒步"}',
+        'https://www.shutterstock.com/image-photo/group-four-hikers-backpacks-walks-260nw-1909533559.jpg', 15),
+    ('YOGA', 3, '{"en": "Yoga", "ms": "Yoga", "zh": "瑜伽"}',
+        'https://img.freepik.com/premium-photo/woman-doing-yoga-beach-with-mountain-background_865967-25537.jpg', 12),
+    ('BASKETBALL', 4, '{"en": "Basketball", "ms": "Bola Keranjang", "zh": "篮球"}',
+        'https://img.freepik.com/free-photo/basketball-game-concept_23-2150910692.jpg?size=626&ext=jpg&ga=GA1.1.87170709.1707523200&semt=sph', 10)
+ON CONFLICT (sport) DO NOTHING;
+
+COMMIT;
+
+
+-- sport is the code kept in events.event_type, e.g. BADMINTON
+-- names are the names of the sport keyed by the locale, e.g. {"en": "Hiking", "ms": "Mendaki"}, where en is required
+-- status can be ACTIVE, RETIRED, where the retired sports are kept for the past events, but cannot be hosted anymore
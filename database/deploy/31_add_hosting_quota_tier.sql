@@ -0,0 +1,23 @@
+-- Deploy sportgether:31_add_hosting_quota_tier to pg
+
+BEGIN;
+
+-- The existing events are left without created_at, so that they do not take up the quota right after the deploy.
+ALTER TABLE sportgether_schema.events ADD COLUMN IF NOT EXISTS created_at timestamp(0) with time zone;
+ALTER TABLE sportgether_schema.events ALTER COLUMN created_at SET DEFAULT NOW();
+
+CREATE INDEX IF NOT EXISTS events_host_created_idx ON sportgether_schema.events (host_id, created_at);
+
+ALTER TABLE sportgether_schema.user_hosting_config ADD COLUMN IF NOT EXISTS tier text NOT NULL DEFAULT 'NEW';
+ALTER TABLE sportgether_schema.user_hosting_config ADD COLUMN IF NOT EXISTS max_count_override int;
+ALTER TABLE sportgether_schema.user_hosting_config ADD COLUMN IF NOT EXISTS override_expire_at timestamp(0) with time zone;
+ALTER TABLE sportgether_schema.user_hosting_config ADD COLUMN IF NOT EXISTS override_note text;
+ALTER TABLE sportgether_schema.user_hosting_config ADD COLUMN IF NOT EXISTS updated_at timestamp(0) with time zone NOT NULL DEFAULT NOW();
+
+COMMIT;
+
+
+-- tier can be NEW, VERIFIED, TRUSTED_HOST, CLUB_ADMIN, which is assigned by the admins
+-- max_count_override replaces the max count of the tier until override_expire_at, NULL means no expiry
+-- The hosted count is counted from the events created within the rolling window of the tier, so host_count and
+-- last_refresh_time are no longer used
@@ -0,0 +1,17 @@
+-- Deploy sportgether:32_add_user_notification_pushed_at to pg
+
+BEGIN;
+
+ALTER TABLE sportgether_schema.user_notification ADD COLUMN IF NOT EXISTS pushed_at timestamp(0) with time zone;
+
+-- The notifications of the outbox already sent are not pushed again.
+UPDATE sportgether_schema.user_notification un
+SET pushed_at = un.created_at
+FROM sportgether_schema.notification_outbox n
+WHERE n.id = un.outbox_id AND n.status <> 'PENDING';
+
+COMMIT;
+
+
+-- pushed_at is when the push message of the notification was sent to the devices of the user, so that the retries of
+-- the outbox notification skip the recipients already pushed, NULL when it is not pushed yet, or never, e.g. muted
@@ -0,0 +1,12 @@
+-- Deploy sportgether:33_backfill_verified_hosting_tier to pg
+
+BEGIN;
+
+-- The users before the tiers had the global quota of 5, which is the quota of VERIFIED now, so that their quota is
+-- not cut to the one of NEW by the deploy. Only the users signed up from now on start as NEW.
+INSERT INTO sportgether_schema.user_hosting_config (user_id, tier)
+SELECT u.id, 'VERIFIED' FROM sportgether_schema.users u
+ON CONFLICT (user_id) DO UPDATE SET tier = EXCLUDED.tier, updated_at = NOW()
+WHERE sportgether_schema.user_hosting_config.tier = 'NEW';
+
+COMMIT;
@@ -0,0 +1,11 @@
+-- Deploy sportgether:34_add_user_notification_pushed_tokens to pg
+
+BEGIN;
+
+ALTER TABLE sportgether_schema.user_notification ADD COLUMN IF NOT EXISTS pushed_tokens text[] NOT NULL DEFAULT '{}';
+
+COMMIT;
+
+
+-- pushed_tokens are the devices of the user the push message has already reached, while some others of the user
+-- failed, so that the retries of the outbox notification only send to the devices which failed
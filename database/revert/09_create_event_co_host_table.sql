@@ -0,0 +1,7 @@
+-- Revert sportgether:09_create_event_co_host_table from pg
+
+BEGIN;
+
+DROP TABLE sportgether_schema.event_co_host;
+
+COMMIT;
@@ -0,0 +1,7 @@
+-- Revert sportgether:10_create_event_participant_removal_table from pg
+
+BEGIN;
+
+DROP TABLE sportgether_schema.event_participant_removal;
+
+COMMIT;
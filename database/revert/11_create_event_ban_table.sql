@@ -0,0 +1,7 @@
+-- Revert sportgether:11_create_event_ban_table from pg
+
+BEGIN;
+
+DROP TABLE sportgether_schema.event_ban;
+
+COMMIT;
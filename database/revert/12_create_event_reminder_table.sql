@@ -0,0 +1,7 @@
+-- Revert sportgether:12_create_event_reminder_table from pg
+
+BEGIN;
+
+DROP TABLE sportgether_schema.event_reminder;
+
+COMMIT;
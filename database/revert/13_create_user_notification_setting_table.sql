@@ -0,0 +1,7 @@
+-- Revert sportgether:13_create_user_notification_setting_table from pg
+
+BEGIN;
+
+DROP TABLE sportgether_schema.user_notification_setting;
+
+COMMIT;
@@ -0,0 +1,7 @@
+-- Revert sportgether:14_create_event_photo_table from pg
+
+BEGIN;
+
+DROP TABLE sportgether_schema.event_photo;
+
+COMMIT;
@@ -0,0 +1,7 @@
+-- Revert sportgether:15_add_profile_icon_thumbnail_url from pg
+
+BEGIN;
+
+ALTER TABLE sportgether_schema.user_profile DROP COLUMN profile_icon_thumbnail_url;
+
+COMMIT;
@@ -0,0 +1,7 @@
+-- Revert sportgether:16_create_media_cleanup_task_table from pg
+
+BEGIN;
+
+DROP TABLE sportgether_schema.media_cleanup_task;
+
+COMMIT;
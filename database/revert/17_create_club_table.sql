@@ -0,0 +1,9 @@
+-- Revert sportgether:17_create_club_table from pg
+
+BEGIN;
+
+DROP TABLE sportgether_schema.club_join_request;
+DROP TABLE sportgether_schema.club_member;
+DROP TABLE sportgether_schema.club;
+
+COMMIT;
@@ -0,0 +1,9 @@
+-- Revert sportgether:18_add_event_club_visibility from pg
+
+BEGIN;
+
+ALTER TABLE sportgether_schema.events DROP COLUMN public_at;
+ALTER TABLE sportgether_schema.events DROP COLUMN visibility;
+ALTER TABLE sportgether_schema.events DROP COLUMN club_id;
+
+COMMIT;
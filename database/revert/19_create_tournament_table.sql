@@ -0,0 +1,10 @@
+-- Revert sportgether:19_create_tournament_table from pg
+
+BEGIN;
+
+DROP TABLE sportgether_schema.tournament_match;
+DROP TABLE sportgether_schema.tournament_entry_member;
+DROP TABLE sportgether_schema.tournament_entry;
+DROP TABLE sportgether_schema.tournament;
+
+COMMIT;
@@ -0,0 +1,9 @@
+-- Revert sportgether:20_create_event_team_table from pg
+
+BEGIN;
+
+DROP TABLE sportgether_schema.event_team_member;
+DROP TABLE sportgether_schema.event_team_draw;
+DROP TABLE sportgether_schema.user_sport_skill;
+
+COMMIT;
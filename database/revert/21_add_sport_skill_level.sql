@@ -0,0 +1,10 @@
+-- Revert sportgether:21_add_sport_skill_level from pg
+
+BEGIN;
+
+ALTER TABLE sportgether_schema.events DROP COLUMN skill_range_policy;
+ALTER TABLE sportgether_schema.events DROP COLUMN max_skill_level;
+ALTER TABLE sportgether_schema.events DROP COLUMN min_skill_level;
+ALTER TABLE sportgether_schema.user_sport_skill DROP COLUMN skill_level;
+
+COMMIT;
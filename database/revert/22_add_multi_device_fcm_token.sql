@@ -0,0 +1,19 @@
+-- Revert sportgether:22_add_multi_device_fcm_token from pg
+
+BEGIN;
+
+DROP INDEX sportgether_schema.firebase_messaging_token_user_idx;
+ALTER TABLE sportgether_schema.firebase_messaging_token_table DROP CONSTRAINT firebase_messaging_token_table_pkey;
+
+-- Only keep the latest device of each user.
+DELETE FROM sportgether_schema.firebase_messaging_token_table a
+USING sportgether_schema.firebase_messaging_token_table b
+WHERE a.user_id = b.user_id AND (a.last_seen_at, a.token) < (b.last_seen_at, b.token);
+
+ALTER TABLE sportgether_schema.firebase_messaging_token_table DROP COLUMN last_seen_at;
+ALTER TABLE sportgether_schema.firebase_messaging_token_table DROP COLUMN created_at;
+ALTER TABLE sportgether_schema.firebase_messaging_token_table DROP COLUMN platform;
+ALTER TABLE sportgether_schema.firebase_messaging_token_table ADD PRIMARY KEY (user_id);
+ALTER TABLE sportgether_schema.firebase_messaging_token_table ALTER COLUMN user_id SET DEFAULT nextval('sportgether_schema.firebase_messaging_token_table_user_id_seq');
+
+COMMIT;
@@ -0,0 +1,7 @@
+-- Revert sportgether:23_create_notification_outbox_table from pg
+
+BEGIN;
+
+DROP TABLE sportgether_schema.notification_outbox;
+
+COMMIT;
@@ -0,0 +1,7 @@
+-- Revert sportgether:24_create_user_notification_table from pg
+
+BEGIN;
+
+DROP TABLE sportgether_schema.user_notification;
+
+COMMIT;
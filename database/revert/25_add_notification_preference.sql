@@ -0,0 +1,17 @@
+-- Revert sportgether:25_add_notification_preference from pg
+
+BEGIN;
+
+DROP TABLE sportgether_schema.user_event_notification_mute;
+
+ALTER TABLE sportgether_schema.user_notification_setting DROP COLUMN timezone;
+ALTER TABLE sportgether_schema.user_notification_setting DROP COLUMN quiet_hours_end_in_min;
+ALTER TABLE sportgether_schema.user_notification_setting DROP COLUMN quiet_hours_start_in_min;
+ALTER TABLE sportgether_schema.user_notification_setting DROP COLUMN quiet_hours_enabled;
+ALTER TABLE sportgether_schema.user_notification_setting DROP COLUMN marketing_enabled;
+ALTER TABLE sportgether_schema.user_notification_setting DROP COLUMN chat_enabled;
+ALTER TABLE sportgether_schema.user_notification_setting DROP COLUMN cancellation_enabled;
+ALTER TABLE sportgether_schema.user_notification_setting DROP COLUMN update_enabled;
+ALTER TABLE sportgether_schema.user_notification_setting DROP COLUMN join_enabled;
+
+COMMIT;
@@ -0,0 +1,10 @@
+-- Revert sportgether:26_add_notification_locale from pg
+
+BEGIN;
+
+ALTER TABLE sportgether_schema.user_notification DROP COLUMN message;
+ALTER TABLE sportgether_schema.notification_outbox DROP COLUMN message;
+
+ALTER TABLE sportgether_schema.user_notification_setting DROP COLUMN locale;
+
+COMMIT;
@@ -0,0 +1,8 @@
+-- Revert sportgether:27_create_email_queue_table from pg
+
+BEGIN;
+
+DROP TABLE sportgether_schema.email_delivery_attempt;
+DROP TABLE sportgether_schema.email_queue;
+
+COMMIT;
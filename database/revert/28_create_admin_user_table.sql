@@ -0,0 +1,7 @@
+-- Revert sportgether:28_create_admin_user_table from pg
+
+BEGIN;
+
+DROP TABLE sportgether_schema.admin_user;
+
+COMMIT;
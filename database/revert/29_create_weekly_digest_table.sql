@@ -0,0 +1,9 @@
+-- Revert sportgether:29_create_weekly_digest_table from pg
+
+BEGIN;
+
+ALTER TABLE sportgether_schema.email_queue DROP COLUMN IF EXISTS headers;
+DROP TABLE sportgether_schema.weekly_digest;
+DROP TABLE sportgether_schema.user_digest_setting;
+
+COMMIT;
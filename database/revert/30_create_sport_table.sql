@@ -0,0 +1,7 @@
+-- Revert sportgether:30_create_sport_table from pg
+
+BEGIN;
+
+DROP TABLE sportgether_schema.sport;
+
+COMMIT;
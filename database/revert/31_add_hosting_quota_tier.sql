@@ -0,0 +1,14 @@
+-- Revert sportgether:31_add_hosting_quota_tier from pg
+
+BEGIN;
+
+ALTER TABLE sportgether_schema.user_hosting_config DROP COLUMN updated_at;
+ALTER TABLE sportgether_schema.user_hosting_config DROP COLUMN override_note;
+ALTER TABLE sportgether_schema.user_hosting_config DROP COLUMN override_expire_at;
+ALTER TABLE sportgether_schema.user_hosting_config DROP COLUMN max_count_override;
+ALTER TABLE sportgether_schema.user_hosting_config DROP COLUMN tier;
+
+DROP INDEX sportgether_schema.events_host_created_idx;
+ALTER TABLE sportgether_schema.events DROP COLUMN created_at;
+
+COMMIT;
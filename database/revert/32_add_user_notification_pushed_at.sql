@@ -0,0 +1,7 @@
+-- Revert sportgether:32_add_user_notification_pushed_at from pg
+
+BEGIN;
+
+ALTER TABLE sportgether_schema.user_notification DROP COLUMN pushed_at;
+
+COMMIT;
@@ -0,0 +1,7 @@
+-- Revert sportgether:33_backfill_verified_hosting_tier from pg
+
+BEGIN;
+
+-- Nothing is reverted, as the users backfilled cannot be told apart from the ones verified by the admins since.
+
+COMMIT;
@@ -0,0 +1,7 @@
+-- Revert sportgether:34_add_user_notification_pushed_tokens from pg
+
+BEGIN;
+
+ALTER TABLE sportgether_schema.user_notification DROP COLUMN pushed_tokens;
+
+COMMIT;
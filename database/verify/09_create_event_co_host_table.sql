@@ -0,0 +1,11 @@
+-- Verify sportgether:09_create_event_co_host_table on pg
+
+BEGIN;
+
+SELECT event_id,
+    co_host_id,
+    created_at
+FROM sportgether_schema.event_co_host
+WHERE false;
+
+ROLLBACK;
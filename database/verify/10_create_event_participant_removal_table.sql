@@ -0,0 +1,15 @@
+-- Verify sportgether:10_create_event_participant_removal_table on pg
+
+BEGIN;
+
+SELECT id,
+    event_id,
+    participant_id,
+    removed_by,
+    removal_type,
+    reason,
+    created_at
+FROM sportgether_schema.event_participant_removal
+WHERE false;
+
+ROLLBACK;
@@ -0,0 +1,14 @@
+-- Verify sportgether:11_create_event_ban_table on pg
+
+BEGIN;
+
+SELECT id,
+    host_id,
+    banned_user_id,
+    event_id,
+    reason,
+    created_at
+FROM sportgether_schema.event_ban
+WHERE false;
+
+ROLLBACK;
@@ -0,0 +1,12 @@
+-- Verify sportgether:12_create_event_reminder_table on pg
+
+BEGIN;
+
+SELECT event_id,
+    participant_id,
+    offset_in_min,
+    sent_at
+FROM sportgether_schema.event_reminder
+WHERE false;
+
+ROLLBACK;
@@ -0,0 +1,11 @@
+-- Verify sportgether:13_create_user_notification_setting_table on pg
+
+BEGIN;
+
+SELECT user_id,
+    event_reminder_enabled,
+    version
+FROM sportgether_schema.user_notification_setting
+WHERE false;
+
+ROLLBACK;
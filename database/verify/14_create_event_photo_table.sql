@@ -0,0 +1,15 @@
+-- Verify sportgether:14_create_event_photo_table on pg
+
+BEGIN;
+
+SELECT id,
+    event_id,
+    uploader_id,
+    public_id,
+    url,
+    status,
+    created_at
+FROM sportgether_schema.event_photo
+WHERE false;
+
+ROLLBACK;
@@ -0,0 +1,9 @@
+-- Verify sportgether:15_add_profile_icon_thumbnail_url on pg
+
+BEGIN;
+
+SELECT profile_icon_thumbnail_url
+FROM sportgether_schema.user_profile
+WHERE false;
+
+ROLLBACK;
@@ -0,0 +1,15 @@
+-- Verify sportgether:16_create_media_cleanup_task_table on pg
+
+BEGIN;
+
+SELECT id,
+    public_id,
+    status,
+    attempts,
+    last_error,
+    next_attempt_at,
+    created_at
+FROM sportgether_schema.media_cleanup_task
+WHERE false;
+
+ROLLBACK;
@@ -0,0 +1,35 @@
+-- Verify sportgether:17_create_club_table on pg
+
+BEGIN;
+
+SELECT id,
+    club_name,
+    description,
+    icon_url,
+    join_policy,
+    created_by,
+    created_at,
+    deleted,
+    version
+FROM sportgether_schema.club
+WHERE false;
+
+SELECT club_id,
+    user_id,
+    role,
+    joined_at
+FROM sportgether_schema.club_member
+WHERE false;
+
+SELECT id,
+    club_id,
+    user_id,
+    message,
+    status,
+    reviewed_by,
+    created_at,
+    reviewed_at
+FROM sportgether_schema.club_join_request
+WHERE false;
+
+ROLLBACK;
@@ -0,0 +1,11 @@
+-- Verify sportgether:18_add_event_club_visibility on pg
+
+BEGIN;
+
+SELECT club_id,
+    visibility,
+    public_at
+FROM sportgether_schema.events
+WHERE false;
+
+ROLLBACK;
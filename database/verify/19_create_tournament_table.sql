@@ -0,0 +1,50 @@
+-- Verify sportgether:19_create_tournament_table on pg
+
+BEGIN;
+
+SELECT id,
+    host_id,
+    tournament_name,
+    event_type,
+    format,
+    registration_type,
+    team_size,
+    max_entry_count,
+    description,
+    status,
+    created_at,
+    version
+FROM sportgether_schema.tournament
+WHERE false;
+
+SELECT id,
+    tournament_id,
+    entry_name,
+    captain_id,
+    created_at
+FROM sportgether_schema.tournament_entry
+WHERE false;
+
+SELECT entry_id,
+    tournament_id,
+    user_id
+FROM sportgether_schema.tournament_entry_member
+WHERE false;
+
+SELECT id,
+    tournament_id,
+    round,
+    match_index,
+    home_entry_id,
+    away_entry_id,
+    home_score,
+    away_score,
+    winner_entry_id,
+    status,
+    event_id,
+    reported_by,
+    reported_at
+FROM sportgether_schema.tournament_match
+WHERE false;
+
+ROLLBACK;
@@ -0,0 +1,31 @@
+-- Verify sportgether:20_create_event_team_table on pg
+
+BEGIN;
+
+SELECT user_id,
+    event_type,
+    skill_rating,
+    updated_at
+FROM sportgether_schema.user_sport_skill
+WHERE false;
+
+SELECT id,
+    event_id,
+    created_by,
+    team_count,
+    balance_gender,
+    keep_together,
+    excluded_user_ids,
+    published_at,
+    created_at
+FROM sportgether_schema.event_team_draw
+WHERE false;
+
+SELECT draw_id,
+    team_index,
+    user_id,
+    skill_rating
+FROM sportgether_schema.event_team_member
+WHERE false;
+
+ROLLBACK;
@@ -0,0 +1,15 @@
+-- Verify sportgether:21_add_sport_skill_level on pg
+
+BEGIN;
+
+SELECT skill_level
+FROM sportgether_schema.user_sport_skill
+WHERE false;
+
+SELECT min_skill_level,
+    max_skill_level,
+    skill_range_policy
+FROM sportgether_schema.events
+WHERE false;
+
+ROLLBACK;
@@ -0,0 +1,13 @@
+-- Verify sportgether:22_add_multi_device_fcm_token on pg
+
+BEGIN;
+
+SELECT user_id,
+    token,
+    platform,
+    created_at,
+    last_seen_at
+FROM sportgether_schema.firebase_messaging_token_table
+WHERE false;
+
+ROLLBACK;
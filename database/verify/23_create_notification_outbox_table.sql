@@ -0,0 +1,18 @@
+-- Verify sportgether:23_create_notification_outbox_table on pg
+
+BEGIN;
+
+SELECT id,
+    kind,
+    data,
+    recipient_ids,
+    status,
+    attempts,
+    next_attempt_at,
+    last_error,
+    created_at,
+    sent_at
+FROM sportgether_schema.notification_outbox
+WHERE false;
+
+ROLLBACK;
@@ -0,0 +1,15 @@
+-- Verify sportgether:24_create_user_notification_table on pg
+
+BEGIN;
+
+SELECT id,
+    user_id,
+    outbox_id,
+    kind,
+    data,
+    read_at,
+    created_at
+FROM sportgether_schema.user_notification
+WHERE false;
+
+ROLLBACK;
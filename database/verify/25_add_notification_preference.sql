@@ -0,0 +1,23 @@
+-- Verify sportgether:25_add_notification_preference on pg
+
+BEGIN;
+
+SELECT join_enabled,
+    update_enabled,
+    cancellation_enabled,
+    chat_enabled,
+    marketing_enabled,
+    quiet_hours_enabled,
+    quiet_hours_start_in_min,
+    quiet_hours_end_in_min,
+    timezone
+FROM sportgether_schema.user_notification_setting
+WHERE false;
+
+SELECT user_id,
+    event_id,
+    created_at
+FROM sportgether_schema.user_event_notification_mute
+WHERE false;
+
+ROLLBACK;
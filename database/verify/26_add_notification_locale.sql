@@ -0,0 +1,17 @@
+-- Verify sportgether:26_add_notification_locale on pg
+
+BEGIN;
+
+SELECT locale
+FROM sportgether_schema.user_notification_setting
+WHERE false;
+
+SELECT message
+FROM sportgether_schema.notification_outbox
+WHERE false;
+
+SELECT message
+FROM sportgether_schema.user_notification
+WHERE false;
+
+ROLLBACK;
@@ -0,0 +1,30 @@
+-- Verify sportgether:27_create_email_queue_table on pg
+
+BEGIN;
+
+SELECT id,
+    recipient,
+    template,
+    locale,
+    subject,
+    plain_body,
+    html_body,
+    status,
+    attempts,
+    next_attempt_at,
+    last_error,
+    created_at,
+    sent_at
+FROM sportgether_schema.email_queue
+WHERE false;
+
+SELECT id,
+    email_id,
+    transport,
+    succeeded,
+    error,
+    attempted_at
+FROM sportgether_schema.email_delivery_attempt
+WHERE false;
+
+ROLLBACK;
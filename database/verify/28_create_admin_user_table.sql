@@ -0,0 +1,10 @@
+-- Verify sportgether:28_create_admin_user_table on pg
+
+BEGIN;
+
+SELECT user_id,
+    created_at
+FROM sportgether_schema.admin_user
+WHERE false;
+
+ROLLBACK;
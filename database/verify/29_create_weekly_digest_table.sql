@@ -0,0 +1,26 @@
+-- Verify sportgether:29_create_weekly_digest_table on pg
+
+BEGIN;
+
+SELECT user_id,
+    enabled,
+    home_long_lat,
+    favourite_sports,
+    updated_at,
+    version
+FROM sportgether_schema.user_digest_setting
+WHERE false;
+
+SELECT user_id,
+    week_start,
+    email_id,
+    event_count,
+    created_at
+FROM sportgether_schema.weekly_digest
+WHERE false;
+
+SELECT headers
+FROM sportgether_schema.email_queue
+WHERE false;
+
+ROLLBACK;
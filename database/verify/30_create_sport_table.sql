@@ -0,0 +1,18 @@
+-- Verify sportgether:30_create_sport_table on pg
+
+BEGIN;
+
+SELECT sport,
+    sport_index,
+    names,
+    image_url,
+    icon_url,
+    default_max_participant_count,
+    status,
+    created_at,
+    updated_at,
+    version
+FROM sportgether_schema.sport
+WHERE false;
+
+ROLLBACK;
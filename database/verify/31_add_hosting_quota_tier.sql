@@ -0,0 +1,17 @@
+-- Verify sportgether:31_add_hosting_quota_tier on pg
+
+BEGIN;
+
+SELECT created_at
+FROM sportgether_schema.events
+WHERE false;
+
+SELECT tier,
+    max_count_override,
+    override_expire_at,
+    override_note,
+    updated_at
+FROM sportgether_schema.user_hosting_config
+WHERE false;
+
+ROLLBACK;
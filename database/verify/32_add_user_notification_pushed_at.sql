@@ -0,0 +1,9 @@
+-- Verify sportgether:32_add_user_notification_pushed_at on pg
+
+BEGIN;
+
+SELECT pushed_at
+FROM sportgether_schema.user_notification
+WHERE false;
+
+ROLLBACK;
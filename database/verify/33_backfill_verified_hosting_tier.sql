@@ -0,0 +1,10 @@
+-- Verify sportgether:33_backfill_verified_hosting_tier on pg
+
+BEGIN;
+
+SELECT user_id,
+    tier
+FROM sportgether_schema.user_hosting_config
+WHERE false;
+
+ROLLBACK;
@@ -0,0 +1,9 @@
+-- Verify sportgether:34_add_user_notification_pushed_tokens on pg
+
+BEGIN;
+
+SELECT pushed_tokens
+FROM sportgether_schema.user_notification
+WHERE false;
+
+ROLLBACK;
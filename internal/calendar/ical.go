@@ -0,0 +1,116 @@
+package calendar
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+const (
+	productId       = "-//CharmFlex Studio//SportGether//EN"
+	timestampLayout = "20060102T150405Z"
+	maxLineOctets   = 75
+)
+
+type Event struct {
+	// UID must stay the same for the same event, so that calendar apps replace the old entry on update.
+	UID         string
+	Sequence    int
+	Summary     string
+	Description string
+	Location    string
+	Start       time.Time
+	End         time.Time
+	Cancelled   bool
+}
+
+// EventUID builds the UID of a SportGether event.
+func EventUID(eventId int64) string {
+	return fmt.Sprintf("event-%d@sportgether", eventId)
+}
+
+// Write writes the events as an iCalendar (RFC 5545) document.
+func Write(w io.Writer, calendarName string, events []Event, now time.Time) error {
+	lines := []string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:" + productId,
+		"CALSCALE:GREGORIAN",
+		"METHOD:PUBLISH",
+		"X-WR-CALNAME:" + escapeText(calendarName),
+	}
+
+	for _, event := range events {
+		status := "CONFIRMED"
+		if event.Cancelled {
+			status = "CANCELLED"
+		}
+
+		lines = append(lines,
+			"BEGIN:VEVENT",
+			"UID:"+event.UID,
+			"DTSTAMP:"+formatTime(now),
+			fmt.Sprintf("SEQUENCE:%d", event.Sequence),
+			"DTSTART:"+formatTime(event.Start),
+			"DTEND:"+formatTime(event.End),
+			"SUMMARY:"+escapeText(event.Summary),
+			"STATUS:"+status,
+		)
+		if event.Location != "" {
+			lines = append(lines, "LOCATION:"+escapeText(event.Location))
+		}
+		if event.Description != "" {
+			lines = append(lines, "DESCRIPTION:"+escapeText(event.Description))
+		}
+		lines = append(lines, "END:VEVENT")
+	}
+
+	lines = append(lines, "END:VCALENDAR")
+
+	for _, line := range lines {
+		_, err := io.WriteString(w, foldLine(line)+"\r\n")
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(timestampLayout)
+}
+
+func escapeText(text string) string {
+	replacer := strings.NewReplacer(
+		`\`, `\\`,
+		";", `\;`,
+		",", `\,`,
+		"\r\n", `\n`,
+		"\n", `\n`,
+	)
+	return replacer.Replace(text)
+}
+
+// foldLine splits lines longer than 75 octets, without breaking a multi-byte character.
+func foldLine(line string) string {
+	if len(line) <= maxLineOctets {
+		return line
+	}
+
+	var builder strings.Builder
+	lineLength := 0
+	for _, char := range line {
+		charLength := len(string(char))
+		if lineLength+charLength > maxLineOctets {
+			builder.WriteString("\r\n ")
+			// The leading space counts towards the line length.
+			lineLength = 1
+		}
+		builder.WriteRune(char)
+		lineLength += charLength
+	}
+
+	return builder.String()
+}
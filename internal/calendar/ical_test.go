@@ -0,0 +1,110 @@
+package calendar
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+var update = flag.Bool("update", false, "update the golden files")
+
+func TestWrite(t *testing.T) {
+	start := time.Date(2100, time.January, 1, 10, 0, 0, 0, time.FixedZone("MYT", 8*60*60))
+	events := []Event{
+		{
+			UID:         EventUID(1),
+			Sequence:    2,
+			Summary:     "Futsal, 5v5; bring \\ both jerseys",
+			Description: "Line one\nLine two\r\nLine three",
+			Location:    "Court 1, Kuala Lumpur",
+			Start:       start,
+			End:         start.Add(2 * time.Hour),
+		},
+		{
+			UID: EventUID(2),
+			// Longer than a line, with the multi-byte characters across the folds.
+			Summary:   strings.Repeat("羽毛球 badminton ", 8),
+			Start:     start.Add(24 * time.Hour),
+			End:       start.Add(26 * time.Hour),
+			Cancelled: true,
+		},
+	}
+
+	output := &bytes.Buffer{}
+	err := Write(output, "SportGether, Kuala Lumpur", events, time.Date(2099, time.December, 31, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	golden := filepath.Join("testdata", "events.ics")
+	if *update {
+		err = os.WriteFile(golden, output.Bytes(), 0o644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(output.Bytes(), want) {
+		t.Errorf("got\n%s\nwant\n%s", output, want)
+	}
+}
+
+func TestFoldLine(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+	}{
+		{name: "short", line: "SUMMARY:Futsal"},
+		{name: "exactly 75 octets", line: "SUMMARY:" + strings.Repeat("a", 67)},
+		{name: "76 octets", line: "SUMMARY:" + strings.Repeat("a", 68)},
+		{name: "many folds", line: "DESCRIPTION:" + strings.Repeat("abcdefghij", 30)},
+		{name: "multi-byte", line: "SUMMARY:" + strings.Repeat("羽毛球", 30)},
+		{name: "emoji", line: "SUMMARY:" + strings.Repeat("⚽🏸", 25)},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			folded := foldLine(test.line)
+
+			for _, line := range strings.Split(folded, "\r\n") {
+				if len(line) > maxLineOctets {
+					t.Errorf("got line of %d octets, want at most %d: %q", len(line), maxLineOctets, line)
+				}
+				if !utf8.ValidString(line) {
+					t.Errorf("got a character broken across the lines: %q", line)
+				}
+			}
+			// Unfolding removes the line breaks along with the leading space.
+			if unfolded := strings.ReplaceAll(folded, "\r\n ", ""); unfolded != test.line {
+				t.Errorf("got %q unfolded, want %q", unfolded, test.line)
+			}
+		})
+	}
+}
+
+func TestEscapeText(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{text: "Futsal", want: "Futsal"},
+		{text: "a,b;c", want: `a\,b\;c`},
+		{text: `C:\court`, want: `C:\\court`},
+		{text: "one\ntwo\r\nthree", want: `one\ntwo\nthree`},
+		{text: `\,`, want: `\\\,`},
+	}
+
+	for _, test := range tests {
+		if got := escapeText(test.text); got != test.want {
+			t.Errorf("got %q for %q, want %q", got, test.text, test.want)
+		}
+	}
+}
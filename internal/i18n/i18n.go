@@ -0,0 +1,206 @@
+// Package i18n renders the user facing texts, i.e. the push notifications and the emails, in the locale of the user.
+//
+// The messages are kept in the catalog in locales/<locale>.json, where a message is either a text, or the plural forms
+// of the text keyed by "one" and "other". The placeholders in the text, e.g. {eventName}, are replaced by the args,
+// and {count} by the count of a plural message. A message missing in the locale falls back to DefaultLocale.
+package i18n
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type Locale string
+
+var (
+	English = Locale("en")
+	Malay   = Locale("ms")
+	Chinese = Locale("zh")
+)
+
+var DefaultLocale = English
+
+// SupportedLocales are the locales in the catalog.
+var SupportedLocales = []Locale{English, Malay, Chinese}
+
+//go:embed "locales"
+var localeFS embed.FS
+
+type message struct {
+	One   string `json:"one"`
+	Other string `json:"other"`
+}
+
+func (m *message) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err == nil {
+		m.Other = text
+		return nil
+	}
+
+	type plural message
+	return json.Unmarshal(data, (*plural)(m))
+}
+
+var catalog = loadCatalog()
+
+func loadCatalog() map[Locale]map[string]message {
+	catalog := map[Locale]map[string]message{}
+	for _, locale := range SupportedLocales {
+		data, err := localeFS.ReadFile(fmt.Sprintf("locales/%s.json", locale))
+		if err != nil {
+			panic(err)
+		}
+
+		messages := map[string]message{}
+		err = json.Unmarshal(data, &messages)
+		if err != nil {
+			panic(fmt.Sprintf("invalid catalog of %s: %s", locale, err))
+		}
+		catalog[locale] = messages
+	}
+	return catalog
+}
+
+// ParseLocale matches the language tag, e.g. zh-CN or ms_MY, with the supported locales.
+func ParseLocale(tag string) (Locale, bool) {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	language, _, _ := strings.Cut(strings.ReplaceAll(tag, "_", "-"), "-")
+	for _, locale := range SupportedLocales {
+		if string(locale) == language {
+			return locale, true
+		}
+	}
+	return "", false
+}
+
+// FromAcceptLanguage returns the supported locale the Accept-Language header prefers the most, or DefaultLocale when
+// there is none.
+func FromAcceptLanguage(header string) Locale {
+	locale, ok := MatchAcceptLanguage(header)
+	if !ok {
+		return DefaultLocale
+	}
+	return locale
+}
+
+// MatchAcceptLanguage returns the supported locale the Accept-Language header prefers the most, and false when the
+// header has none of the supported locales.
+func MatchAcceptLanguage(header string) (Locale, bool) {
+	type candidate struct {
+		locale  Locale
+		quality float64
+	}
+
+	candidates := []candidate{}
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(part, ";")
+		locale, ok := ParseLocale(tag)
+		if !ok {
+			continue
+		}
+
+		quality := 1.0
+		if value, found := strings.CutPrefix(strings.TrimSpace(params), "q="); found {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			quality = parsed
+		}
+		if quality > 0 {
+			candidates = append(candidates, candidate{locale, quality})
+		}
+	}
+
+	if len(candidates) == 0 {
+		return "", false
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].quality > candidates[j].quality
+	})
+	return candidates[0].locale, true
+}
+
+// Text is a message to be rendered later in the locale of the recipient.
+type Text struct {
+	Key   string            `json:"key"`
+	Args  map[string]string `json:"args,omitempty"`
+	Count *int              `json:"count,omitempty"`
+}
+
+func NewText(key string, args map[string]string) Text {
+	return Text{Key: key, Args: args}
+}
+
+func NewPluralText(key string, count int, args map[string]string) Text {
+	return Text{Key: key, Args: args, Count: &count}
+}
+
+func (text Text) Render(locale Locale) string {
+	if text.Count != nil {
+		return Plural(locale, text.Key, *text.Count, text.Args)
+	}
+	return Translate(locale, text.Key, text.Args)
+}
+
+// Translate renders the message. The key itself is returned when the message is in none of the catalogs.
+func Translate(locale Locale, key string, args map[string]string) string {
+	m, ok := lookup(locale, key)
+	if !ok {
+		return key
+	}
+	return format(m.Other, args)
+}
+
+// Plural renders the plural form of the message for the count.
+func Plural(locale Locale, key string, count int, args map[string]string) string {
+	m, ok := lookup(locale, key)
+	if !ok {
+		return key
+	}
+
+	text := m.Other
+	if pluralForm(locale, count) == "one" && m.One != "" {
+		text = m.One
+	}
+
+	values := map[string]string{"count": strconv.Itoa(count)}
+	for name, value := range args {
+		values[name] = value
+	}
+	return format(text, values)
+}
+
+func lookup(locale Locale, key string) (message, bool) {
+	if m, ok := catalog[locale][key]; ok {
+		return m, true
+	}
+	m, ok := catalog[DefaultLocale][key]
+	return m, ok
+}
+
+// pluralForm follows the CLDR plural rules, where Malay and Chinese do not inflect for the count.
+func pluralForm(locale Locale, count int) string {
+	if locale == English && count == 1 {
+		return "one"
+	}
+	return "other"
+}
+
+func format(text string, args map[string]string) string {
+	if len(args) == 0 {
+		return text
+	}
+
+	pairs := make([]string, 0, len(args)*2)
+	for name, value := range args {
+		pairs = append(pairs, "{"+name+"}", value)
+	}
+	return strings.NewReplacer(pairs...).Replace(text)
+}
@@ -0,0 +1,198 @@
+package i18n
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"testing"
+)
+
+func TestParseLocale(t *testing.T) {
+	tests := []struct {
+		tag    string
+		want   Locale
+		wantOk bool
+	}{
+		{tag: "en", want: English, wantOk: true},
+		{tag: "ms_MY", want: Malay, wantOk: true},
+		{tag: "zh-Hans-CN", want: Chinese, wantOk: true},
+		{tag: " ZH-tw ", want: Chinese, wantOk: true},
+		{tag: "fr-FR", wantOk: false},
+		{tag: "", wantOk: false},
+		{tag: "*", wantOk: false},
+	}
+
+	for _, test := range tests {
+		got, ok := ParseLocale(test.tag)
+		if got != test.want || ok != test.wantOk {
+			t.Errorf("got %q %t for %q, want %q %t", got, ok, test.tag, test.want, test.wantOk)
+		}
+	}
+}
+
+func TestMatchAcceptLanguage(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		want   Locale
+		wantOk bool
+	}{
+		{name: "single", header: "ms", want: Malay, wantOk: true},
+		{name: "first preferred", header: "ms-MY,ms;q=0.9,en;q=0.8", want: Malay, wantOk: true},
+		{name: "by quality", header: "en;q=0.5, zh-CN;q=0.9", want: Chinese, wantOk: true},
+		{name: "unsupported skipped", header: "fr-FR,fr;q=0.9,en;q=0.7,zh;q=0.5", want: English, wantOk: true},
+		{name: "same quality keeps the order", header: "zh;q=0.8,ms;q=0.8", want: Chinese, wantOk: true},
+		{name: "invalid quality skipped", header: "en;q=high,ms;q=0.1", want: Malay, wantOk: true},
+		{name: "not acceptable", header: "zh;q=0", wantOk: false},
+		{name: "none supported", header: "fr-FR,de;q=0.9", wantOk: false},
+		{name: "empty", header: "", wantOk: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, ok := MatchAcceptLanguage(test.header)
+			if got != test.want || ok != test.wantOk {
+				t.Errorf("got %q %t, want %q %t", got, ok, test.want, test.wantOk)
+			}
+			// FromAcceptLanguage falls back to the default locale.
+			want := test.want
+			if !test.wantOk {
+				want = DefaultLocale
+			}
+			if got := FromAcceptLanguage(test.header); got != want {
+				t.Errorf("got %q from FromAcceptLanguage, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestTranslate(t *testing.T) {
+	args := map[string]string{"eventName": "Futsal"}
+
+	tests := []struct {
+		name   string
+		locale Locale
+		key    string
+		args   map[string]string
+		want   string
+	}{
+		{name: "english", locale: English, key: "notification.event_created.subtitle", args: args, want: "Futsal is open for joining"},
+		{name: "malay", locale: Malay, key: "notification.event_created.subtitle", args: args, want: "Futsal kini dibuka untuk penyertaan"},
+		{name: "missing arg kept", locale: English, key: "notification.event_created.subtitle", want: "{eventName} is open for joining"},
+		{name: "unknown key", locale: Chinese, key: "notification.unknown", args: args, want: "notification.unknown"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := Translate(test.locale, test.key, test.args); got != test.want {
+				t.Errorf("got %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestPlural(t *testing.T) {
+	args := map[string]string{"eventName": "Futsal"}
+
+	tests := []struct {
+		locale Locale
+		count  int
+		want   string
+	}{
+		{locale: English, count: 1, want: "Futsal starts in 1 hour"},
+		{locale: English, count: 2, want: "Futsal starts in 2 hours"},
+		{locale: English, count: 0, want: "Futsal starts in 0 hours"},
+		// Malay and Chinese do not inflect for the count.
+		{locale: Malay, count: 1, want: "Futsal bermula dalam 1 jam"},
+		{locale: Chinese, count: 24, want: "Futsal 将在 24 小时后开始"},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("%s %d", test.locale, test.count), func(t *testing.T) {
+			text := NewPluralText("notification.event_reminder.subtitle_hours", test.count, args)
+			if got := text.Render(test.locale); got != test.want {
+				t.Errorf("got %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestFallbackToDefaultLocale(t *testing.T) {
+	saved := catalog
+	t.Cleanup(func() {
+		catalog = saved
+	})
+	catalog = map[Locale]map[string]message{
+		English: {"greeting": {Other: "Hello {name}"}, "players": {One: "1 player", Other: "{count} players"}},
+		Malay:   {},
+	}
+
+	if got := Translate(Malay, "greeting", map[string]string{"name": "Ali"}); got != "Hello Ali" {
+		t.Errorf("got %q, want the english message", got)
+	}
+	// The plural form of the locale is still used for the message of the default locale.
+	if got := Plural(Malay, "players", 1, nil); got != "1 players" {
+		t.Errorf("got %q, want the other form of the english message", got)
+	}
+}
+
+func TestTextJson(t *testing.T) {
+	text := NewPluralText("notification.event_reminder.subtitle_minutes", 1, map[string]string{"eventName": "Futsal"})
+
+	data, err := json.Marshal(text)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded := Text{}
+	err = json.Unmarshal(data, &decoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The texts are stored with the notifications, and rendered when they are sent.
+	if got := decoded.Render(English); got != "Futsal starts in 1 minute" {
+		t.Errorf("got %q from %s, want it rendered as before storing", got, data)
+	}
+}
+
+var placeholderRX = regexp.MustCompile(`\{[A-Za-z]+\}`)
+
+func placeholders(m message) string {
+	found := map[string]bool{}
+	for _, text := range []string{m.One, m.Other} {
+		for _, placeholder := range placeholderRX.FindAllString(text, -1) {
+			found[placeholder] = true
+		}
+	}
+	names := []string{}
+	for name := range found {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return fmt.Sprint(names)
+}
+
+func TestCatalogsAreComplete(t *testing.T) {
+	for _, locale := range SupportedLocales {
+		if locale == DefaultLocale {
+			continue
+		}
+		for key, want := range catalog[DefaultLocale] {
+			got, ok := catalog[locale][key]
+			if !ok {
+				t.Errorf("got no %s in %s", key, locale)
+				continue
+			}
+			// The one form may leave the count out, e.g. "1 hour", so only the other forms are compared.
+			if placeholders(message{Other: got.Other}) != placeholders(message{Other: want.Other}) {
+				t.Errorf("got placeholders %s of %s in %s, want %s", placeholders(got), key, locale, placeholders(want))
+			}
+		}
+		for key := range catalog[locale] {
+			if _, ok := catalog[DefaultLocale][key]; !ok {
+				t.Errorf("got %s in %s, which is not in %s", key, locale, DefaultLocale)
+			}
+		}
+	}
+}
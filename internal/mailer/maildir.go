@@ -0,0 +1,59 @@
+package mailer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+)
+
+// MaildirMailer keeps the emails in a maildir instead of sending them, which any mail client can open, so that the
+// development environment never needs a real SMTP server.
+type MaildirMailer struct {
+	root    string
+	sender  string
+	counter atomic.Int64
+}
+
+func NewMaildirMailer(root string, sender string) (*MaildirMailer, error) {
+	for _, dir := range []string{"tmp", "new", "cur"} {
+		err := os.MkdirAll(filepath.Join(root, dir), 0o755)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &MaildirMailer{root: root, sender: sender}, nil
+}
+
+func (m *MaildirMailer) Name() string {
+	return "maildir"
+}
+
+// Send writes the email to tmp then moves it to new, as the maildir readers expect a complete file in new.
+func (m *MaildirMailer) Send(message *Message) error {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "localhost"
+	}
+	name := fmt.Sprintf("%d.%d_%d.%s", time.Now().Unix(), os.Getpid(), m.counter.Add(1), hostname)
+
+	tmpPath := filepath.Join(m.root, "tmp", name)
+	file, err := os.Create(tmpPath)
+	if err != nil {
+		return err
+	}
+
+	_, err = newMailMessage(m.sender, message).WriteTo(file)
+	closeErr := file.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+
+	return os.Rename(tmpPath, filepath.Join(m.root, "new", name))
+}
@@ -3,74 +3,82 @@ package mailer
 import (
 	"bytes"
 	"embed"
+	"fmt"
 	"html/template"
-	"time"
-
-	"github.com/go-mail/mail/v2"
+	"sportgether/internal/i18n"
 )
 
 //go:embed "templates"
 var templateFS embed.FS
 
-type Mailer struct {
-	dialer *mail.Dialer
-	sender string
+// Message is the email rendered from the templates, ready to be sent.
+type Message struct {
+	To        string
+	Subject   string
+	PlainBody string
+	HtmlBody  string
+	// Headers are the extra email headers, e.g. List-Unsubscribe.
+	Headers map[string]string
 }
 
-func New(host string, port int, username, password, sender string) Mailer {
-	// Initialize a new mail.Dialer instance with the given SMTP server settings. We
-	// also configure this to use a 5-second timeout whenever we send an email.
-	dialer := mail.NewDialer(host, port, username, password)
-	dialer.Timeout = 5 * time.Second
-
-	return Mailer{
-		dialer: dialer,
-		sender: sender,
-	}
+// Mailer delivers the email right away, without retrying. Send the emails through the email queue instead, which
+// retries and keeps the delivery attempts.
+type Mailer interface {
+	Send(message *Message) error
+	// Name is the name of the transport, kept in the delivery attempts.
+	Name() string
 }
 
-// Define a Send() method on the Mailer type. This takes the recipient email address
-// as the first parameter, the name of the file containing the templates, and any
-// dynamic data for the templates as an any parameter.
-func (m Mailer) Send(recipient, templateFile string, data any) error {
-	tmpl, err := template.New("email").ParseFS(templateFS, "templates/"+templateFile)
+// Render renders the email for the recipient. It takes the recipient email address as
+// the first parameter, the locale of the recipient, the name of the file containing
+// the templates, and any dynamic data for the templates as an any parameter.
+func Render(recipient string, locale i18n.Locale, templateFile string, data any) (*Message, error) {
+	tmpl, err := template.New("email").Funcs(templateFuncs(locale)).ParseFS(templateFS, "templates/"+templateFile)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	subject := new(bytes.Buffer)
 	err = tmpl.ExecuteTemplate(subject, "subject", data)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	plainBody := new(bytes.Buffer)
 	err = tmpl.ExecuteTemplate(plainBody, "plainBody", data)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	htmlBody := new(bytes.Buffer)
 	err = tmpl.ExecuteTemplate(htmlBody, "htmlBody", data)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	msg := mail.NewMessage()
-	msg.SetHeader("To", recipient)
-	msg.SetHeader("From", m.sender)
-	msg.SetHeader("Subject", subject.String())
-	msg.SetBody("text/plain", plainBody.String())
-	msg.AddAlternative("text/html", htmlBody.String())
+	return &Message{
+		To:        recipient,
+		Subject:   subject.String(),
+		PlainBody: plainBody.String(),
+		HtmlBody:  htmlBody.String(),
+	}, nil
+}
 
-	for i := 1; i <= 3; i++ {
-		err = m.dialer.DialAndSend(msg)
-		if err == nil {
-			return nil
-		}
+// templateFuncs renders the messages of the catalog in the templates, e.g. {{t "mail.greeting" "name" .userId}}, where
+// the key is followed by the pairs of the placeholder name and value. The catalog is trusted, so only the values are
+// escaped.
+func templateFuncs(locale i18n.Locale) template.FuncMap {
+	return template.FuncMap{
+		"t": func(key string, pairs ...any) (template.HTML, error) {
+			if len(pairs)%2 != 0 {
+				return "", fmt.Errorf("odd number of args for %s", key)
+			}
 
-		time.Sleep(500 * time.Millisecond)
+			args := map[string]string{}
+			for i := 0; i < len(pairs); i += 2 {
+				args[fmt.Sprint(pairs[i])] = template.HTMLEscapeString(fmt.Sprint(pairs[i+1]))
+			}
+			return template.HTML(i18n.Translate(locale, key, args)), nil
+		},
 	}
-
-	return err
 }
@@ -0,0 +1,34 @@
+package mailer
+
+import "sync"
+
+// MemoryMailer records the emails instead of sending them. Every send fails with Err when it is set.
+type MemoryMailer struct {
+	Err error
+
+	mu       sync.Mutex
+	messages []*Message
+}
+
+func (m *MemoryMailer) Name() string {
+	return "memory"
+}
+
+func (m *MemoryMailer) Send(message *Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.Err != nil {
+		return m.Err
+	}
+	m.messages = append(m.messages, message)
+	return nil
+}
+
+// Messages returns the emails sent so far.
+func (m *MemoryMailer) Messages() []*Message {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]*Message{}, m.messages...)
+}
@@ -0,0 +1,59 @@
+package mailer
+
+import (
+	"errors"
+	"net/textproto"
+	"time"
+
+	"github.com/go-mail/mail/v2"
+)
+
+type SMTPMailer struct {
+	dialer *mail.Dialer
+	sender string
+}
+
+func NewSMTPMailer(host string, port int, username, password, sender string) *SMTPMailer {
+	// Initialize a new mail.Dialer instance with the given SMTP server settings. We
+	// also configure this to use a 5-second timeout whenever we send an email.
+	dialer := mail.NewDialer(host, port, username, password)
+	dialer.Timeout = 5 * time.Second
+
+	return &SMTPMailer{
+		dialer: dialer,
+		sender: sender,
+	}
+}
+
+func (m *SMTPMailer) Name() string {
+	return "smtp"
+}
+
+func (m *SMTPMailer) Send(message *Message) error {
+	return m.dialer.DialAndSend(newMailMessage(m.sender, message))
+}
+
+// IsPermanent tells whether the SMTP server rejected the email for good, e.g. the mailbox does not exist, so that it
+// is not worth retrying.
+func IsPermanent(err error) bool {
+	var sendError *mail.SendError
+	if errors.As(err, &sendError) {
+		err = sendError.Cause
+	}
+
+	var protocolError *textproto.Error
+	return errors.As(err, &protocolError) && protocolError.Code >= 500
+}
+
+func newMailMessage(sender string, message *Message) *mail.Message {
+	msg := mail.NewMessage()
+	msg.SetHeader("To", message.To)
+	msg.SetHeader("From", sender)
+	msg.SetHeader("Subject", message.Subject)
+	for name, value := range message.Headers {
+		msg.SetHeader(name, value)
+	}
+	msg.SetBody("text/plain", message.PlainBody)
+	msg.AddAlternative("text/html", message.HtmlBody)
+	return msg
+}
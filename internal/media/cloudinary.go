@@ -0,0 +1,127 @@
+package media
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cloudinary/cloudinary-go/v2"
+	"github.com/cloudinary/cloudinary-go/v2/api"
+	"github.com/cloudinary/cloudinary-go/v2/api/admin"
+	"github.com/cloudinary/cloudinary-go/v2/api/uploader"
+)
+
+type CloudinaryStorage struct {
+	cld *cloudinary.Cloudinary
+}
+
+func NewCloudinaryStorage(cld *cloudinary.Cloudinary) *CloudinaryStorage {
+	return &CloudinaryStorage{
+		cld: cld,
+	}
+}
+
+func (storage *CloudinaryStorage) Upload(ctx context.Context, folder string, content io.Reader) (*StoredMedia, error) {
+	name, err := newMediaName()
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := storage.cld.Upload.Upload(ctx, content, uploader.UploadParams{
+		Folder:       folder,
+		PublicID:     name,
+		ResourceType: "image",
+	})
+	if err != nil {
+		return nil, err
+	}
+	if res.Error.Message != "" {
+		return nil, errors.New(res.Error.Message)
+	}
+
+	return &StoredMedia{
+		PublicId: res.PublicID,
+		Url:      res.SecureURL,
+	}, nil
+}
+
+func (storage *CloudinaryStorage) Delete(ctx context.Context, publicId string) error {
+	res, err := storage.cld.Upload.Destroy(ctx, uploader.DestroyParams{PublicID: publicId})
+	if err != nil {
+		return err
+	}
+
+	switch res.Result {
+	case "ok":
+		return nil
+	case "not found":
+		return MediaNotFoundError
+	default:
+		return errors.New(res.Error.Message)
+	}
+}
+
+func (storage *CloudinaryStorage) SignUpload(folder string, allowedFormats []string, now time.Time) (*UploadTicket, error) {
+	// Everything signed here cannot be changed by the client.
+	params := url.Values{}
+	params.Set("folder", folder)
+	params.Set("timestamp", strconv.FormatInt(now.Unix(), 10))
+	params.Set("allowed_formats", strings.Join(allowedFormats, ","))
+
+	signature, err := api.SignParameters(params, storage.cld.Config.Cloud.APISecret)
+	if err != nil {
+		return nil, err
+	}
+
+	ticketParams := map[string]string{
+		"api_key":   storage.cld.Config.Cloud.APIKey,
+		"signature": signature,
+	}
+	for key := range params {
+		ticketParams[key] = params.Get(key)
+	}
+
+	return &UploadTicket{
+		UploadUrl: fmt.Sprintf("%s/%s/image/upload", api.BaseURL(storage.cld.Config.API.UploadPrefix), storage.cld.Config.Cloud.CloudName),
+		Params:    ticketParams,
+		ExpiresAt: now.Add(uploadTicketTTL),
+	}, nil
+}
+
+func (storage *CloudinaryStorage) Inspect(ctx context.Context, publicId string) (*MediaInfo, error) {
+	res, err := storage.cld.Admin.Asset(ctx, admin.AssetParams{PublicID: publicId})
+	if err != nil {
+		return nil, err
+	}
+	if res.Error.Message != "" {
+		if strings.Contains(strings.ToLower(res.Error.Message), "not found") {
+			return nil, MediaNotFoundError
+		}
+		return nil, errors.New(res.Error.Message)
+	}
+
+	return &MediaInfo{
+		PublicId: res.PublicID,
+		Url:      res.SecureURL,
+		Format:   res.Format,
+		Bytes:    int64(res.Bytes),
+		Width:    res.Width,
+		Height:   res.Height,
+	}, nil
+}
+
+// Thumbnail is generated by Cloudinary on the first request, cropped around the face if there is one.
+func (storage *CloudinaryStorage) Thumbnail(ctx context.Context, publicId string, size int) (string, error) {
+	image, err := storage.cld.Image(publicId)
+	if err != nil {
+		return "", err
+	}
+	image.Transformation = fmt.Sprintf("c_thumb,g_face,h_%d,w_%d", size, size)
+
+	return image.String()
+}
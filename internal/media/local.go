@@ -0,0 +1,303 @@
+package media
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	"image/png"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LocalStorage keeps the media in a directory, which is served by the api itself under UrlPath.
+// Direct uploads are sent to UploadUrl, which the api serves with UploadWithTicket.
+// It is meant for development and testing only.
+type LocalStorage struct {
+	Root      string
+	UrlPath   string
+	UploadUrl string
+	secret    []byte
+}
+
+func NewLocalStorage(root string, urlPath string, uploadUrl string) (*LocalStorage, error) {
+	err := os.MkdirAll(root, 0o755)
+	if err != nil {
+		return nil, err
+	}
+
+	// Tickets do not need to survive a restart during development, so a random secret is good enough.
+	secret := make([]byte, 32)
+	_, err = rand.Read(secret)
+	if err != nil {
+		return nil, err
+	}
+
+	return &LocalStorage{
+		Root:      root,
+		UrlPath:   strings.TrimSuffix(urlPath, "/") + "/",
+		UploadUrl: uploadUrl,
+		secret:    secret,
+	}, nil
+}
+
+func (storage *LocalStorage) Upload(ctx context.Context, folder string, content io.Reader) (*StoredMedia, error) {
+	name, err := newMediaName()
+	if err != nil {
+		return nil, err
+	}
+
+	publicId := path.Join(folder, name)
+	filePath, err := storage.filePath(publicId)
+	if err != nil {
+		return nil, err
+	}
+
+	err = os.MkdirAll(filepath.Dir(filePath), 0o755)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Create(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	_, err = io.Copy(file, content)
+	if err != nil {
+		os.Remove(filePath)
+		return nil, err
+	}
+
+	return &StoredMedia{
+		PublicId: publicId,
+		Url:      storage.UrlPath + publicId,
+	}, nil
+}
+
+func (storage *LocalStorage) Delete(ctx context.Context, publicId string) error {
+	filePath, err := storage.filePath(publicId)
+	if err != nil {
+		return err
+	}
+
+	err = os.Remove(filePath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return MediaNotFoundError
+	}
+	if err != nil {
+		return err
+	}
+
+	// The thumbnail goes along with the image, if there is one.
+	os.Remove(filePath + "_thumb")
+
+	return nil
+}
+
+// filePath makes sure the public id cannot point outside of the root directory.
+func (storage *LocalStorage) filePath(publicId string) (string, error) {
+	cleaned := path.Clean("/" + publicId)
+	if cleaned == "/" {
+		return "", MediaNotFoundError
+	}
+
+	return filepath.Join(storage.Root, filepath.FromSlash(cleaned)), nil
+}
+
+func (storage *LocalStorage) SignUpload(folder string, allowedFormats []string, now time.Time) (*UploadTicket, error) {
+	params := url.Values{}
+	params.Set("folder", folder)
+	params.Set("timestamp", strconv.FormatInt(now.Unix(), 10))
+	params.Set("allowed_formats", strings.Join(allowedFormats, ","))
+
+	ticketParams := map[string]string{
+		"signature": storage.sign(params),
+	}
+	for key := range params {
+		ticketParams[key] = params.Get(key)
+	}
+
+	return &UploadTicket{
+		UploadUrl: storage.UploadUrl,
+		Params:    ticketParams,
+		ExpiresAt: now.Add(uploadTicketTTL),
+	}, nil
+}
+
+// UploadWithTicket stores the content uploaded directly by the client, after verifying the ticket issued by SignUpload.
+func (storage *LocalStorage) UploadWithTicket(ctx context.Context, params url.Values, content io.Reader, now time.Time) (*StoredMedia, error) {
+	signature, err := hex.DecodeString(params.Get("signature"))
+	if err != nil {
+		return nil, BadUploadSignatureError
+	}
+
+	signedParams := url.Values{}
+	for _, key := range []string{"folder", "timestamp", "allowed_formats"} {
+		signedParams.Set(key, params.Get(key))
+	}
+	expected, _ := hex.DecodeString(storage.sign(signedParams))
+	if !hmac.Equal(signature, expected) {
+		return nil, BadUploadSignatureError
+	}
+
+	timestamp, err := strconv.ParseInt(params.Get("timestamp"), 10, 64)
+	if err != nil || now.Sub(time.Unix(timestamp, 0)) > uploadTicketTTL {
+		return nil, BadUploadSignatureError
+	}
+
+	head := make([]byte, 512)
+	n, err := io.ReadFull(content, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return nil, err
+	}
+	head = head[:n]
+
+	allowedFormats := strings.Split(params.Get("allowed_formats"), ",")
+	if !slices.Contains(allowedFormats, detectFormat(head)) {
+		return nil, fmt.Errorf("format is not allowed, allowed formats are %s", params.Get("allowed_formats"))
+	}
+
+	return storage.Upload(ctx, params.Get("folder"), io.MultiReader(bytes.NewReader(head), content))
+}
+
+func (storage *LocalStorage) Inspect(ctx context.Context, publicId string) (*MediaInfo, error) {
+	filePath, err := storage.filePath(publicId)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(filePath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, MediaNotFoundError
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	stat, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	head := make([]byte, 512)
+	n, err := io.ReadFull(file, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return nil, err
+	}
+
+	info := &MediaInfo{
+		PublicId: publicId,
+		Url:      storage.UrlPath + publicId,
+		Format:   detectFormat(head[:n]),
+		Bytes:    stat.Size(),
+	}
+
+	// Not every format can be decoded by the standard library, e.g. webp, so the dimension is best effort.
+	_, err = file.Seek(0, io.SeekStart)
+	if err != nil {
+		return nil, err
+	}
+	config, _, err := image.DecodeConfig(file)
+	if err == nil {
+		info.Width = config.Width
+		info.Height = config.Height
+	}
+
+	return info, nil
+}
+
+// Thumbnail crops the centre square of the image and scales it down, and stores it next to the image.
+func (storage *LocalStorage) Thumbnail(ctx context.Context, publicId string, size int) (string, error) {
+	filePath, err := storage.filePath(publicId)
+	if err != nil {
+		return "", err
+	}
+
+	file, err := os.Open(filePath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", MediaNotFoundError
+	}
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	source, _, err := image.Decode(file)
+	if err != nil {
+		return "", err
+	}
+
+	bounds := source.Bounds()
+	side := min(bounds.Dx(), bounds.Dy())
+	offsetX := bounds.Min.X + (bounds.Dx()-side)/2
+	offsetY := bounds.Min.Y + (bounds.Dy()-side)/2
+	if side < size {
+		size = side
+	}
+
+	thumbnail := image.NewRGBA(image.Rect(0, 0, size, size))
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			thumbnail.Set(x, y, source.At(offsetX+x*side/size, offsetY+y*side/size))
+		}
+	}
+
+	thumbnailId := publicId + "_thumb"
+	thumbnailPath, err := storage.filePath(thumbnailId)
+	if err != nil {
+		return "", err
+	}
+
+	thumbnailFile, err := os.Create(thumbnailPath)
+	if err != nil {
+		return "", err
+	}
+	defer thumbnailFile.Close()
+
+	err = png.Encode(thumbnailFile, thumbnail)
+	if err != nil {
+		return "", err
+	}
+
+	return storage.UrlPath + thumbnailId, nil
+}
+
+func (storage *LocalStorage) sign(params url.Values) string {
+	mac := hmac.New(sha256.New, storage.secret)
+	mac.Write([]byte(params.Encode()))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func detectFormat(head []byte) string {
+	switch http.DetectContentType(head) {
+	case "image/jpeg":
+		return "jpg"
+	case "image/png":
+		return "png"
+	case "image/gif":
+		return "gif"
+	case "image/webp":
+		return "webp"
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,66 @@
+package media
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"io"
+	"time"
+)
+
+var (
+	MediaNotFoundError      = errors.New("media not found")
+	BadUploadSignatureError = errors.New("bad upload signature")
+)
+
+type StoredMedia struct {
+	PublicId string `json:"publicId"`
+	Url      string `json:"url"`
+}
+
+type MediaInfo struct {
+	PublicId string
+	Url      string
+	// Format is the file extension, e.g. jpg, png
+	Format string
+	Bytes  int64
+	Width  int
+	Height int
+}
+
+// UploadTicket lets the client upload directly to the storage. The client sends Params as form fields along
+// with the file in the "file" field to UploadUrl.
+type UploadTicket struct {
+	UploadUrl string            `json:"uploadUrl"`
+	Params    map[string]string `json:"params"`
+	ExpiresAt time.Time         `json:"expiresAt"`
+}
+
+// Storage hides where the media files are kept, so that features can be developed and tested
+// against the local file system without a Cloudinary account.
+type Storage interface {
+	// Upload stores the content under the folder and returns the public id to refer to it later.
+	Upload(ctx context.Context, folder string, content io.Reader) (*StoredMedia, error)
+	// Delete removes the media. Deleting media which does not exist returns MediaNotFoundError.
+	Delete(ctx context.Context, publicId string) error
+	// SignUpload issues a ticket for the client to upload a single image into the folder.
+	SignUpload(folder string, allowedFormats []string, now time.Time) (*UploadTicket, error)
+	// Inspect returns the stored detail of the media, so that it can be verified before use.
+	Inspect(ctx context.Context, publicId string) (*MediaInfo, error)
+	// Thumbnail returns the url of a square thumbnail of the image.
+	Thumbnail(ctx context.Context, publicId string, size int) (string, error)
+}
+
+// Upload tickets expire after an hour, which is also the limit of Cloudinary.
+const uploadTicketTTL = time.Hour
+
+func newMediaName() (string, error) {
+	randomBytes := make([]byte, 16)
+	_, err := rand.Read(randomBytes)
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(randomBytes), nil
+}
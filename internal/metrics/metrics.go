@@ -0,0 +1,282 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultBuckets are in seconds, from 5ms to 10s, which suit the latency of the requests and the queries.
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// Registry keeps the metrics, and writes them in the Prometheus text format on every scrape.
+type Registry struct {
+	mu         sync.Mutex
+	families   []family
+	collectors []func()
+}
+
+type family interface {
+	write(w *bufio.Writer)
+}
+
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// OnCollect registers fn to be run before every scrape, e.g. to set the gauges read from somewhere else.
+func (registry *Registry) OnCollect(fn func()) {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	registry.collectors = append(registry.collectors, fn)
+}
+
+func (registry *Registry) register(f family) {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	registry.families = append(registry.families, f)
+}
+
+// Handler serves the metrics for Prometheus to scrape.
+func (registry *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		registry.mu.Lock()
+		collectors := append([]func(){}, registry.collectors...)
+		families := append([]family{}, registry.families...)
+		registry.mu.Unlock()
+
+		for _, collect := range collectors {
+			collect()
+		}
+
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		writer := bufio.NewWriter(w)
+		for _, f := range families {
+			f.write(writer)
+		}
+		writer.Flush()
+	})
+}
+
+// desc is shared by the kinds of the metrics, whose series are kept by the label values joined.
+type desc struct {
+	name   string
+	help   string
+	kind   string
+	labels []string
+}
+
+func (d desc) key(values []string) string {
+	if len(values) != len(d.labels) {
+		panic(fmt.Sprintf("metric %s has labels %v, but %d values are given", d.name, d.labels, len(values)))
+	}
+	return strings.Join(values, "\xff")
+}
+
+func (d desc) writeHeader(w *bufio.Writer) {
+	fmt.Fprintf(w, "# HELP %s %s\n", d.name, strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(d.help))
+	fmt.Fprintf(w, "# TYPE %s %s\n", d.name, d.kind)
+}
+
+// writeSample writes the series, with the extra label after the ones of the metric, e.g. le of the histogram buckets.
+func (d desc) writeSample(w *bufio.Writer, suffix string, values []string, extraName string, extraValue string, value float64) {
+	w.WriteString(d.name)
+	w.WriteString(suffix)
+
+	names := d.labels
+	if extraName != "" {
+		names = append(append([]string{}, d.labels...), extraName)
+		values = append(append([]string{}, values...), extraValue)
+	}
+	if len(names) > 0 {
+		w.WriteByte('{')
+		for i, name := range names {
+			if i > 0 {
+				w.WriteByte(',')
+			}
+			fmt.Fprintf(w, `%s="%s"`, name, escapeLabel(values[i]))
+		}
+		w.WriteByte('}')
+	}
+
+	w.WriteByte(' ')
+	w.WriteString(formatFloat(value))
+	w.WriteByte('\n')
+}
+
+func escapeLabel(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
+
+func formatFloat(value float64) string {
+	switch {
+	case math.IsInf(value, 1):
+		return "+Inf"
+	case math.IsInf(value, -1):
+		return "-Inf"
+	default:
+		return strconv.FormatFloat(value, 'g', -1, 64)
+	}
+}
+
+type series struct {
+	values []string
+	value  float64
+}
+
+// valueVec keeps the values of the counters and the gauges.
+type valueVec struct {
+	desc
+	mu     sync.Mutex
+	series map[string]*series
+}
+
+// init starts the metric without labels at zero, so that it is scraped before it ever changes.
+func (vec *valueVec) init(d desc) {
+	vec.desc = d
+	vec.series = map[string]*series{}
+	if len(d.labels) == 0 {
+		vec.get(nil)
+	}
+}
+
+func (vec *valueVec) get(values []string) *series {
+	key := vec.key(values)
+	s, ok := vec.series[key]
+	if !ok {
+		s = &series{values: append([]string{}, values...)}
+		vec.series[key] = s
+	}
+	return s
+}
+
+func (vec *valueVec) write(w *bufio.Writer) {
+	vec.mu.Lock()
+	defer vec.mu.Unlock()
+
+	vec.writeHeader(w)
+	for _, key := range sortedKeys(vec.series) {
+		s := vec.series[key]
+		vec.writeSample(w, "", s.values, "", "", s.value)
+	}
+}
+
+// CounterVec only goes up, and is reset on restart, which Prometheus handles by rate.
+type CounterVec struct {
+	valueVec
+}
+
+func (registry *Registry) NewCounterVec(name string, help string, labels ...string) *CounterVec {
+	vec := &CounterVec{}
+	vec.init(desc{name, help, "counter", labels})
+	registry.register(vec)
+	return vec
+}
+
+func (vec *CounterVec) Inc(labelValues ...string) {
+	vec.Add(1, labelValues...)
+}
+
+func (vec *CounterVec) Add(value float64, labelValues ...string) {
+	if value < 0 {
+		panic(fmt.Sprintf("counter %s cannot decrease", vec.name))
+	}
+	vec.mu.Lock()
+	defer vec.mu.Unlock()
+	vec.get(labelValues).value += value
+}
+
+type GaugeVec struct {
+	valueVec
+}
+
+func (registry *Registry) NewGaugeVec(name string, help string, labels ...string) *GaugeVec {
+	vec := &GaugeVec{}
+	vec.init(desc{name, help, "gauge", labels})
+	registry.register(vec)
+	return vec
+}
+
+func (vec *GaugeVec) Set(value float64, labelValues ...string) {
+	vec.mu.Lock()
+	defer vec.mu.Unlock()
+	vec.get(labelValues).value = value
+}
+
+type histogram struct {
+	values []string
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+// HistogramVec counts the observations by the upper bounds of the buckets, which are written cumulatively.
+type HistogramVec struct {
+	desc
+	buckets []float64
+	mu      sync.Mutex
+	series  map[string]*histogram
+}
+
+func (registry *Registry) NewHistogramVec(name string, help string, buckets []float64, labels ...string) *HistogramVec {
+	buckets = append([]float64{}, buckets...)
+	sort.Float64s(buckets)
+	vec := &HistogramVec{
+		desc:    desc{name, help, "histogram", labels},
+		buckets: buckets,
+		series:  map[string]*histogram{},
+	}
+	registry.register(vec)
+	return vec
+}
+
+func (vec *HistogramVec) Observe(value float64, labelValues ...string) {
+	vec.mu.Lock()
+	defer vec.mu.Unlock()
+
+	key := vec.key(labelValues)
+	h, ok := vec.series[key]
+	if !ok {
+		h = &histogram{values: append([]string{}, labelValues...), counts: make([]uint64, len(vec.buckets))}
+		vec.series[key] = h
+	}
+
+	index := sort.SearchFloat64s(vec.buckets, value)
+	if index < len(vec.buckets) {
+		h.counts[index]++
+	}
+	h.count++
+	h.sum += value
+}
+
+func (vec *HistogramVec) write(w *bufio.Writer) {
+	vec.mu.Lock()
+	defer vec.mu.Unlock()
+
+	vec.writeHeader(w)
+	for _, key := range sortedKeys(vec.series) {
+		h := vec.series[key]
+		var cumulative uint64
+		for i, bound := range vec.buckets {
+			cumulative += h.counts[i]
+			vec.writeSample(w, "_bucket", h.values, "le", formatFloat(bound), float64(cumulative))
+		}
+		vec.writeSample(w, "_bucket", h.values, "le", "+Inf", float64(h.count))
+		vec.writeSample(w, "_sum", h.values, "", "", h.sum)
+		vec.writeSample(w, "_count", h.values, "", "", float64(h.count))
+	}
+}
+
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,119 @@
+package metrics
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func scrape(t *testing.T, registry *Registry) string {
+	t.Helper()
+
+	recorder := httptest.NewRecorder()
+	registry.Handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	if contentType := recorder.Header().Get("Content-Type"); contentType != "text/plain; version=0.0.4; charset=utf-8" {
+		t.Errorf("got content type %s, want the Prometheus text format", contentType)
+	}
+	body, err := io.ReadAll(recorder.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(body)
+}
+
+func assertScrape(t *testing.T, registry *Registry, want string) {
+	t.Helper()
+
+	if got := scrape(t, registry); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestHistogram(t *testing.T) {
+	registry := NewRegistry()
+	histogram := registry.NewHistogramVec("request_duration_seconds", "Latency of the requests.", []float64{1, 0.5}, "route")
+
+	for _, value := range []float64{0.25, 0.5, 1, 4} {
+		histogram.Observe(value, "/v1/event")
+	}
+	histogram.Observe(0.25, "/v1/club")
+
+	// The buckets are sorted, cumulative, and include their upper bounds.
+	assertScrape(t, registry, `# HELP request_duration_seconds Latency of the requests.
+# TYPE request_duration_seconds histogram
+request_duration_seconds_bucket{route="/v1/club",le="0.5"} 1
+request_duration_seconds_bucket{route="/v1/club",le="1"} 1
+request_duration_seconds_bucket{route="/v1/club",le="+Inf"} 1
+request_duration_seconds_sum{route="/v1/club"} 0.25
+request_duration_seconds_count{route="/v1/club"} 1
+request_duration_seconds_bucket{route="/v1/event",le="0.5"} 2
+request_duration_seconds_bucket{route="/v1/event",le="1"} 3
+request_duration_seconds_bucket{route="/v1/event",le="+Inf"} 4
+request_duration_seconds_sum{route="/v1/event"} 5.75
+request_duration_seconds_count{route="/v1/event"} 4
+`)
+}
+
+func TestLabelEscaping(t *testing.T) {
+	registry := NewRegistry()
+	counter := registry.NewCounterVec("requests_total", "Number of the requests,\nby the path in \\ form.", "path")
+
+	counter.Inc("a\"b\\c\nd")
+
+	assertScrape(t, registry, `# HELP requests_total Number of the requests,\nby the path in \\ form.
+# TYPE requests_total counter
+requests_total{path="a\"b\\c\nd"} 1
+`)
+}
+
+func TestCountersAndGauges(t *testing.T) {
+	registry := NewRegistry()
+	sendErrors := registry.NewCounterVec("send_errors_total", "Number of the failed sends.")
+	results := registry.NewCounterVec("messages_total", "Number of the messages by result.", "result")
+	upcoming := registry.NewGaugeVec("upcoming_events", "Number of the upcoming events.")
+	registry.OnCollect(func() {
+		upcoming.Set(3)
+	})
+
+	// The metrics without labels are scraped at zero before they change, and the ones with labels have no series.
+	assertScrape(t, registry, `# HELP send_errors_total Number of the failed sends.
+# TYPE send_errors_total counter
+send_errors_total 0
+# HELP messages_total Number of the messages by result.
+# TYPE messages_total counter
+# HELP upcoming_events Number of the upcoming events.
+# TYPE upcoming_events gauge
+upcoming_events 3
+`)
+
+	sendErrors.Inc()
+	results.Add(2, "success")
+	results.Inc("failure")
+	results.Inc("success")
+
+	assertScrape(t, registry, `# HELP send_errors_total Number of the failed sends.
+# TYPE send_errors_total counter
+send_errors_total 1
+# HELP messages_total Number of the messages by result.
+# TYPE messages_total counter
+messages_total{result="failure"} 1
+messages_total{result="success"} 3
+# HELP upcoming_events Number of the upcoming events.
+# TYPE upcoming_events gauge
+upcoming_events 3
+`)
+}
+
+func TestLabelCountMismatch(t *testing.T) {
+	registry := NewRegistry()
+	counter := registry.NewCounterVec("requests_total", "Number of the requests.", "method", "route")
+
+	defer func() {
+		if recover() == nil {
+			t.Error("got no panic for the missing label value")
+		}
+	}()
+	counter.Inc("GET")
+}
@@ -0,0 +1,652 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+type ClubRole string
+
+var (
+	ClubOwner  = ClubRole("OWNER")
+	ClubAdmin  = ClubRole("ADMIN")
+	ClubMember = ClubRole("MEMBER")
+)
+
+// CanManage tells whether the role can manage the club, e.g. reviewing join requests and hosting club events.
+func (role ClubRole) CanManage() bool {
+	return role == ClubOwner || role == ClubAdmin
+}
+
+const (
+	ClubOpenJoin    = "OPEN"
+	ClubRequestJoin = "REQUEST"
+
+	ClubJoinRequestPending   = "PENDING"
+	ClubJoinRequestApproved  = "APPROVED"
+	ClubJoinRequestRejected  = "REJECTED"
+	ClubJoinRequestCancelled = "CANCELLED"
+)
+
+type ClubDao struct {
+	db *sql.DB
+}
+
+type Club struct {
+	ID          int64     `json:"id"`
+	ClubName    string    `json:"clubName"`
+	Description *string   `json:"description"`
+	IconUrl     *string   `json:"iconUrl"`
+	JoinPolicy  string    `json:"joinPolicy"`
+	CreatedBy   int64     `json:"createdBy"`
+	CreatedAt   time.Time `json:"createdAt"`
+	Version     int       `json:"version"`
+}
+
+type ClubDetail struct {
+	Club
+	MemberCount        int       `json:"memberCount"`
+	UpcomingEventCount int       `json:"upcomingEventCount"`
+	Role               *ClubRole `json:"role"`
+	HasPendingRequest  bool      `json:"hasPendingRequest"`
+}
+
+type ClubMemberDetail struct {
+	UserId         int64     `json:"userId"`
+	Username       string    `json:"username"`
+	PreferredName  *string   `json:"userPreferredName"`
+	ProfileIconUrl *string   `json:"profileIconUrl"`
+	Role           ClubRole  `json:"role"`
+	JoinedAt       time.Time `json:"joinedAt"`
+}
+
+type ClubJoinRequest struct {
+	ID             int64     `json:"id"`
+	ClubId         int64     `json:"clubId"`
+	UserId         int64     `json:"userId"`
+	Username       string    `json:"username"`
+	PreferredName  *string   `json:"userPreferredName"`
+	ProfileIconUrl *string   `json:"profileIconUrl"`
+	Message        *string   `json:"message"`
+	Status         string    `json:"status"`
+	CreatedAt      time.Time `json:"createdAt"`
+}
+
+type ClubEvent struct {
+	EventId             int64           `json:"eventId"`
+	EventName           string          `json:"eventName"`
+	StartTime           string          `json:"startTime"`
+	EndTime             string          `json:"endTime"`
+	Destination         string          `json:"destination"`
+	EventType           string          `json:"eventType"`
+	Visibility          EventVisibility `json:"visibility"`
+	PublicAt            *string         `json:"publicAt"`
+	ParticipantCount    int             `json:"participantCount"`
+	MaxParticipantCount int             `json:"maxParticipantCount"`
+	IsJoined            bool            `json:"isJoined"`
+	SportImageUrl       string          `json:"sportImageUrl"`
+}
+
+// CreateClub creates the club with the creator as its owner.
+func (dao ClubDao) CreateClub(club *Club, tx *sql.Tx) error {
+	query := `
+	WITH new_club AS (
+		INSERT INTO sportgether_schema.club (club_name, description, icon_url, join_policy, created_by)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at, version
+	), owner AS (
+		INSERT INTO sportgether_schema.club_member (club_id, user_id, role)
+		SELECT new_club.id, $5, $6 FROM new_club
+	)
+	SELECT id, created_at, version FROM new_club
+`
+	args := []any{
+		club.ClubName,
+		club.Description,
+		club.IconUrl,
+		club.JoinPolicy,
+		club.CreatedBy,
+		ClubOwner,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := tx.QueryRowContext(ctx, query, args...).Scan(&club.ID, &club.CreatedAt, &club.Version)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (dao ClubDao) UpdateClub(club *Club) error {
+	query := `
+	UPDATE sportgether_schema.club
+	SET club_name = $1, description = $2, icon_url = $3, join_policy = $4, version = version + 1
+	WHERE id = $5 AND deleted IS FALSE
+	RETURNING version
+`
+	args := []any{
+		club.ClubName,
+		club.Description,
+		club.IconUrl,
+		club.JoinPolicy,
+		club.ID,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := dao.db.QueryRowContext(ctx, query, args...).Scan(&club.Version)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// DeleteClub keeps the club events, with their visibility. Without the club, the members only events are only seen by
+// their current participants and hosts, and the members first events by them until they are open to the public.
+func (dao ClubDao) DeleteClub(clubId int64, tx *sql.Tx) error {
+	query := `UPDATE sportgether_schema.club SET deleted = true WHERE id = $1`
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := tx.ExecContext(ctx, query, clubId)
+	if err != nil {
+		return err
+	}
+
+	query = `
+	UPDATE sportgether_schema.events SET club_id = NULL, version = version + 1
+	WHERE club_id = $1
+`
+	ctx, cancel1 := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel1()
+
+	_, err = tx.ExecContext(ctx, query, clubId)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetClubDetail returns sql.ErrNoRows when the club does not exist or was deleted.
+func (dao ClubDao) GetClubDetail(clubId int64, userId int64) (*ClubDetail, error) {
+	query := `
+	SELECT
+	    c.id,
+	    c.club_name,
+	    c.description,
+	    c.icon_url,
+	    c.join_policy,
+	    c.created_by,
+	    c.created_at,
+	    c.version,
+	    (SELECT count(*) FROM sportgether_schema.club_member cm WHERE cm.club_id = c.id),
+	    (SELECT count(*) FROM sportgether_schema.events e WHERE e.club_id = c.id AND e.start_time > $3 AND e.deleted IS FALSE),
+	    (SELECT cm.role FROM sportgether_schema.club_member cm WHERE cm.club_id = c.id AND cm.user_id = $2),
+	    EXISTS (
+	        SELECT 1 FROM sportgether_schema.club_join_request jr
+	        WHERE jr.club_id = c.id AND jr.user_id = $2 AND jr.status = $4
+	    )
+	FROM sportgether_schema.club c
+	WHERE c.id = $1 AND c.deleted IS FALSE
+`
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	detail := &ClubDetail{}
+	err := dao.db.QueryRowContext(ctx, query, clubId, userId, time.Now(), ClubJoinRequestPending).Scan(
+		&detail.ID,
+		&detail.ClubName,
+		&detail.Description,
+		&detail.IconUrl,
+		&detail.JoinPolicy,
+		&detail.CreatedBy,
+		&detail.CreatedAt,
+		&detail.Version,
+		&detail.MemberCount,
+		&detail.UpcomingEventCount,
+		&detail.Role,
+		&detail.HasPendingRequest,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return detail, nil
+}
+
+func (dao ClubDao) GetUserClubs(userId int64) ([]*ClubDetail, error) {
+	query := `
+	SELECT
+	    c.id,
+	    c.club_name,
+	    c.description,
+	    c.icon_url,
+	    c.join_policy,
+	    c.created_by,
+	    c.created_at,
+	    c.version,
+	    (SELECT count(*) FROM sportgether_schema.club_member m WHERE m.club_id = c.id),
+	    (SELECT count(*) FROM sportgether_schema.events e WHERE e.club_id = c.id AND e.start_time > $2 AND e.deleted IS FALSE),
+	    cm.role
+	FROM sportgether_schema.club_member cm
+	INNER JOIN sportgether_schema.club c ON c.id = cm.club_id
+	WHERE cm.user_id = $1 AND c.deleted IS FALSE
+	ORDER BY cm.joined_at DESC
+`
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := dao.db.QueryContext(ctx, query, userId, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	clubs := []*ClubDetail{}
+	for rows.Next() {
+		detail := &ClubDetail{}
+		err = rows.Scan(
+			&detail.ID,
+			&detail.ClubName,
+			&detail.Description,
+			&detail.IconUrl,
+			&detail.JoinPolicy,
+			&detail.CreatedBy,
+			&detail.CreatedAt,
+			&detail.Version,
+			&detail.MemberCount,
+			&detail.UpcomingEventCount,
+			&detail.Role,
+		)
+		if err != nil {
+			return nil, err
+		}
+		clubs = append(clubs, detail)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return clubs, nil
+}
+
+// GetClubMemberRole returns nil when the user is not a member of the club.
+func (dao ClubDao) GetClubMemberRole(clubId int64, userId int64) (*ClubRole, error) {
+	query := `
+	SELECT cm.role FROM sportgether_schema.club_member cm
+	INNER JOIN sportgether_schema.club c ON c.id = cm.club_id
+	WHERE cm.club_id = $1 AND cm.user_id = $2 AND c.deleted IS FALSE
+`
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var role ClubRole
+	err := dao.db.QueryRowContext(ctx, query, clubId, userId).Scan(&role)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &role, nil
+}
+
+// GetClubMemberIds returns all the members of the club, including the owner and admins.
+func (dao ClubDao) GetClubMemberIds(clubId int64, tx *sql.Tx) ([]int64, error) {
+	query := `SELECT cm.user_id FROM sportgether_schema.club_member cm WHERE cm.club_id = $1`
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := tx.QueryContext(ctx, query, clubId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	memberIds := []int64{}
+	for rows.Next() {
+		var memberId int64
+		err = rows.Scan(&memberId)
+		if err != nil {
+			return nil, err
+		}
+		memberIds = append(memberIds, memberId)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return memberIds, nil
+}
+
+func (dao ClubDao) GetClubMembers(clubId int64, pageNumber int64, pageSize int64) ([]*ClubMemberDetail, error) {
+	// Owner first, then admins, then the members by the time they joined.
+	query := `
+	SELECT
+	    u.id,
+	    u.username,
+	    up.preferred_name,
+	    up.profile_icon_url,
+	    cm.role,
+	    cm.joined_at
+	FROM sportgether_schema.club_member cm
+	INNER JOIN sportgether_schema.users u ON u.id = cm.user_id
+	LEFT JOIN sportgether_schema.user_profile up ON up.user_id = cm.user_id
+	WHERE cm.club_id = $1
+	ORDER BY CASE cm.role WHEN $2 THEN 0 WHEN $3 THEN 1 ELSE 2 END, cm.joined_at, u.id
+	LIMIT $4 OFFSET $5
+`
+	args := []any{
+		clubId,
+		ClubOwner,
+		ClubAdmin,
+		pageSize,
+		(pageNumber - 1) * pageSize,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := dao.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	members := []*ClubMemberDetail{}
+	for rows.Next() {
+		member := &ClubMemberDetail{}
+		err = rows.Scan(
+			&member.UserId,
+			&member.Username,
+			&member.PreferredName,
+			&member.ProfileIconUrl,
+			&member.Role,
+			&member.JoinedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		members = append(members, member)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return members, nil
+}
+
+func (dao ClubDao) AddClubMember(clubId int64, userId int64, role ClubRole, tx *sql.Tx) error {
+	query := `
+	INSERT INTO sportgether_schema.club_member (club_id, user_id, role)
+	VALUES ($1, $2, $3)
+	ON CONFLICT (club_id, user_id) DO NOTHING
+`
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var err error
+	if tx != nil {
+		_, err = tx.ExecContext(ctx, query, clubId, userId, role)
+	} else {
+		_, err = dao.db.ExecContext(ctx, query, clubId, userId, role)
+	}
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// UpdateClubMemberRole never touches the owner, who can only be changed by transferring the club.
+func (dao ClubDao) UpdateClubMemberRole(clubId int64, userId int64, role ClubRole) error {
+	query := `
+	UPDATE sportgether_schema.club_member SET role = $1
+	WHERE club_id = $2 AND user_id = $3 AND role <> $4
+`
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := dao.db.ExecContext(ctx, query, role, clubId, userId, ClubOwner)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (dao ClubDao) RemoveClubMember(clubId int64, userId int64) error {
+	query := `
+	DELETE FROM sportgether_schema.club_member WHERE club_id = $1 AND user_id = $2 AND role <> $3
+`
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := dao.db.ExecContext(ctx, query, clubId, userId, ClubOwner)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// CreateClubJoinRequest returns sql.ErrNoRows when the user has a pending request to the club already.
+func (dao ClubDao) CreateClubJoinRequest(request *ClubJoinRequest) error {
+	query := `
+	INSERT INTO sportgether_schema.club_join_request (club_id, user_id, message)
+	VALUES ($1, $2, $3)
+	ON CONFLICT (club_id, user_id) WHERE status = 'PENDING' DO NOTHING
+	RETURNING id, status, created_at
+`
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := dao.db.QueryRowContext(ctx, query, request.ClubId, request.UserId, request.Message).Scan(&request.ID, &request.Status, &request.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (dao ClubDao) CancelClubJoinRequest(clubId int64, userId int64) error {
+	query := `
+	UPDATE sportgether_schema.club_join_request SET status = $1
+	WHERE club_id = $2 AND user_id = $3 AND status = $4
+`
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := dao.db.ExecContext(ctx, query, ClubJoinRequestCancelled, clubId, userId, ClubJoinRequestPending)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (dao ClubDao) GetPendingClubJoinRequests(clubId int64, pageNumber int64, pageSize int64) ([]*ClubJoinRequest, error) {
+	query := `
+	SELECT
+	    jr.id,
+	    jr.club_id,
+	    u.id,
+	    u.username,
+	    up.preferred_name,
+	    up.profile_icon_url,
+	    jr.message,
+	    jr.status,
+	    jr.created_at
+	FROM sportgether_schema.club_join_request jr
+	INNER JOIN sportgether_schema.users u ON u.id = jr.user_id
+	LEFT JOIN sportgether_schema.user_profile up ON up.user_id = jr.user_id
+	WHERE jr.club_id = $1 AND jr.status = $2
+	ORDER BY jr.created_at, jr.id
+	LIMIT $3 OFFSET $4
+`
+	args := []any{
+		clubId,
+		ClubJoinRequestPending,
+		pageSize,
+		(pageNumber - 1) * pageSize,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := dao.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	requests := []*ClubJoinRequest{}
+	for rows.Next() {
+		request := &ClubJoinRequest{}
+		err = rows.Scan(
+			&request.ID,
+			&request.ClubId,
+			&request.UserId,
+			&request.Username,
+			&request.PreferredName,
+			&request.ProfileIconUrl,
+			&request.Message,
+			&request.Status,
+			&request.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		requests = append(requests, request)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return requests, nil
+}
+
+// ReviewClubJoinRequest approves or rejects the pending request, and returns the requester.
+// sql.ErrNoRows is returned when the request is no longer pending.
+func (dao ClubDao) ReviewClubJoinRequest(clubId int64, requestId int64, reviewerId int64, approve bool, tx *sql.Tx) (int64, error) {
+	status := ClubJoinRequestRejected
+	if approve {
+		status = ClubJoinRequestApproved
+	}
+
+	query := `
+	UPDATE sportgether_schema.club_join_request
+	SET status = $1, reviewed_by = $2, reviewed_at = $3
+	WHERE id = $4 AND club_id = $5 AND status = $6
+	RETURNING user_id
+`
+	args := []any{
+		status,
+		reviewerId,
+		time.Now(),
+		requestId,
+		clubId,
+		ClubJoinRequestPending,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var userId int64
+	err := tx.QueryRowContext(ctx, query, args...).Scan(&userId)
+	if err != nil {
+		return 0, err
+	}
+
+	if approve {
+		err = dao.AddClubMember(clubId, userId, ClubMember, tx)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	return userId, nil
+}
+
+// GetClubEvents is the feed of the club. The members-only events, and the members-first events which are not
+// public yet, are left out unless the user is a member.
+func (dao ClubDao) GetClubEvents(clubId int64, userId int64, pageNumber int64, pageSize int64) ([]*ClubEvent, error) {
+	query := `
+	SELECT
+	    e.id,
+	    e.event_name,
+	    e.start_time,
+	    e.end_time,
+	    e.destination,
+	    e.event_type,
+	    e.visibility,
+	    e.public_at,
+	    (SELECT count(*) FROM sportgether_schema.event_participant ep WHERE ep.eventid = e.id),
+	    e.max_participant_count,
+	    EXISTS (SELECT 1 FROM sportgether_schema.event_participant ep WHERE ep.eventid = e.id AND ep.participantid = $2),
+	    COALESCE(s.image_url, '')
+	FROM sportgether_schema.events e
+	LEFT JOIN sportgether_schema.sport s ON s.sport = e.event_type
+	WHERE e.club_id = $1 AND e.end_time > $3 AND e.deleted IS FALSE AND ` + eventVisibleCondition("e", "$2", "$3") + `
+	ORDER BY e.start_time, e.id
+	LIMIT $4 OFFSET $5
+`
+	args := []any{
+		clubId,
+		userId,
+		time.Now(),
+		pageSize,
+		(pageNumber - 1) * pageSize,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := dao.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := []*ClubEvent{}
+	for rows.Next() {
+		event := &ClubEvent{}
+		err = rows.Scan(
+			&event.EventId,
+			&event.EventName,
+			&event.StartTime,
+			&event.EndTime,
+			&event.Destination,
+			&event.EventType,
+			&event.Visibility,
+			&event.PublicAt,
+			&event.ParticipantCount,
+			&event.MaxParticipantCount,
+			&event.IsJoined,
+			&event.SportImageUrl,
+		)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
@@ -3,6 +3,7 @@ package models
 import (
 	"context"
 	"database/sql"
+	"sportgether/remote_config"
 	"time"
 )
 
@@ -13,16 +14,28 @@ type Daos struct {
 	UserProfileDao
 	MessagingDao
 	TokenDao
+	ReminderDao
+	NotificationSettingDao
+	EventPhotoDao
+	MediaCleanupDao
+	ClubDao
+	TournamentDao
+	NotificationOutboxDao
+	UserNotificationDao
+	EmailQueueDao
+	DigestDao
+	SportDao
 }
 
-func NewDaoHandler(database *sql.DB) Daos {
+func NewDaoHandler(database *sql.DB, remoteConfig *remote_config.Store) Daos {
 	return Daos{
 		database,
 		UserDao{
 			database,
 		},
 		EventDao{
-			db: database,
+			db:           database,
+			remoteConfig: remoteConfig,
 		},
 		UserProfileDao{
 			db: database,
@@ -33,6 +46,39 @@ func NewDaoHandler(database *sql.DB) Daos {
 		TokenDao{
 			db: database,
 		},
+		ReminderDao{
+			db: database,
+		},
+		NotificationSettingDao{
+			db: database,
+		},
+		EventPhotoDao{
+			db: database,
+		},
+		MediaCleanupDao{
+			db: database,
+		},
+		ClubDao{
+			db: database,
+		},
+		TournamentDao{
+			db: database,
+		},
+		NotificationOutboxDao{
+			db: database,
+		},
+		UserNotificationDao{
+			db: database,
+		},
+		EmailQueueDao{
+			db: database,
+		},
+		DigestDao{
+			db: database,
+		},
+		SportDao{
+			db: database,
+		},
 	}
 }
 
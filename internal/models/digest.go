@@ -0,0 +1,204 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+type DigestDao struct {
+	db *sql.DB
+}
+
+type DigestSetting struct {
+	Enabled bool `json:"enabled"`
+	// HomeLocation is where the events of the digest are near to, the digest is not sent without it.
+	HomeLocation *GeoType `json:"homeLocation"`
+	// FavouriteSports are the event types of the digest, which fall back to the sports with a skill level when empty.
+	FavouriteSports []string `json:"favouriteSports"`
+}
+
+// DigestSubscriber is the user who opts in the weekly digest, with the home location and the favourite sports.
+type DigestSubscriber struct {
+	UserId       int64
+	Username     string
+	Email        string
+	HomeLocation GeoType
+	Sports       []string
+}
+
+func (dao DigestDao) GetDigestSetting(userId int64) (*DigestSetting, error) {
+	query := `
+	SELECT enabled, ST_X(home_long_lat), ST_Y(home_long_lat), favourite_sports
+	FROM sportgether_schema.user_digest_setting
+	WHERE user_id = $1
+`
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	setting := &DigestSetting{FavouriteSports: []string{}}
+	var longitude, latitude *float64
+	var sportsJson []byte
+	err := dao.db.QueryRowContext(ctx, query, userId).Scan(&setting.Enabled, &longitude, &latitude, &sportsJson)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return setting, nil
+		}
+		return nil, err
+	}
+
+	if longitude != nil && latitude != nil {
+		setting.HomeLocation = &GeoType{Longitude: *longitude, Latitude: *latitude}
+	}
+	err = json.Unmarshal(sportsJson, &setting.FavouriteSports)
+	if err != nil {
+		return nil, err
+	}
+
+	return setting, nil
+}
+
+func (dao DigestDao) UpdateDigestSetting(userId int64, setting *DigestSetting) error {
+	query := `
+	INSERT INTO sportgether_schema.user_digest_setting (user_id, enabled, home_long_lat, favourite_sports)
+	VALUES ($1, $2, CASE WHEN $3::float8 IS NULL THEN NULL ELSE ST_SetSRID(ST_MakePoint($3, $4), 4326) END, $5)
+	ON CONFLICT (user_id)
+	DO UPDATE
+	SET enabled = EXCLUDED.enabled, home_long_lat = EXCLUDED.home_long_lat, favourite_sports = EXCLUDED.favourite_sports,
+		updated_at = NOW(), version = user_digest_setting.version + 1
+`
+	var longitude, latitude *float64
+	if setting.HomeLocation != nil {
+		longitude, latitude = &setting.HomeLocation.Longitude, &setting.HomeLocation.Latitude
+	}
+	sports := setting.FavouriteSports
+	if sports == nil {
+		sports = []string{}
+	}
+	sportsJson, err := json.Marshal(sports)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err = dao.db.ExecContext(ctx, query, userId, setting.Enabled, longitude, latitude, string(sportsJson))
+	return err
+}
+
+// DisableDigest opts the user out of the weekly digest, e.g. from the unsubscribe link.
+func (dao DigestDao) DisableDigest(userId int64) error {
+	query := `
+	UPDATE sportgether_schema.user_digest_setting
+	SET enabled = false, updated_at = NOW(), version = version + 1
+	WHERE user_id = $1 AND enabled
+`
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := dao.db.ExecContext(ctx, query, userId)
+	return err
+}
+
+// GetDigestSubscribers returns the active users who opt in the weekly digest with a home location, after the user id,
+// so that the subscribers are gone through in batches.
+func (dao DigestDao) GetDigestSubscribers(afterUserId int64, limit int) ([]*DigestSubscriber, error) {
+	query := `
+	SELECT u.id, u.username, u.email, ST_X(ds.home_long_lat), ST_Y(ds.home_long_lat),
+		CASE WHEN jsonb_array_length(ds.favourite_sports) > 0 THEN ds.favourite_sports
+		ELSE COALESCE((SELECT jsonb_agg(uss.event_type) FROM sportgether_schema.user_sport_skill uss WHERE uss.user_id = u.id), '[]')
+		END
+	FROM sportgether_schema.user_digest_setting ds
+	INNER JOIN sportgether_schema.users u ON u.id = ds.user_id
+	WHERE ds.enabled AND ds.home_long_lat IS NOT NULL AND u.status = 'ACTIVE' AND u.id > $1
+	ORDER BY u.id
+	LIMIT $2
+`
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := dao.db.QueryContext(ctx, query, afterUserId, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	subscribers := []*DigestSubscriber{}
+	for rows.Next() {
+		subscriber := &DigestSubscriber{}
+		var sportsJson []byte
+		err = rows.Scan(
+			&subscriber.UserId,
+			&subscriber.Username,
+			&subscriber.Email,
+			&subscriber.HomeLocation.Longitude,
+			&subscriber.HomeLocation.Latitude,
+			&sportsJson,
+		)
+		if err != nil {
+			return nil, err
+		}
+		err = json.Unmarshal(sportsJson, &subscriber.Sports)
+		if err != nil {
+			return nil, err
+		}
+		subscribers = append(subscribers, subscriber)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return subscribers, nil
+}
+
+// ClaimWeeklyDigest takes the digest of the week for the user, and returns false when it has been taken already, so
+// that every user gets at most one digest per week even with several instances.
+func (dao DigestDao) ClaimWeeklyDigest(userId int64, weekStart time.Time) (bool, error) {
+	query := `
+	INSERT INTO sportgether_schema.weekly_digest (user_id, week_start)
+	VALUES ($1, $2)
+	ON CONFLICT (user_id, week_start) DO NOTHING
+`
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := dao.db.ExecContext(ctx, query, userId, weekStart.Format(time.DateOnly))
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return affected == 1, nil
+}
+
+// ReleaseWeeklyDigest gives the claim back when the digest fails to be queued, so that it is retried in the next run.
+func (dao DigestDao) ReleaseWeeklyDigest(userId int64, weekStart time.Time) error {
+	query := `DELETE FROM sportgether_schema.weekly_digest WHERE user_id = $1 AND week_start = $2 AND email_id IS NULL`
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := dao.db.ExecContext(ctx, query, userId, weekStart.Format(time.DateOnly))
+	return err
+}
+
+// CompleteWeeklyDigest keeps the queued email of the digest, which is nil when there is no event to send.
+func (dao DigestDao) CompleteWeeklyDigest(userId int64, weekStart time.Time, emailId *int64, eventCount int) error {
+	query := `
+	UPDATE sportgether_schema.weekly_digest
+	SET email_id = $1, event_count = $2
+	WHERE user_id = $3 AND week_start = $4
+`
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := dao.db.ExecContext(ctx, query, emailId, eventCount, userId, weekStart.Format(time.DateOnly))
+	return err
+}
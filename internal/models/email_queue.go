@@ -0,0 +1,271 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+type EmailStatus string
+
+var (
+	EmailPending = EmailStatus("PENDING")
+	EmailSent    = EmailStatus("SENT")
+	// EmailFailed is given up after too many failed attempts.
+	EmailFailed = EmailStatus("FAILED")
+	// EmailBounced is rejected by the mail server for good, e.g. the mailbox does not exist.
+	EmailBounced = EmailStatus("BOUNCED")
+)
+
+func (status EmailStatus) IsValid() bool {
+	return status == EmailPending || status == EmailSent || status == EmailFailed || status == EmailBounced
+}
+
+type EmailQueueDao struct {
+	db *sql.DB
+}
+
+type QueuedEmail struct {
+	ID        int64  `json:"id"`
+	Recipient string `json:"recipient"`
+	Template  string `json:"template"`
+	Locale    string `json:"locale"`
+	Subject   string `json:"subject"`
+	PlainBody string `json:"-"`
+	HtmlBody  string `json:"-"`
+	// Headers are the extra email headers, e.g. List-Unsubscribe.
+	Headers       map[string]string `json:"-"`
+	Status        EmailStatus       `json:"status"`
+	Attempts      int               `json:"attempts"`
+	NextAttemptAt time.Time         `json:"nextAttemptAt"`
+	LastError     *string           `json:"lastError"`
+	CreatedAt     time.Time         `json:"createdAt"`
+	SentAt        *time.Time        `json:"sentAt"`
+}
+
+type EmailDeliveryAttempt struct {
+	ID          int64     `json:"id"`
+	EmailId     int64     `json:"emailId"`
+	Transport   string    `json:"transport"`
+	Succeeded   bool      `json:"succeeded"`
+	Error       *string   `json:"error"`
+	AttemptedAt time.Time `json:"attemptedAt"`
+}
+
+const queuedEmailColumns = `id, recipient, template, locale, subject, plain_body, html_body, headers, status,
+	attempts, next_attempt_at, last_error, created_at, sent_at`
+
+func (dao EmailQueueDao) EnqueueEmail(email *QueuedEmail) error {
+	query := `
+	INSERT INTO sportgether_schema.email_queue (recipient, template, locale, subject, plain_body, html_body, headers)
+	VALUES ($1, $2, $3, $4, $5, $6, $7)
+	RETURNING id, status, next_attempt_at, created_at
+`
+	headers := email.Headers
+	if headers == nil {
+		headers = map[string]string{}
+	}
+	headersJson, err := json.Marshal(headers)
+	if err != nil {
+		return err
+	}
+
+	args := []any{
+		email.Recipient,
+		email.Template,
+		email.Locale,
+		email.Subject,
+		email.PlainBody,
+		email.HtmlBody,
+		string(headersJson),
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	return dao.db.QueryRowContext(ctx, query, args...).Scan(&email.ID, &email.Status, &email.NextAttemptAt, &email.CreatedAt)
+}
+
+// ClaimEmails leases the due emails for leaseDuration, so that other instances skip them meanwhile.
+func (dao EmailQueueDao) ClaimEmails(now time.Time, limit int, leaseDuration time.Duration) ([]*QueuedEmail, error) {
+	query := fmt.Sprintf(`
+	UPDATE sportgether_schema.email_queue
+	SET next_attempt_at = $1
+	WHERE id IN (
+		SELECT id FROM sportgether_schema.email_queue
+		WHERE status = $2 AND next_attempt_at <= $3
+		ORDER BY next_attempt_at
+		LIMIT $4
+		FOR UPDATE SKIP LOCKED
+	)
+	RETURNING %s
+`, queuedEmailColumns)
+	args := []any{
+		now.Add(leaseDuration),
+		EmailPending,
+		now,
+		limit,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := dao.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanQueuedEmails(rows)
+}
+
+// CompleteEmail marks the email as sent. It is a no-op when the email is not pending anymore.
+func (dao EmailQueueDao) CompleteEmail(emailId int64, sentAt time.Time) error {
+	query := `
+	UPDATE sportgether_schema.email_queue
+	SET status = $1, attempts = attempts + 1, sent_at = $2, last_error = NULL
+	WHERE id = $3 AND status = $4
+`
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := dao.db.ExecContext(ctx, query, EmailSent, sentAt, emailId, EmailPending)
+	return err
+}
+
+// RetryEmail schedules the email again at nextAttemptAt when the status is pending, else the email is given up with
+// the status.
+func (dao EmailQueueDao) RetryEmail(emailId int64, lastError string, nextAttemptAt time.Time, status EmailStatus) error {
+	query := `
+	UPDATE sportgether_schema.email_queue
+	SET attempts = attempts + 1, last_error = $1, next_attempt_at = $2, status = $3
+	WHERE id = $4 AND status = $5
+`
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := dao.db.ExecContext(ctx, query, lastError, nextAttemptAt, status, emailId, EmailPending)
+	return err
+}
+
+// InsertEmailDeliveryAttempt logs the attempt to send the email, where a nil error means it succeeded.
+func (dao EmailQueueDao) InsertEmailDeliveryAttempt(emailId int64, transport string, attemptError *string, attemptedAt time.Time) error {
+	query := `
+	INSERT INTO sportgether_schema.email_delivery_attempt (email_id, transport, succeeded, error, attempted_at)
+	VALUES ($1, $2, $3, $4, $5)
+`
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := dao.db.ExecContext(ctx, query, emailId, transport, attemptError == nil, attemptError, attemptedAt)
+	return err
+}
+
+// GetQueuedEmails returns the emails from the latest, of the status if given.
+func (dao EmailQueueDao) GetQueuedEmails(status *EmailStatus, pageNumber int64, pageSize int64) ([]*QueuedEmail, error) {
+	values := []any{}
+	whereClause := ""
+	if status != nil {
+		values = append(values, *status)
+		whereClause = fmt.Sprintf("WHERE status = $%d", len(values))
+	}
+	values = append(values, pageSize, (pageNumber-1)*pageSize)
+
+	query := fmt.Sprintf(`
+	SELECT %s FROM sportgether_schema.email_queue
+	%s
+	ORDER BY id DESC
+	LIMIT $%d OFFSET $%d
+`, queuedEmailColumns, whereClause, len(values)-1, len(values))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := dao.db.QueryContext(ctx, query, values...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanQueuedEmails(rows)
+}
+
+func (dao EmailQueueDao) GetEmailDeliveryAttempts(emailId int64) ([]*EmailDeliveryAttempt, error) {
+	query := `
+	SELECT id, email_id, transport, succeeded, error, attempted_at
+	FROM sportgether_schema.email_delivery_attempt
+	WHERE email_id = $1
+	ORDER BY id
+`
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := dao.db.QueryContext(ctx, query, emailId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	attempts := []*EmailDeliveryAttempt{}
+	for rows.Next() {
+		attempt := &EmailDeliveryAttempt{}
+		err = rows.Scan(
+			&attempt.ID,
+			&attempt.EmailId,
+			&attempt.Transport,
+			&attempt.Succeeded,
+			&attempt.Error,
+			&attempt.AttemptedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		attempts = append(attempts, attempt)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return attempts, nil
+}
+
+func scanQueuedEmails(rows *sql.Rows) ([]*QueuedEmail, error) {
+	emails := []*QueuedEmail{}
+	for rows.Next() {
+		email := &QueuedEmail{}
+		var headersJson []byte
+		err := rows.Scan(
+			&email.ID,
+			&email.Recipient,
+			&email.Template,
+			&email.Locale,
+			&email.Subject,
+			&email.PlainBody,
+			&email.HtmlBody,
+			&headersJson,
+			&email.Status,
+			&email.Attempts,
+			&email.NextAttemptAt,
+			&email.LastError,
+			&email.CreatedAt,
+			&email.SentAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		err = json.Unmarshal(headersJson, &email.Headers)
+		if err != nil {
+			return nil, err
+		}
+		emails = append(emails, email)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return emails, nil
+}
@@ -7,35 +7,43 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"math"
 	"os"
 	"slices"
 	"sportgether/constants"
 	"sportgether/remote_config"
 	"sportgether/tools"
-	"sportgether/utils"
 	"strings"
 	"time"
 )
 
 type EventDao struct {
-	db *sql.DB
+	db           *sql.DB
+	remoteConfig *remote_config.Store
 }
 type GeoType struct {
 	Longitude float64 `json:"longitude"`
 	Latitude  float64 `json:"latitude"`
 }
 type Event struct {
-	ID                  int64   `json:"id"`
-	EventName           string  `json:"eventName"`
-	HostId              int64   `json:"-"`
-	StartTime           string  `json:"startTime"`
-	EndTime             string  `json:"endTime"`
-	Destination         string  `json:"destination"`
-	Distance            float64 `json:"distance"`
-	LongLat             GeoType `json:"longLat"`
-	EventType           string  `json:"eventType"`
-	MaxParticipantCount int     `json:"maxParticipantCount"`
-	Description         string  `json:"description"`
+	ID                  int64            `json:"id"`
+	EventName           string           `json:"eventName"`
+	HostId              int64            `json:"-"`
+	StartTime           string           `json:"startTime"`
+	EndTime             string           `json:"endTime"`
+	Destination         string           `json:"destination"`
+	Distance            float64          `json:"distance"`
+	LongLat             GeoType          `json:"longLat"`
+	EventType           string           `json:"eventType"`
+	MaxParticipantCount int              `json:"maxParticipantCount"`
+	Description         string           `json:"description"`
+	ClubId              *int64           `json:"clubId"`
+	ClubName            *string          `json:"clubName"`
+	Visibility          EventVisibility  `json:"visibility"`
+	PublicAt            *string          `json:"publicAt"`
+	MinSkillLevel       *SkillLevel      `json:"minSkillLevel"`
+	MaxSkillLevel       *SkillLevel      `json:"maxSkillLevel"`
+	SkillRangePolicy    SkillRangePolicy `json:"skillRangePolicy"`
 }
 
 type EventParticipantDetail struct {
@@ -50,11 +58,59 @@ type EventHostDetail EventParticipantDetail
 type EventDetail struct {
 	Event
 	EventHostDetail `json:"host"`
+	CoHosts         []EventParticipantDetail `json:"coHosts"`
 	IsHost          bool                     `json:"isHost"`
+	IsCoHost        bool                     `json:"isCoHost"`
 	IsJoined        bool                     `json:"isJoined"`
 	Status          EventStatus              `json:"status"`
 	Participants    []EventParticipantDetail `json:"participants"`
 	Version         int                      `json:"version"`
+	IsClubMember    bool                     `json:"isClubMember"`
+	// IsRestricted is true when the event is only open to the club members for now, and the user is not one of them.
+	IsRestricted bool `json:"isRestricted"`
+}
+
+// CanManage tells whether the requesting user can edit and moderate the event, which is either the host or a co-host.
+func (detail *EventDetail) CanManage() bool {
+	return detail.IsHost || detail.IsCoHost
+}
+
+func (detail *EventDetail) IsCancelled() bool {
+	return detail.Status == eventCancelled
+}
+
+func (detail *EventDetail) HasCoHost(userId int64) bool {
+	return isCoHost(detail.CoHosts, userId)
+}
+
+func (detail *EventDetail) HasParticipant(userId int64) bool {
+	for _, participant := range detail.Participants {
+		if participant.ParticipantId == userId {
+			return true
+		}
+	}
+	return false
+}
+
+type EventVisibility string
+
+var (
+	PublicEvent           = EventVisibility("PUBLIC")
+	ClubMembersOnlyEvent  = EventVisibility("MEMBERS_ONLY")
+	ClubMembersFirstEvent = EventVisibility("MEMBERS_FIRST")
+)
+
+func (visibility EventVisibility) IsValid() bool {
+	return visibility == PublicEvent || visibility == ClubMembersOnlyEvent || visibility == ClubMembersFirstEvent
+}
+
+// eventVisibleCondition builds the sql condition of whether the event is visible to the user, where the club events
+// can be limited to the club members, or to the club members first until public_at.
+func eventVisibleCondition(eventAlias string, userIdPlaceholder string, nowPlaceholder string) string {
+	return fmt.Sprintf(`(%[1]s.visibility = 'PUBLIC'
+		OR (%[1]s.visibility = 'MEMBERS_FIRST' AND %[1]s.public_at <= %[3]s)
+		OR EXISTS (SELECT 1 FROM sportgether_schema.club_member vcm WHERE vcm.club_id = %[1]s.club_id AND vcm.user_id = %[2]s))`,
+		eventAlias, userIdPlaceholder, nowPlaceholder)
 }
 
 type EventStatus string
@@ -70,14 +126,19 @@ type UserScheduledEventsResponse struct {
 }
 
 type UserScheduledEventDetail struct {
-	EventId       int64  `json:"eventId"`
-	EventName     string `json:"eventName"`
-	StartTime     string `json:"startTime"`
-	EndTime       string `json:"endTime"`
-	Destination   string `json:"destination"`
-	EventType     string `json:"eventType"`
-	Deleted       bool   `json:"isDeleted"`
-	SportImageUrl string `json:"sportImageUrl"`
+	EventId       int64   `json:"eventId"`
+	EventName     string  `json:"eventName"`
+	StartTime     string  `json:"startTime"`
+	EndTime       string  `json:"endTime"`
+	Destination   string  `json:"destination"`
+	EventType     string  `json:"eventType"`
+	Deleted       bool    `json:"isDeleted"`
+	SportImageUrl string  `json:"sportImageUrl"`
+	Version       int     `json:"version"`
+	ClubId        *int64  `json:"clubId"`
+	ClubName      *string `json:"clubName"`
+	// IsJoined is false for the club events which the user has not joined.
+	IsJoined bool `json:"isJoined"`
 }
 
 type EventDetailResponse struct {
@@ -91,11 +152,14 @@ type EventHistoryResponse struct {
 	EventType      string `json:"eventType"`
 }
 
-func (eventDao EventDao) UpdateEvent(event *Event) error {
+// UpdateEvent only updates when editorId is the host or one of the co-hosts of the event.
+func (eventDao EventDao) UpdateEvent(event *Event, editorId int64) error {
 	query := `
 	UPDATE sportgether_schema.events
-	SET start_time = $1, end_time = $2, description = $3
-	WHERE id = $4 AND host_id = $5
+	SET start_time = $1, end_time = $2, description = $3, version = version + 1
+	WHERE id = $4 AND (host_id = $5 OR EXISTS (
+		SELECT 1 FROM sportgether_schema.event_co_host ch WHERE ch.event_id = $4 AND ch.co_host_id = $5
+	))
 	`
 
 	args := []any{
@@ -103,7 +167,7 @@ func (eventDao EventDao) UpdateEvent(event *Event) error {
 		event.EndTime,
 		event.Description,
 		event.ID,
-		event.HostId,
+		editorId,
 	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
@@ -119,9 +183,13 @@ func (eventDao EventDao) UpdateEvent(event *Event) error {
 }
 
 func (eventDao EventDao) CreateEvent(event *Event, tx *sql.Tx) error {
+	if event.SkillRangePolicy == "" {
+		event.SkillRangePolicy = WarnSkillRange
+	}
+
 	query := `
-	INSERT INTO sportgether_schema.events (event_name, host_id, destination, long_lat, start_time, end_time, event_type, max_participant_count, description)
-	VALUES ($1, $2, $3, ST_SetSRID(ST_MakePoint($4, $5), 4326), $6, $7, $8, $9, $10)
+	INSERT INTO sportgether_schema.events (event_name, host_id, destination, long_lat, start_time, end_time, event_type, max_participant_count, description, club_id, visibility, public_at, min_skill_level, max_skill_level, skill_range_policy)
+	VALUES ($1, $2, $3, ST_SetSRID(ST_MakePoint($4, $5), 4326), $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)
 	RETURNING id
 `
 	args := []any{
@@ -135,6 +203,12 @@ func (eventDao EventDao) CreateEvent(event *Event, tx *sql.Tx) error {
 		event.EventType,
 		event.MaxParticipantCount,
 		event.Description,
+		event.ClubId,
+		event.Visibility,
+		event.PublicAt,
+		event.MinSkillLevel,
+		event.MaxSkillLevel,
+		event.SkillRangePolicy,
 	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
@@ -184,6 +258,13 @@ func (eventDao EventDao) GetEvents(filter tools.Filter, user *User) (*EventDetai
 	whereClause := fmt.Sprintf("WHERE event.start_time > $%d AND %s AND event.deleted IS FALSE", len(values)+1, eventTypeQuery)
 	values = append(values, time.Now())
 
+	whereClause += " AND " + eventVisibleCondition("event", fmt.Sprintf("$%d", len(values)+1), fmt.Sprintf("$%d", len(values)))
+	values = append(values, user.ID)
+
+	if filter.SuitsMyLevel {
+		whereClause += " AND " + suitsSkillCondition("event", fmt.Sprintf("$%d", len(values)))
+	}
+
 	distanceQuery := fmt.Sprintf("ST_DistanceSphere(ST_SetSRID(ST_MakePoint($%d, $%d), 4326), event.long_lat)", len(values)+1, len(values)+2)
 	values = append(values, filter.FromLocation.Longitude, filter.FromLocation.Latitude)
 
@@ -216,12 +297,20 @@ func (eventDao EventDao) GetEvents(filter tools.Filter, user *User) (*EventDetai
 	    event_type, 
 	    max_participant_count, 
 	    description, 
+	    event.club_id,
+	    c.club_name,
+	    event.visibility,
+	    event.public_at,
+	    event.min_skill_level,
+	    event.max_skill_level,
+	    event.skill_range_policy,
 	    ep.participantid as participant_id, 
 	    u1.username as participant_name,
 		pup.preferred_name as participant_preferred_name,
 	    pup.profile_icon_url as participant_profile_icon_url from event
 	    INNER JOIN sportgether_schema.users u ON host_id = u.id
 		LEFT JOIN sportgether_schema.user_profile up ON host_id = up.user_id
+		LEFT JOIN sportgether_schema.club c ON event.club_id = c.id
 	    LEFT JOIN sportgether_schema.event_participant ep on ep.eventid = event.id
 	    LEFT join sportgether_schema.users u1 on ep.participantid = u1.id
 		LEFT join sportgether_schema.user_profile pup on u1.id = pup.user_id
@@ -270,6 +359,13 @@ func (eventDao EventDao) GetEvents(filter tools.Filter, user *User) (*EventDetai
 			&eventDetail.EventType,
 			&eventDetail.MaxParticipantCount,
 			&eventDetail.Description,
+			&eventDetail.ClubId,
+			&eventDetail.ClubName,
+			&eventDetail.Visibility,
+			&eventDetail.PublicAt,
+			&eventDetail.MinSkillLevel,
+			&eventDetail.MaxSkillLevel,
+			&eventDetail.SkillRangePolicy,
 			&participant.id,
 			&participant.name,
 			&participant.preferredName,
@@ -312,6 +408,19 @@ func (eventDao EventDao) GetEvents(filter tools.Filter, user *User) (*EventDetai
 		return nil, err
 	}
 
+	eventIds := make([]int64, 0, len(eventsMap))
+	for eventId := range eventsMap {
+		eventIds = append(eventIds, eventId)
+	}
+	coHosts, err := eventDao.getEventCoHosts(eventIds)
+	if err != nil {
+		return nil, err
+	}
+	for eventId, detail := range eventsMap {
+		detail.CoHosts = append([]EventParticipantDetail{}, coHosts[eventId]...)
+		detail.IsCoHost = isCoHost(detail.CoHosts, user.ID)
+	}
+
 	nextCursorId, e := tools.EncodeToBase32(newCursor)
 	if e != nil {
 		return nil, e
@@ -347,7 +456,9 @@ func appendEventStatus(detail *EventDetail) {
 	}
 }
 
-func (EventDao EventDao) GetUserEvents(userId int64) (*UserScheduledEventsResponse, error) {
+// GetUserEvents returns the events of the user which end after endedAfter, i.e. the upcoming and ongoing ones when it
+// is now.
+func (EventDao EventDao) GetUserEvents(userId int64, endedAfter time.Time) (*UserScheduledEventsResponse, error) {
 	query := `
   		select 
   		    e.id, 
@@ -356,16 +467,27 @@ func (EventDao EventDao) GetUserEvents(userId int64) (*UserScheduledEventsRespon
   			e.end_time, 
   			e.destination, 
   			e.event_type, 
-  			e.deleted
-  		from sportgether_schema.event_participant ep 
-  		inner join sportgether_schema.events e on ep.eventId = e.id
-  		WHERE e.end_time > $1 AND ep.participantId = $2
+  			e.deleted,
+  			e.version,
+  			e.club_id,
+  			c.club_name,
+  			ep.participantId IS NOT NULL,
+  			COALESCE(s.image_url, '')
+  		from sportgether_schema.events e
+  		left join sportgether_schema.event_participant ep on ep.eventId = e.id AND ep.participantId = $2
+  		left join sportgether_schema.club c on c.id = e.club_id
+  		left join sportgether_schema.sport s on s.sport = e.event_type
+  		WHERE e.end_time > $1 AND (ep.participantId IS NOT NULL OR (
+  			e.deleted IS FALSE AND EXISTS (
+  				SELECT 1 FROM sportgether_schema.club_member cm WHERE cm.club_id = e.club_id AND cm.user_id = $2
+  			)
+  		))
 		ORDER BY e.start_time
 `
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
-	rows, err := EventDao.db.QueryContext(ctx, query, time.Now(), userId)
+	rows, err := EventDao.db.QueryContext(ctx, query, endedAfter, userId)
 	if err != nil {
 		return nil, err
 	}
@@ -381,14 +503,15 @@ func (EventDao EventDao) GetUserEvents(userId int64) (*UserScheduledEventsRespon
 			&event.Destination,
 			&event.EventType,
 			&event.Deleted,
+			&event.Version,
+			&event.ClubId,
+			&event.ClubName,
+			&event.IsJoined,
+			&event.SportImageUrl,
 		)
 		if err != nil {
 			return nil, err
 		}
-		event.SportImageUrl, err = remote_config.FromSportToImageUrl(event.EventType)
-		if err != nil {
-			return nil, err
-		}
 		userEvents = append(userEvents, event)
 	}
 
@@ -417,17 +540,29 @@ func (eventDao EventDao) GetEventById(eventId int64, userId int64) (*EventDetail
 		    event.event_type, 
 		    event.max_participant_count, 
 		    event.description, 
-			event.deleted
+			event.deleted,
+			event.version,
+			event.club_id,
+			c.club_name,
+			event.visibility,
+			event.public_at,
+			event.min_skill_level,
+			event.max_skill_level,
+			event.skill_range_policy,
+			(event.visibility = 'MEMBERS_ONLY' OR (event.visibility = 'MEMBERS_FIRST' AND event.public_at > $3)),
+			EXISTS (SELECT 1 FROM sportgether_schema.club_member cm WHERE cm.club_id = event.club_id AND cm.user_id = $2)
 		
 		FROM event
 		INNER JOIN sportgether_schema.users u on event.host_id = u.id
 		LEFT JOIN sportgether_schema.user_profile up on u.id = up.user_id
+		LEFT JOIN sportgether_schema.club c on event.club_id = c.id
 `
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
 	var cancelled bool
-	err := eventDao.db.QueryRowContext(ctx, query, eventId).Scan(
+	var membersOnly bool
+	err := eventDao.db.QueryRowContext(ctx, query, eventId, userId, time.Now()).Scan(
 		&eventDetail.ID,
 		&eventDetail.EventName,
 		&eventDetail.EventHostDetail.ParticipantId,
@@ -440,6 +575,16 @@ func (eventDao EventDao) GetEventById(eventId int64, userId int64) (*EventDetail
 		&eventDetail.MaxParticipantCount,
 		&eventDetail.Description,
 		&cancelled,
+		&eventDetail.Version,
+		&eventDetail.ClubId,
+		&eventDetail.ClubName,
+		&eventDetail.Visibility,
+		&eventDetail.PublicAt,
+		&eventDetail.MinSkillLevel,
+		&eventDetail.MaxSkillLevel,
+		&eventDetail.SkillRangePolicy,
+		&membersOnly,
+		&eventDetail.IsClubMember,
 	)
 	if err != nil {
 		return nil, err
@@ -486,6 +631,14 @@ func (eventDao EventDao) GetEventById(eventId int64, userId int64) (*EventDetail
 		}
 	}
 
+	coHosts, err := eventDao.getEventCoHosts([]int64{eventId})
+	if err != nil {
+		return nil, err
+	}
+	eventDetail.CoHosts = append([]EventParticipantDetail{}, coHosts[eventId]...)
+	eventDetail.IsCoHost = isCoHost(eventDetail.CoHosts, userId)
+	eventDetail.IsRestricted = membersOnly && !eventDetail.IsClubMember && !eventDetail.IsJoined && !eventDetail.CanManage()
+
 	return &eventDetail, nil
 }
 
@@ -509,7 +662,7 @@ func (eventDao EventDao) JoinEventByOwner(eventId int64, ownerId int64, tx *sql.
 }
 
 // This will execute in Transaction, always
-func (eventDao EventDao) JoinEventByParticipant(eventId int64, maxParticipantCount int, participantId int64) error {
+func (eventDao EventDao) JoinEventByParticipant(eventId int64, maxParticipantCount int, participantId int64, tx *sql.Tx) error {
 	query := `
 	INSERT INTO sportgether_schema.event_participant (eventid, participantid)
 	SELECT $1, $2 
@@ -528,7 +681,7 @@ func (eventDao EventDao) JoinEventByParticipant(eventId int64, maxParticipantCou
 
 	// var eventIdOutput int64
 	// var participantIdOutput int64
-	res, err := eventDao.db.ExecContext(ctx, query, args...)
+	res, err := tx.ExecContext(ctx, query, args...)
 	if err != nil {
 		return err
 	}
@@ -549,6 +702,41 @@ func (eventDao EventDao) JoinEventByParticipant(eventId int64, maxParticipantCou
 	// }
 }
 
+// GetEventParticipantIds returns the participants of the event, including the host. tx can be nil.
+func (eventDao EventDao) GetEventParticipantIds(eventId int64, tx *sql.Tx) ([]int64, error) {
+	query := `SELECT ep.participantid FROM sportgether_schema.event_participant ep WHERE ep.eventid = $1`
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var rows *sql.Rows
+	var err error
+	if tx != nil {
+		rows, err = tx.QueryContext(ctx, query, eventId)
+	} else {
+		rows, err = eventDao.db.QueryContext(ctx, query, eventId)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	participantIds := []int64{}
+	for rows.Next() {
+		var participantId int64
+		err = rows.Scan(&participantId)
+		if err != nil {
+			return nil, err
+		}
+		participantIds = append(participantIds, participantId)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return participantIds, nil
+}
+
 func (eventDao EventDao) CheckEventParticipantCount(eventId int64, tx *sql.Tx) (int, error) {
 	query := `
 		SELECT COUNT(*) FROM sportgether_schema.event_participant ep where ep.eventId = $1
@@ -567,13 +755,21 @@ func (eventDao EventDao) CheckEventParticipantCount(eventId int64, tx *sql.Tx) (
 }
 
 func (eventDao EventDao) QuitEvent(eventId int64, userId int64) error {
+	// A co-host who quits loses the co-host right as well.
 	query := `
-		DELETE FROM sportgether_schema.event_participant ep where ep.participantid = $1 and ep.eventid = $2
+		WITH removed_co_host AS (
+			DELETE FROM sportgether_schema.event_co_host ch WHERE ch.co_host_id = $1 and ch.event_id = $2
+		), removed AS (
+			DELETE FROM sportgether_schema.event_participant ep where ep.participantid = $1 and ep.eventid = $2
+			RETURNING ep.eventid, ep.participantid
+		)
+		INSERT INTO sportgether_schema.event_participant_removal (event_id, participant_id, removed_by, removal_type)
+		SELECT removed.eventid, removed.participantid, removed.participantid, $3 FROM removed
 `
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
-	_, err := eventDao.db.ExecContext(ctx, query, userId, eventId)
+	_, err := eventDao.db.ExecContext(ctx, query, userId, eventId, ParticipantQuitRemoval)
 	if err != nil {
 		return err
 	}
@@ -581,16 +777,16 @@ func (eventDao EventDao) QuitEvent(eventId int64, userId int64) error {
 	return nil
 }
 
-func (eventDao EventDao) DeleteEvent(eventId int64) error {
+func (eventDao EventDao) DeleteEvent(eventId int64, tx *sql.Tx) error {
 	query := `
 		UPDATE sportgether_schema.events
-		    SET deleted = $1
+		    SET deleted = $1, version = version + 1
 		WHERE id = $2
 `
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
-	_, err := eventDao.db.ExecContext(ctx, query, true, eventId)
+	_, err := tx.ExecContext(ctx, query, true, eventId)
 	if err != nil {
 		return err
 	}
@@ -665,6 +861,25 @@ func (eventDao EventDao) GetUserJoinedEventCount(userId int64) (int, error) {
 	return count, err
 }
 
+// CountUpcomingEvents counts the events not started yet, across all the users.
+func (eventDao EventDao) CountUpcomingEvents() (int, error) {
+	query := `
+	SELECT count(*) FROM sportgether_schema.events e
+	WHERE e.start_time > $1 AND e.deleted IS FALSE
+`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var count int
+	err := eventDao.db.QueryRowContext(ctx, query, time.Now()).Scan(&count)
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
 func (eventDao EventDao) GetMutualJoinedEventCount(userId int64, participantId int64) (int, error) {
 	query := `
 	SELECT  count(*)
@@ -703,127 +918,209 @@ func (eventDao EventDao) InitialiseUserHostingConfig(userId int64) error {
 	return nil
 }
 
+type HostingTier = remote_config.HostingTier
+
 type UserHostingConfigInfo struct {
-	HostCount    int    `json:"hostCount"`
-	MaxHostCount int    `json:"maxHostCount"`
+	Tier         HostingTier `json:"tier"`
+	HostCount    int         `json:"hostCount"`
+	MaxHostCount int         `json:"maxHostCount"`
+	WindowInMin  int         `json:"windowInMin"`
+	// NextSlotAt is when an event hosted within the window leaves it and frees up a slot, i.e. the one the user waits
+	// for when the quota is used up. It is nil when nothing is hosted within the window.
+	NextSlotAt *time.Time `json:"nextSlotAt"`
+	// RefreshInMin is the minutes until NextSlotAt.
 	RefreshInMin int    `json:"refreshInMin"`
+	IsOverridden bool   `json:"isOverridden"`
 	Status       string `json:"status"`
 }
 
-type HostingConfigurator struct {
-	MaxCount      int `json:"maxCount"`
-	RefreshPeriod int `json:"refreshPeriod"`
+func (config *UserHostingConfigInfo) CanHost() bool {
+	return config.HostCount < config.MaxHostCount
 }
 
-type UpdateHostingConfigInput struct {
-	userId                int64
-	needRefreshTime       bool
-	needUpdateHostCount   bool
-	currentUserConfigInfo UserHostingConfigInfo
-	configurator          HostingConfigurator
+// HostingQuotaAssignment is the tier the admins assign to the user, and the max count overriding the one of the tier.
+type HostingQuotaAssignment struct {
+	UserId           int64       `json:"userId"`
+	Tier             HostingTier `json:"tier"`
+	MaxCountOverride *int        `json:"maxCountOverride"`
+	// OverrideExpireAt is nil when the override never expires.
+	OverrideExpireAt *time.Time `json:"overrideExpireAt"`
+	OverrideNote     *string    `json:"overrideNote"`
 }
 
-// Update and return result if needed, else just return result, as though calling get
-func (eventDao EventDao) UpdateUserHostingConfig(userId int64, updateAfterUserHosted bool, tx *sql.Tx) (*UserHostingConfigInfo, error) {
-	var configurator HostingConfigurator = HostingConfigurator{}
-	err := utils.ReadJsonFromFile("./data/hosting_config.json", &configurator)
-	if err != nil {
-		return nil, err
-	}
+func (assignment *HostingQuotaAssignment) isOverridden(now time.Time) bool {
+	return assignment.MaxCountOverride != nil && (assignment.OverrideExpireAt == nil || assignment.OverrideExpireAt.After(now))
+}
 
-	queryResult := struct {
-		hostCount         int
-		last_refresh_time time.Time
-	}{}
-	currentConfigQuery := `SELECT hc.host_count, hc.last_refresh_time FROM sportgether_schema.user_hosting_config hc WHERE hc.user_id = $1`
+// GetHostingQuotaAssignment returns the assignment of the user, which is the NEW tier for the user never assigned.
+func (eventDao EventDao) GetHostingQuotaAssignment(userId int64) (*HostingQuotaAssignment, error) {
+	query := `
+	SELECT hc.tier, hc.max_count_override, hc.override_expire_at, hc.override_note
+	FROM sportgether_schema.user_hosting_config hc
+	WHERE hc.user_id = $1
+`
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
-	err = eventDao.db.QueryRowContext(ctx, currentConfigQuery, userId).Scan(&queryResult.hostCount, &queryResult.last_refresh_time)
+	assignment := &HostingQuotaAssignment{UserId: userId}
+	err := eventDao.db.QueryRowContext(ctx, query, userId).Scan(
+		&assignment.Tier,
+		&assignment.MaxCountOverride,
+		&assignment.OverrideExpireAt,
+		&assignment.OverrideNote,
+	)
 	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			assignment.Tier = remote_config.NewUserTier
+			return assignment, nil
+		}
 		return nil, err
 	}
 
-	needRefresh := time.Since(queryResult.last_refresh_time) >= time.Duration(configurator.RefreshPeriod*int(time.Minute))
+	return assignment, nil
+}
 
-	currentUserConfigInfo := UserHostingConfigInfo{
-		MaxHostCount: configurator.MaxCount,
-		HostCount:    queryResult.hostCount,
+func (eventDao EventDao) UpdateHostingQuotaAssignment(assignment *HostingQuotaAssignment) error {
+	query := `
+	INSERT INTO sportgether_schema.user_hosting_config (user_id, tier, max_count_override, override_expire_at, override_note)
+	VALUES ($1, $2, $3, $4, $5)
+	ON CONFLICT (user_id) DO UPDATE
+	SET tier = EXCLUDED.tier,
+		max_count_override = EXCLUDED.max_count_override,
+		override_expire_at = EXCLUDED.override_expire_at,
+		override_note = EXCLUDED.override_note,
+		updated_at = NOW()
+`
+	args := []any{
+		assignment.UserId,
+		assignment.Tier,
+		assignment.MaxCountOverride,
+		assignment.OverrideExpireAt,
+		assignment.OverrideNote,
 	}
-	if needRefresh || updateAfterUserHosted {
-		input := UpdateHostingConfigInput{
-			userId:                userId,
-			needRefreshTime:       needRefresh,
-			needUpdateHostCount:   updateAfterUserHosted,
-			currentUserConfigInfo: currentUserConfigInfo,
-			configurator:          configurator,
-		}
-		userHostingConfig, err := eventDao.updateHostingConfig(input, tx)
-		if err != nil {
-			return nil, err
-		}
-		return userHostingConfig, nil
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := eventDao.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
 	}
 
-	currentUserConfigInfo.RefreshInMin = int(queryResult.last_refresh_time.Add(time.Duration(configurator.RefreshPeriod * int(time.Minute))).Sub(time.Now()).Minutes())
-	currentUserConfigInfo.appendStatus(configurator)
-	return &currentUserConfigInfo, nil
+	return nil
 }
 
-func (eventDao EventDao) updateHostingConfig(input UpdateHostingConfigInput, tx *sql.Tx) (*UserHostingConfigInfo, error) {
-	values := []any{}
-	setQuery := "SET"
-
-	if input.needRefreshTime {
-		setQuery += fmt.Sprintf(" last_refresh_time=$%d, host_count=$%d", len(values)+1, len(values)+2)
-		values = append(values, time.Now(), 0)
-	}
-	if input.needUpdateHostCount {
-		if input.needRefreshTime {
-			values[len(values)-1] = 1
-		} else {
-			setQuery += fmt.Sprintf(" host_count=$%d", len(values)+1)
-			values = append(values, input.currentUserConfigInfo.HostCount+1)
-		}
+// GetUserHostingConfig counts the events the user hosted within the rolling window of the tier of the user. The
+// cancelled events are counted as well, else hosting and cancelling would get around the quota, while the transferred
+// events go to the quota of the new host. The tournament match events are not counted.
+//
+// Within the tx, the hosting config of the user is locked until the tx ends, so that the concurrent hosting of the
+// same user is checked one after another.
+func (eventDao EventDao) GetUserHostingConfig(userId int64, tx *sql.Tx) (*UserHostingConfigInfo, error) {
+	queryRow := eventDao.db.QueryRowContext
+	query := eventDao.db.QueryContext
+	if tx != nil {
+		queryRow = tx.QueryRowContext
+		query = tx.QueryContext
 	}
 
-	updateQuery := fmt.Sprintf(`
-	UPDATE sportgether_schema.user_hosting_config
-	%s
-	WHERE user_id = $%d
-	RETURNING host_count, last_refresh_time
-`, setQuery, len(values)+1)
-	values = append(values, input.userId)
-
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
-	var res = UserHostingConfigInfo{
-		MaxHostCount: input.configurator.MaxCount,
-	}
-	var lastRefreshTime time.Time
-
 	if tx != nil {
-		err := tx.QueryRowContext(ctx, updateQuery, values...).Scan(&res.HostCount, &lastRefreshTime)
+		lockQuery := `
+		INSERT INTO sportgether_schema.user_hosting_config (user_id)
+		VALUES ($1)
+		ON CONFLICT (user_id) DO UPDATE SET user_id = EXCLUDED.user_id
+		RETURNING user_id
+	`
+		err := tx.QueryRowContext(ctx, lockQuery, userId).Scan(&userId)
 		if err != nil {
 			return nil, err
 		}
-	} else {
-		err := eventDao.db.QueryRowContext(ctx, updateQuery, values...).Scan(&res.HostCount, &lastRefreshTime)
+	}
+
+	assignmentQuery := `
+	SELECT
+	    COALESCE(hc.tier, $2),
+	    hc.max_count_override,
+	    hc.override_expire_at,
+	    EXISTS (SELECT 1 FROM sportgether_schema.club_member cm WHERE cm.user_id = u.user_id AND cm.role IN ($3, $4))
+	FROM (SELECT $1::bigint AS user_id) u
+	LEFT JOIN sportgether_schema.user_hosting_config hc ON hc.user_id = u.user_id
+`
+	assignment := HostingQuotaAssignment{UserId: userId}
+	var isClubManager bool
+	err := queryRow(ctx, assignmentQuery, userId, remote_config.NewUserTier, ClubOwner, ClubAdmin).Scan(
+		&assignment.Tier,
+		&assignment.MaxCountOverride,
+		&assignment.OverrideExpireAt,
+		&isClubManager,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	tiers := eventDao.remoteConfig.Current().Hosting.Value.Tiers
+	tier := assignment.Tier
+	if isClubManager && tiers[remote_config.ClubAdminTier].MaxCount > tiers[tier].MaxCount {
+		tier = remote_config.ClubAdminTier
+	}
+	quota := tiers[tier]
+	now := time.Now()
+	window := time.Duration(quota.WindowInMin) * time.Minute
+
+	hostedQuery := `
+	SELECT e.created_at
+	FROM sportgether_schema.events e
+	WHERE e.host_id = $1 AND e.created_at > $2
+	AND NOT EXISTS (SELECT 1 FROM sportgether_schema.tournament_match m WHERE m.event_id = e.id)
+	ORDER BY e.created_at
+`
+	rows, err := query(ctx, hostedQuery, userId, now.Add(-window))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	hostedTimes := []time.Time{}
+	for rows.Next() {
+		var createdAt time.Time
+		err = rows.Scan(&createdAt)
 		if err != nil {
 			return nil, err
 		}
+		hostedTimes = append(hostedTimes, createdAt)
 	}
 
-	res.RefreshInMin = int(lastRefreshTime.Add(time.Duration(input.configurator.RefreshPeriod * int(time.Minute))).Sub(time.Now()).Minutes())
-	res.appendStatus(input.configurator)
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
 
-	return &res, nil
+	config := &UserHostingConfigInfo{
+		Tier:         tier,
+		HostCount:    len(hostedTimes),
+		MaxHostCount: quota.MaxCount,
+		WindowInMin:  quota.WindowInMin,
+		IsOverridden: assignment.isOverridden(now),
+	}
+	if config.IsOverridden {
+		config.MaxHostCount = *assignment.MaxCountOverride
+	}
+	if len(hostedTimes) > 0 && config.MaxHostCount > 0 {
+		// Another slot is needed when the quota is used up, which takes the events over the max count to leave first.
+		nextSlotAt := hostedTimes[max(config.HostCount-config.MaxHostCount, 0)].Add(window)
+		config.NextSlotAt = &nextSlotAt
+		config.RefreshInMin = int(math.Ceil(nextSlotAt.Sub(now).Minutes()))
+	}
+	config.appendStatus()
+
+	return config, nil
 }
 
-func (config *UserHostingConfigInfo) appendStatus(configurator HostingConfigurator) {
+func (config *UserHostingConfigInfo) appendStatus() {
 	status := ""
-	if config.HostCount >= configurator.MaxCount {
+	if !config.CanHost() {
 		status = "INVALID"
 	} else {
 		status = "VALID"
@@ -0,0 +1,161 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sportgether/constants"
+	"strings"
+	"time"
+)
+
+func (eventDao EventDao) AddCoHost(eventId int64, coHostId int64) error {
+	query := `
+	INSERT INTO sportgether_schema.event_co_host (event_id, co_host_id)
+	VALUES ($1, $2)
+	ON CONFLICT (event_id, co_host_id) DO NOTHING
+`
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := eventDao.db.ExecContext(ctx, query, eventId, coHostId)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (eventDao EventDao) RemoveCoHost(eventId int64, coHostId int64) error {
+	query := `
+	DELETE FROM sportgether_schema.event_co_host ch WHERE ch.event_id = $1 AND ch.co_host_id = $2
+`
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := eventDao.db.ExecContext(ctx, query, eventId, coHostId)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// TransferHost hands the event over to newHostId, who must already be a participant.
+// Only upcoming events which are not cancelled can be transferred.
+func (eventDao EventDao) TransferHost(eventId int64, currentHostId int64, newHostId int64, tx *sql.Tx) error {
+	query := `
+	UPDATE sportgether_schema.events e
+	SET host_id = $1, version = version + 1
+	WHERE e.id = $2 AND e.host_id = $3 AND e.deleted IS FALSE AND e.start_time > $4
+	AND EXISTS (
+		SELECT 1 FROM sportgether_schema.event_participant ep WHERE ep.eventid = e.id AND ep.participantid = $1
+	)
+`
+	args := []any{
+		newHostId,
+		eventId,
+		currentHostId,
+		time.Now(),
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	res, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+
+	if rowCount, err := res.RowsAffected(); err != nil {
+		return err
+	} else if rowCount == 0 {
+		return constants.EventNotEditableError
+	}
+
+	// The new host does not need to stay as co-host.
+	query = `
+	DELETE FROM sportgether_schema.event_co_host ch WHERE ch.event_id = $1 AND ch.co_host_id = $2
+`
+	ctx, cancel1 := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel1()
+
+	_, err = tx.ExecContext(ctx, query, eventId, newHostId)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (eventDao EventDao) getEventCoHosts(eventIds []int64) (map[int64][]EventParticipantDetail, error) {
+	coHosts := make(map[int64][]EventParticipantDetail)
+	if len(eventIds) == 0 {
+		return coHosts, nil
+	}
+
+	values := make([]any, 0, len(eventIds))
+	placeHolders := make([]string, 0, len(eventIds))
+	for _, eventId := range eventIds {
+		placeHolders = append(placeHolders, fmt.Sprintf("$%d", len(values)+1))
+		values = append(values, eventId)
+	}
+
+	query := fmt.Sprintf(`
+	SELECT
+	    ch.event_id,
+	    u.id,
+	    u.username,
+		up.preferred_name,
+	    up.profile_icon_url
+	FROM sportgether_schema.event_co_host ch
+	INNER JOIN sportgether_schema.users u on u.id = ch.co_host_id
+	LEFT JOIN sportgether_schema.user_profile up on u.id = up.user_id
+	WHERE ch.event_id IN (%s)
+	ORDER BY ch.created_at
+`, strings.Join(placeHolders, ","))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := eventDao.db.QueryContext(ctx, query, values...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var eventId int64
+		var preferredName *string
+		coHost := EventParticipantDetail{}
+		err = rows.Scan(
+			&eventId,
+			&coHost.ParticipantId,
+			&coHost.ParticipantUsername,
+			&preferredName,
+			&coHost.ProfileIconUrl,
+		)
+		if err != nil {
+			return nil, err
+		}
+		if preferredName != nil {
+			coHost.ParticipantPreferredName = *preferredName
+		}
+		coHosts[eventId] = append(coHosts[eventId], coHost)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return coHosts, nil
+}
+
+func isCoHost(coHosts []EventParticipantDetail, userId int64) bool {
+	for _, coHost := range coHosts {
+		if coHost.ParticipantId == userId {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,134 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"sportgether/constants"
+	"time"
+)
+
+const (
+	ParticipantQuitRemoval   = "QUIT"
+	ParticipantKickedRemoval = "KICKED"
+)
+
+type EventBanScope string
+
+var (
+	NoBan        = EventBanScope("NONE")
+	EventOnlyBan = EventBanScope("EVENT")
+	HostWideBan  = EventBanScope("HOST")
+)
+
+func (scope EventBanScope) IsValid() bool {
+	return scope == NoBan || scope == EventOnlyBan || scope == HostWideBan
+}
+
+type EventBan struct {
+	ID           int64     `json:"id"`
+	HostId       int64     `json:"hostId"`
+	BannedUserId int64     `json:"bannedUserId"`
+	EventId      *int64    `json:"eventId"`
+	Reason       *string   `json:"reason"`
+	CreatedAt    time.Time `json:"createdAt"`
+}
+
+// KickParticipant removes the participant (and the co-host right, if any) from the event, and keeps a record of it.
+// This is separated from QuitEvent so that being kicked does not count as the user quitting.
+func (eventDao EventDao) KickParticipant(eventId int64, participantId int64, removedBy int64, reason *string, tx *sql.Tx) error {
+	query := `
+	WITH removed_co_host AS (
+		DELETE FROM sportgether_schema.event_co_host ch WHERE ch.co_host_id = $2 AND ch.event_id = $1
+	), removed AS (
+		DELETE FROM sportgether_schema.event_participant ep WHERE ep.participantid = $2 AND ep.eventid = $1
+		RETURNING ep.eventid, ep.participantid
+	)
+	INSERT INTO sportgether_schema.event_participant_removal (event_id, participant_id, removed_by, removal_type, reason)
+	SELECT removed.eventid, removed.participantid, $3, $4, $5 FROM removed
+`
+	args := []any{
+		eventId,
+		participantId,
+		removedBy,
+		ParticipantKickedRemoval,
+		reason,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	res, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+
+	if rowCount, err := res.RowsAffected(); err != nil {
+		return err
+	} else if rowCount == 0 {
+		return constants.NotEventParticipantError
+	}
+
+	return nil
+}
+
+func (eventDao EventDao) BanUser(ban *EventBan, tx *sql.Tx) error {
+	query := `
+	INSERT INTO sportgether_schema.event_ban (host_id, banned_user_id, event_id, reason)
+	VALUES ($1, $2, $3, $4)
+	RETURNING id, created_at
+`
+	args := []any{
+		ban.HostId,
+		ban.BannedUserId,
+		ban.EventId,
+		ban.Reason,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := tx.QueryRowContext(ctx, query, args...).Scan(&ban.ID, &ban.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// IsUserBannedFromEvent checks both the ban on this event and the ban on all events of its host.
+func (eventDao EventDao) IsUserBannedFromEvent(eventId int64, userId int64) (bool, error) {
+	query := `
+	SELECT EXISTS (
+		SELECT 1 FROM sportgether_schema.event_ban b
+		INNER JOIN sportgether_schema.events e ON e.id = $1
+		WHERE b.banned_user_id = $2 AND (b.event_id = e.id OR (b.event_id IS NULL AND b.host_id = e.host_id))
+	)
+`
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var banned bool
+	err := eventDao.db.QueryRowContext(ctx, query, eventId, userId).Scan(&banned)
+	if err != nil {
+		return false, err
+	}
+
+	return banned, nil
+}
+
+func (eventDao EventDao) GetUserQuitEventCount(userId int64) (int, error) {
+	query := `
+	SELECT count(*) FROM sportgether_schema.event_participant_removal r
+	WHERE r.participant_id = $1 AND r.removal_type = $2
+`
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var count int
+	err := eventDao.db.QueryRowContext(ctx, query, userId, ParticipantQuitRemoval).Scan(&count)
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
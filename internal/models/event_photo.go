@@ -0,0 +1,171 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+const (
+	EventPhotoVisible = "VISIBLE"
+	EventPhotoHidden  = "HIDDEN"
+)
+
+type EventPhotoDao struct {
+	db *sql.DB
+}
+
+type EventPhoto struct {
+	ID                    int64     `json:"id"`
+	EventId               int64     `json:"eventId"`
+	UploaderId            int64     `json:"uploaderId"`
+	UploaderPreferredName *string   `json:"uploaderPreferredName"`
+	PublicId              string    `json:"-"`
+	Url                   string    `json:"url"`
+	Status                string    `json:"status"`
+	CreatedAt             time.Time `json:"createdAt"`
+}
+
+func (dao EventPhotoDao) InsertEventPhoto(photo *EventPhoto) error {
+	query := `
+	INSERT INTO sportgether_schema.event_photo (event_id, uploader_id, public_id, url)
+	VALUES ($1, $2, $3, $4)
+	RETURNING id, status, created_at
+`
+	args := []any{
+		photo.EventId,
+		photo.UploaderId,
+		photo.PublicId,
+		photo.Url,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := dao.db.QueryRowContext(ctx, query, args...).Scan(&photo.ID, &photo.Status, &photo.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetEventPhotos returns the hidden photos as well only when includeHidden is true, which is meant for the host.
+func (dao EventPhotoDao) GetEventPhotos(eventId int64, includeHidden bool, pageNumber int64, pageSize int64) ([]*EventPhoto, error) {
+	query := `
+	SELECT
+	    p.id,
+	    p.event_id,
+	    p.uploader_id,
+	    up.preferred_name,
+	    p.url,
+	    p.status,
+	    p.created_at
+	FROM sportgether_schema.event_photo p
+	LEFT JOIN sportgether_schema.user_profile up ON up.user_id = p.uploader_id
+	WHERE p.event_id = $1 AND (p.status = $2 OR $3)
+	ORDER BY p.created_at DESC, p.id DESC LIMIT $4 OFFSET $5
+`
+	args := []any{
+		eventId,
+		EventPhotoVisible,
+		includeHidden,
+		pageSize,
+		(pageNumber - 1) * pageSize,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := dao.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	photos := []*EventPhoto{}
+	for rows.Next() {
+		photo := &EventPhoto{}
+		err = rows.Scan(
+			&photo.ID,
+			&photo.EventId,
+			&photo.UploaderId,
+			&photo.UploaderPreferredName,
+			&photo.Url,
+			&photo.Status,
+			&photo.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		photos = append(photos, photo)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return photos, nil
+}
+
+func (dao EventPhotoDao) GetEventPhoto(eventId int64, photoId int64) (*EventPhoto, error) {
+	query := `
+	SELECT p.id, p.event_id, p.uploader_id, p.public_id, p.url, p.status, p.created_at
+	FROM sportgether_schema.event_photo p
+	WHERE p.id = $1 AND p.event_id = $2
+`
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	photo := &EventPhoto{}
+	err := dao.db.QueryRowContext(ctx, query, photoId, eventId).Scan(
+		&photo.ID,
+		&photo.EventId,
+		&photo.UploaderId,
+		&photo.PublicId,
+		&photo.Url,
+		&photo.Status,
+		&photo.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return photo, nil
+}
+
+func (dao EventPhotoDao) UpdateEventPhotoStatus(photoId int64, status string) error {
+	if status != EventPhotoVisible && status != EventPhotoHidden {
+		return errors.New("unknown event photo status " + status)
+	}
+
+	query := `UPDATE sportgether_schema.event_photo SET status = $1 WHERE id = $2`
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := dao.db.ExecContext(ctx, query, status, photoId)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (dao EventPhotoDao) DeleteEventPhoto(photoId int64, tx *sql.Tx) error {
+	query := `DELETE FROM sportgether_schema.event_photo WHERE id = $1`
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var err error
+	if tx != nil {
+		_, err = tx.ExecContext(ctx, query, photoId)
+	} else {
+		_, err = dao.db.ExecContext(ctx, query, photoId)
+	}
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
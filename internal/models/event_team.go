@@ -0,0 +1,220 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"sportgether/internal/teams"
+	"time"
+)
+
+// DefaultSkillRating is used for the players who have no rating in the sport yet, which is the rating of
+// IntermediateLevel.
+const DefaultSkillRating = 2.0
+
+type EventTeamDraw struct {
+	ID              int64        `json:"id"`
+	EventId         int64        `json:"eventId"`
+	CreatedBy       int64        `json:"createdBy"`
+	TeamCount       int          `json:"teamCount"`
+	BalanceGender   bool         `json:"balanceGender"`
+	KeepTogether    [][2]int64   `json:"keepTogether"`
+	ExcludedUserIds []int64      `json:"excludedUserIds"`
+	PublishedAt     *time.Time   `json:"publishedAt"`
+	CreatedAt       time.Time    `json:"createdAt"`
+	Teams           []*EventTeam `json:"teams"`
+}
+
+type EventTeam struct {
+	TeamIndex int                `json:"teamIndex"`
+	Rating    float64            `json:"rating"`
+	Members   []*EventTeamMember `json:"members"`
+}
+
+type EventTeamMember struct {
+	EventParticipantDetail
+	SkillRating float64 `json:"skillRating"`
+}
+
+// GetEventPlayers returns the participants with their rating in the sport of the event.
+func (eventDao EventDao) GetEventPlayers(eventId int64, eventType string) ([]teams.Player, error) {
+	query := `
+	SELECT ep.participantid, COALESCE(up.gender, ''), COALESCE(ss.skill_rating, $3)
+	FROM sportgether_schema.event_participant ep
+	LEFT JOIN sportgether_schema.user_profile up ON up.user_id = ep.participantid
+	LEFT JOIN sportgether_schema.user_sport_skill ss ON ss.user_id = ep.participantid AND ss.event_type = $2
+	WHERE ep.eventid = $1
+	ORDER BY ep.participantid
+`
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := eventDao.db.QueryContext(ctx, query, eventId, eventType, DefaultSkillRating)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	players := []teams.Player{}
+	for rows.Next() {
+		player := teams.Player{}
+		err = rows.Scan(&player.UserId, &player.Gender, &player.Rating)
+		if err != nil {
+			return nil, err
+		}
+		players = append(players, player)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return players, nil
+}
+
+func (eventDao EventDao) InsertTeamDraw(draw *EventTeamDraw, generated []teams.Team, tx *sql.Tx) error {
+	keepTogether, err := json.Marshal(draw.KeepTogether)
+	if err != nil {
+		return err
+	}
+	excludedUserIds, err := json.Marshal(draw.ExcludedUserIds)
+	if err != nil {
+		return err
+	}
+
+	query := `
+	INSERT INTO sportgether_schema.event_team_draw (event_id, created_by, team_count, balance_gender, keep_together, excluded_user_ids)
+	VALUES ($1, $2, $3, $4, $5, $6)
+	RETURNING id, created_at
+`
+	args := []any{
+		draw.EventId,
+		draw.CreatedBy,
+		draw.TeamCount,
+		draw.BalanceGender,
+		string(keepTogether),
+		string(excludedUserIds),
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err = tx.QueryRowContext(ctx, query, args...).Scan(&draw.ID, &draw.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	for teamIndex, team := range generated {
+		for _, player := range team.Players {
+			query = `
+			INSERT INTO sportgether_schema.event_team_member (draw_id, team_index, user_id, skill_rating)
+			VALUES ($1, $2, $3, $4)
+`
+			_, err = tx.ExecContext(ctx, query, draw.ID, teamIndex, player.UserId, player.Rating)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// GetLatestTeamDraw returns the latest teams of the event, or only the latest published one when publishedOnly is true.
+func (eventDao EventDao) GetLatestTeamDraw(eventId int64, publishedOnly bool) (*EventTeamDraw, error) {
+	query := `
+	SELECT id, event_id, created_by, team_count, balance_gender, keep_together, excluded_user_ids, published_at, created_at
+	FROM sportgether_schema.event_team_draw
+	WHERE event_id = $1 AND (published_at IS NOT NULL OR NOT $2)
+	ORDER BY id DESC LIMIT 1
+`
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	draw := &EventTeamDraw{}
+	var keepTogether, excludedUserIds []byte
+	err := eventDao.db.QueryRowContext(ctx, query, eventId, publishedOnly).Scan(
+		&draw.ID,
+		&draw.EventId,
+		&draw.CreatedBy,
+		&draw.TeamCount,
+		&draw.BalanceGender,
+		&keepTogether,
+		&excludedUserIds,
+		&draw.PublishedAt,
+		&draw.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	err = json.Unmarshal(keepTogether, &draw.KeepTogether)
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(excludedUserIds, &draw.ExcludedUserIds)
+	if err != nil {
+		return nil, err
+	}
+
+	query = `
+	SELECT tm.team_index, u.id, u.username, COALESCE(up.preferred_name, ''), up.profile_icon_url, tm.skill_rating
+	FROM sportgether_schema.event_team_member tm
+	INNER JOIN sportgether_schema.users u ON u.id = tm.user_id
+	LEFT JOIN sportgether_schema.user_profile up ON up.user_id = tm.user_id
+	WHERE tm.draw_id = $1
+	ORDER BY tm.team_index, tm.skill_rating DESC, u.id
+`
+	ctx1, cancel1 := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel1()
+
+	rows, err := eventDao.db.QueryContext(ctx1, query, draw.ID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	draw.Teams = make([]*EventTeam, 0, draw.TeamCount)
+	for index := 0; index < draw.TeamCount; index++ {
+		draw.Teams = append(draw.Teams, &EventTeam{TeamIndex: index, Members: []*EventTeamMember{}})
+	}
+	for rows.Next() {
+		var teamIndex int
+		member := &EventTeamMember{}
+		err = rows.Scan(
+			&teamIndex,
+			&member.ParticipantId,
+			&member.ParticipantUsername,
+			&member.ParticipantPreferredName,
+			&member.ProfileIconUrl,
+			&member.SkillRating,
+		)
+		if err != nil {
+			return nil, err
+		}
+		if teamIndex < 0 || teamIndex >= len(draw.Teams) {
+			continue
+		}
+		draw.Teams[teamIndex].Members = append(draw.Teams[teamIndex].Members, member)
+		draw.Teams[teamIndex].Rating += member.SkillRating
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return draw, nil
+}
+
+func (eventDao EventDao) PublishTeamDraw(drawId int64) error {
+	query := `UPDATE sportgether_schema.event_team_draw SET published_at = $1 WHERE id = $2`
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := eventDao.db.ExecContext(ctx, query, time.Now(), drawId)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,114 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+const (
+	mediaCleanupPending = "PENDING"
+	mediaCleanupFailed  = "FAILED"
+)
+
+type MediaCleanupDao struct {
+	db *sql.DB
+}
+
+type MediaCleanupTask struct {
+	ID       int64
+	PublicId string
+	Attempts int
+}
+
+// EnqueueMediaCleanup schedules the media to be deleted from the storage. Pass the transaction which stops
+// referencing the media, so that the media is only deleted once the change is committed.
+func (dao MediaCleanupDao) EnqueueMediaCleanup(publicId string, tx *sql.Tx) error {
+	query := `INSERT INTO sportgether_schema.media_cleanup_task (public_id) VALUES ($1)`
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var err error
+	if tx != nil {
+		_, err = tx.ExecContext(ctx, query, publicId)
+	} else {
+		_, err = dao.db.ExecContext(ctx, query, publicId)
+	}
+
+	return err
+}
+
+// ClaimMediaCleanupTasks leases the due tasks for leaseDuration, so that other instances skip them meanwhile.
+func (dao MediaCleanupDao) ClaimMediaCleanupTasks(now time.Time, limit int, leaseDuration time.Duration) ([]*MediaCleanupTask, error) {
+	query := `
+	UPDATE sportgether_schema.media_cleanup_task t
+	SET next_attempt_at = $1
+	WHERE t.id IN (
+		SELECT id FROM sportgether_schema.media_cleanup_task
+		WHERE status = $2 AND next_attempt_at <= $3
+		ORDER BY next_attempt_at
+		LIMIT $4
+		FOR UPDATE SKIP LOCKED
+	)
+	RETURNING t.id, t.public_id, t.attempts
+`
+	args := []any{
+		now.Add(leaseDuration),
+		mediaCleanupPending,
+		now,
+		limit,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := dao.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tasks := []*MediaCleanupTask{}
+	for rows.Next() {
+		task := &MediaCleanupTask{}
+		err = rows.Scan(&task.ID, &task.PublicId, &task.Attempts)
+		if err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, task)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return tasks, nil
+}
+
+func (dao MediaCleanupDao) CompleteMediaCleanupTask(taskId int64) error {
+	query := `DELETE FROM sportgether_schema.media_cleanup_task WHERE id = $1`
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := dao.db.ExecContext(ctx, query, taskId)
+	return err
+}
+
+// RetryMediaCleanupTask schedules the task again at nextAttemptAt, or gives up when giveUp is true.
+func (dao MediaCleanupDao) RetryMediaCleanupTask(taskId int64, lastError string, nextAttemptAt time.Time, giveUp bool) error {
+	status := mediaCleanupPending
+	if giveUp {
+		status = mediaCleanupFailed
+	}
+
+	query := `
+	UPDATE sportgether_schema.media_cleanup_task
+	SET attempts = attempts + 1, last_error = $1, next_attempt_at = $2, status = $3
+	WHERE id = $4
+`
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := dao.db.ExecContext(ctx, query, lastError, nextAttemptAt, status, taskId)
+	return err
+}
@@ -0,0 +1,218 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"sportgether/internal/i18n"
+	"time"
+)
+
+const (
+	notificationPending = "PENDING"
+	notificationSent    = "SENT"
+	notificationDead    = "DEAD"
+)
+
+type NotificationKind string
+
+var (
+	EventCreatedNotification             = NotificationKind("EVENT_CREATED")
+	EventUpdatedNotification             = NotificationKind("EVENT_UPDATED")
+	EventJoinedNotification              = NotificationKind("EVENT_JOINED")
+	EventCancelledNotification           = NotificationKind("EVENT_CANCELLED")
+	EventHostTransferredNotification     = NotificationKind("EVENT_HOST_TRANSFERRED")
+	EventKickedNotification              = NotificationKind("EVENT_KICKED")
+	EventReminderNotification            = NotificationKind("EVENT_REMINDER")
+	EventTeamsPublishedNotification      = NotificationKind("EVENT_TEAMS_PUBLISHED")
+	ClubJoinReviewedNotification         = NotificationKind("CLUB_JOIN_REVIEWED")
+	TournamentStartedNotification        = NotificationKind("TOURNAMENT_STARTED")
+	TournamentMatchScheduledNotification = NotificationKind("TOURNAMENT_MATCH_SCHEDULED")
+)
+
+type NotificationOutboxDao struct {
+	db *sql.DB
+}
+
+// NotificationMessage is the title and subtitle of the notification, rendered in the locale of each recipient.
+type NotificationMessage struct {
+	Title    i18n.Text `json:"title"`
+	Subtitle i18n.Text `json:"subtitle"`
+}
+
+// Render adds the title and subtitle in the locale to the data. The data is returned as is without the message.
+func (message *NotificationMessage) Render(data map[string]string, locale i18n.Locale) map[string]string {
+	rendered := map[string]string{}
+	for key, value := range data {
+		rendered[key] = value
+	}
+	if message != nil {
+		rendered["title"] = message.Title.Render(locale)
+		rendered["subtitle"] = message.Subtitle.Render(locale)
+	}
+	return rendered
+}
+
+type OutboxNotification struct {
+	ID           int64
+	Kind         NotificationKind
+	Data         map[string]string
+	Message      *NotificationMessage
+	RecipientIds []int64
+	Attempts     int
+}
+
+// EnqueueNotification stores the notification to be sent by the dispatcher, along with the notification in the inbox
+// of each recipient. Pass the transaction of the change which triggers the notification, so that the notification is
+// only sent once the change is committed, and is never lost after that. Nothing is stored when there is no recipient.
+func (dao NotificationOutboxDao) EnqueueNotification(notification *OutboxNotification, tx *sql.Tx) error {
+	if len(notification.RecipientIds) == 0 {
+		return nil
+	}
+
+	data, err := json.Marshal(notification.Data)
+	if err != nil {
+		return err
+	}
+	recipientIds, err := json.Marshal(notification.RecipientIds)
+	if err != nil {
+		return err
+	}
+	var message *string
+	if notification.Message != nil {
+		messageJson, err := json.Marshal(notification.Message)
+		if err != nil {
+			return err
+		}
+		message = new(string)
+		*message = string(messageJson)
+	}
+
+	query := `
+	INSERT INTO sportgether_schema.notification_outbox (kind, data, message, recipient_ids)
+	VALUES ($1, $2, $3, $4)
+	RETURNING id
+`
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err = tx.QueryRowContext(ctx, query, notification.Kind, string(data), message, string(recipientIds)).Scan(&notification.ID)
+	if err != nil {
+		return err
+	}
+
+	query = `
+	INSERT INTO sportgether_schema.user_notification (user_id, outbox_id, kind, data, message)
+	SELECT unnest($1::bigint[]), $2, $3, $4, $5
+`
+	_, err = tx.ExecContext(ctx, query, notification.RecipientIds, notification.ID, notification.Kind, string(data), message)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// ClaimNotifications leases the due notifications for leaseDuration, so that other instances skip them meanwhile.
+func (dao NotificationOutboxDao) ClaimNotifications(now time.Time, limit int, leaseDuration time.Duration) ([]*OutboxNotification, error) {
+	query := `
+	UPDATE sportgether_schema.notification_outbox n
+	SET next_attempt_at = $1
+	WHERE n.id IN (
+		SELECT id FROM sportgether_schema.notification_outbox
+		WHERE status = $2 AND next_attempt_at <= $3
+		ORDER BY next_attempt_at
+		LIMIT $4
+		FOR UPDATE SKIP LOCKED
+	)
+	RETURNING n.id, n.kind, n.data, n.recipient_ids, n.attempts
+`
+	args := []any{
+		now.Add(leaseDuration),
+		notificationPending,
+		now,
+		limit,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := dao.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	notifications := []*OutboxNotification{}
+	for rows.Next() {
+		notification := &OutboxNotification{}
+		var data, recipientIds []byte
+		err = rows.Scan(&notification.ID, &notification.Kind, &data, &recipientIds, &notification.Attempts)
+		if err != nil {
+			return nil, err
+		}
+		err = json.Unmarshal(data, &notification.Data)
+		if err != nil {
+			return nil, err
+		}
+		err = json.Unmarshal(recipientIds, &notification.RecipientIds)
+		if err != nil {
+			return nil, err
+		}
+		notifications = append(notifications, notification)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return notifications, nil
+}
+
+// ExtendNotificationLease leases the claimed notification until leasedUntil, so that a long send is not claimed again
+// by another instance in the middle. It is a no-op when the notification is not pending anymore.
+func (dao NotificationOutboxDao) ExtendNotificationLease(notificationId int64, leasedUntil time.Time) error {
+	query := `
+	UPDATE sportgether_schema.notification_outbox
+	SET next_attempt_at = $1
+	WHERE id = $2 AND status = $3
+`
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := dao.db.ExecContext(ctx, query, leasedUntil, notificationId, notificationPending)
+	return err
+}
+
+// CompleteNotification marks the notification as sent. It is a no-op when the notification is not pending anymore.
+func (dao NotificationOutboxDao) CompleteNotification(notificationId int64) error {
+	query := `
+	UPDATE sportgether_schema.notification_outbox
+	SET status = $1, sent_at = $2
+	WHERE id = $3 AND status = $4
+`
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := dao.db.ExecContext(ctx, query, notificationSent, time.Now(), notificationId, notificationPending)
+	return err
+}
+
+// RetryNotification schedules the notification again at nextAttemptAt, or dead-letters it when giveUp is true.
+func (dao NotificationOutboxDao) RetryNotification(notificationId int64, lastError string, nextAttemptAt time.Time, giveUp bool) error {
+	status := notificationPending
+	if giveUp {
+		status = notificationDead
+	}
+
+	query := `
+	UPDATE sportgether_schema.notification_outbox
+	SET attempts = attempts + 1, last_error = $1, next_attempt_at = $2, status = $3
+	WHERE id = $4 AND status = $5
+`
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := dao.db.ExecContext(ctx, query, lastError, nextAttemptAt, status, notificationId, notificationPending)
+	return err
+}
@@ -0,0 +1,326 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sportgether/internal/i18n"
+	"strings"
+	"time"
+)
+
+// DefaultNotificationTimezone is the timezone of the quiet hours when the user never sets one.
+const DefaultNotificationTimezone = "Asia/Kuala_Lumpur"
+
+type NotificationCategory string
+
+var (
+	JoinNotificationCategory         = NotificationCategory("JOINS")
+	UpdateNotificationCategory       = NotificationCategory("UPDATES")
+	CancellationNotificationCategory = NotificationCategory("CANCELLATIONS")
+	ReminderNotificationCategory     = NotificationCategory("REMINDERS")
+	ChatNotificationCategory         = NotificationCategory("CHAT")
+	MarketingNotificationCategory    = NotificationCategory("MARKETING")
+)
+
+// Category is the category of the notification kind which the user can turn off.
+func (kind NotificationKind) Category() NotificationCategory {
+	switch kind {
+	case EventJoinedNotification:
+		return JoinNotificationCategory
+	case EventCancelledNotification, EventKickedNotification:
+		return CancellationNotificationCategory
+	case EventReminderNotification:
+		return ReminderNotificationCategory
+	default:
+		return UpdateNotificationCategory
+	}
+}
+
+type NotificationSettingDao struct {
+	db *sql.DB
+}
+
+type NotificationSetting struct {
+	EventReminderEnabled bool `json:"eventReminderEnabled"`
+	JoinEnabled          bool `json:"joinEnabled"`
+	UpdateEnabled        bool `json:"updateEnabled"`
+	CancellationEnabled  bool `json:"cancellationEnabled"`
+	ChatEnabled          bool `json:"chatEnabled"`
+	MarketingEnabled     bool `json:"marketingEnabled"`
+	QuietHoursEnabled    bool `json:"quietHoursEnabled"`
+	// The minutes of the day in the timezone, the quiet hours go past midnight when the start is after the end.
+	QuietHoursStartInMin int    `json:"quietHoursStartInMin"`
+	QuietHoursEndInMin   int    `json:"quietHoursEndInMin"`
+	Timezone             string `json:"timezone"`
+	// Nil when the user never chooses one, see NotificationLocale.
+	Locale *i18n.Locale `json:"locale"`
+}
+
+// NotificationLocale is the locale the notifications are rendered in, which falls back to the given locale, e.g. of the
+// Accept-Language of the request, when the user never chooses one.
+func (setting *NotificationSetting) NotificationLocale(fallback i18n.Locale) i18n.Locale {
+	if setting.Locale == nil {
+		return fallback
+	}
+	return *setting.Locale
+}
+
+func defaultNotificationSetting() *NotificationSetting {
+	return &NotificationSetting{
+		EventReminderEnabled: true,
+		JoinEnabled:          true,
+		UpdateEnabled:        true,
+		CancellationEnabled:  true,
+		ChatEnabled:          true,
+		MarketingEnabled:     false,
+		QuietHoursEnabled:    false,
+		QuietHoursStartInMin: 22 * 60,
+		QuietHoursEndInMin:   7 * 60,
+		Timezone:             DefaultNotificationTimezone,
+	}
+}
+
+func (setting *NotificationSetting) CategoryEnabled(category NotificationCategory) bool {
+	switch category {
+	case JoinNotificationCategory:
+		return setting.JoinEnabled
+	case UpdateNotificationCategory:
+		return setting.UpdateEnabled
+	case CancellationNotificationCategory:
+		return setting.CancellationEnabled
+	case ReminderNotificationCategory:
+		return setting.EventReminderEnabled
+	case ChatNotificationCategory:
+		return setting.ChatEnabled
+	case MarketingNotificationCategory:
+		return setting.MarketingEnabled
+	default:
+		return true
+	}
+}
+
+// InQuietHours tells whether the time is within the quiet hours of the user, in the Location of the user.
+func (setting *NotificationSetting) InQuietHours(now time.Time) bool {
+	if !setting.QuietHoursEnabled || setting.QuietHoursStartInMin == setting.QuietHoursEndInMin {
+		return false
+	}
+
+	local := now.In(setting.Location())
+	minute := local.Hour()*60 + local.Minute()
+	start, end := setting.QuietHoursStartInMin, setting.QuietHoursEndInMin
+	if start < end {
+		return minute >= start && minute < end
+	}
+	return minute >= start || minute < end
+}
+
+// Location is the timezone of the user, which falls back to DefaultNotificationTimezone when it is unknown.
+func (setting *NotificationSetting) Location() *time.Location {
+	location, err := time.LoadLocation(setting.Timezone)
+	if err != nil {
+		location, err = time.LoadLocation(DefaultNotificationTimezone)
+		if err != nil {
+			location = time.UTC
+		}
+	}
+	return location
+}
+
+// Allows tells whether a notification of the category can be sent to the user now.
+func (setting *NotificationSetting) Allows(category NotificationCategory, now time.Time) bool {
+	return setting.CategoryEnabled(category) && !setting.InQuietHours(now)
+}
+
+const notificationSettingColumns = `ns.user_id, ns.event_reminder_enabled, ns.join_enabled, ns.update_enabled,
+	ns.cancellation_enabled, ns.chat_enabled, ns.marketing_enabled, ns.quiet_hours_enabled,
+	ns.quiet_hours_start_in_min, ns.quiet_hours_end_in_min, ns.timezone, ns.locale`
+
+// GetNotificationSetting returns the default setting if the user never changes it.
+func (dao NotificationSettingDao) GetNotificationSetting(userId int64) (*NotificationSetting, error) {
+	settings, err := dao.GetNotificationSettings([]int64{userId})
+	if err != nil {
+		return nil, err
+	}
+
+	return settings[userId], nil
+}
+
+// GetNotificationSettings returns the setting of each user, which is the default setting if the user never changes it.
+func (dao NotificationSettingDao) GetNotificationSettings(userIds []int64) (map[int64]*NotificationSetting, error) {
+	settings := map[int64]*NotificationSetting{}
+	if len(userIds) == 0 {
+		return settings, nil
+	}
+
+	values := make([]any, 0, len(userIds))
+	placeholders := make([]string, 0, len(userIds))
+	for _, userId := range userIds {
+		settings[userId] = defaultNotificationSetting()
+		values = append(values, userId)
+		placeholders = append(placeholders, fmt.Sprintf("$%d", len(values)))
+	}
+
+	query := fmt.Sprintf(`
+	SELECT %s FROM sportgether_schema.user_notification_setting ns WHERE ns.user_id IN (%s)
+`, notificationSettingColumns, strings.Join(placeholders, ","))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := dao.db.QueryContext(ctx, query, values...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var userId int64
+		setting := &NotificationSetting{}
+		err = rows.Scan(
+			&userId,
+			&setting.EventReminderEnabled,
+			&setting.JoinEnabled,
+			&setting.UpdateEnabled,
+			&setting.CancellationEnabled,
+			&setting.ChatEnabled,
+			&setting.MarketingEnabled,
+			&setting.QuietHoursEnabled,
+			&setting.QuietHoursStartInMin,
+			&setting.QuietHoursEndInMin,
+			&setting.Timezone,
+			&setting.Locale,
+		)
+		if err != nil {
+			return nil, err
+		}
+		settings[userId] = setting
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return settings, nil
+}
+
+func (dao NotificationSettingDao) UpdateNotificationSetting(userId int64, setting *NotificationSetting) error {
+	query := `
+	INSERT INTO sportgether_schema.user_notification_setting (user_id, event_reminder_enabled, join_enabled,
+		update_enabled, cancellation_enabled, chat_enabled, marketing_enabled, quiet_hours_enabled,
+		quiet_hours_start_in_min, quiet_hours_end_in_min, timezone, locale)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+	ON CONFLICT (user_id)
+	DO UPDATE
+	SET event_reminder_enabled = $2, join_enabled = $3, update_enabled = $4, cancellation_enabled = $5,
+		chat_enabled = $6, marketing_enabled = $7, quiet_hours_enabled = $8, quiet_hours_start_in_min = $9,
+		quiet_hours_end_in_min = $10, timezone = $11, locale = $12, version = user_notification_setting.version + 1
+`
+	args := []any{
+		userId,
+		setting.EventReminderEnabled,
+		setting.JoinEnabled,
+		setting.UpdateEnabled,
+		setting.CancellationEnabled,
+		setting.ChatEnabled,
+		setting.MarketingEnabled,
+		setting.QuietHoursEnabled,
+		setting.QuietHoursStartInMin,
+		setting.QuietHoursEndInMin,
+		setting.Timezone,
+		setting.Locale,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := dao.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// InitNotificationLocale sets the locale of the user, only when the user never has one.
+func (dao NotificationSettingDao) InitNotificationLocale(userId int64, locale i18n.Locale) error {
+	query := `
+	INSERT INTO sportgether_schema.user_notification_setting (user_id, locale)
+	VALUES ($1, $2)
+	ON CONFLICT (user_id)
+	DO UPDATE
+	SET locale = $2, version = user_notification_setting.version + 1
+	WHERE user_notification_setting.locale IS NULL
+`
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := dao.db.ExecContext(ctx, query, userId, locale)
+	return err
+}
+
+func (dao NotificationSettingDao) MuteEventNotification(userId int64, eventId int64) error {
+	query := `
+	INSERT INTO sportgether_schema.user_event_notification_mute (user_id, event_id)
+	VALUES ($1, $2)
+	ON CONFLICT (user_id, event_id) DO NOTHING
+`
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := dao.db.ExecContext(ctx, query, userId, eventId)
+	return err
+}
+
+func (dao NotificationSettingDao) UnmuteEventNotification(userId int64, eventId int64) error {
+	query := `DELETE FROM sportgether_schema.user_event_notification_mute WHERE user_id = $1 AND event_id = $2`
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := dao.db.ExecContext(ctx, query, userId, eventId)
+	return err
+}
+
+// GetEventMutedUserIds returns which of the users muted the notifications of the event.
+func (dao NotificationSettingDao) GetEventMutedUserIds(eventId int64, userIds []int64) (map[int64]bool, error) {
+	muted := map[int64]bool{}
+	if len(userIds) == 0 {
+		return muted, nil
+	}
+
+	values := []any{eventId}
+	placeholders := make([]string, 0, len(userIds))
+	for _, userId := range userIds {
+		values = append(values, userId)
+		placeholders = append(placeholders, fmt.Sprintf("$%d", len(values)))
+	}
+
+	query := fmt.Sprintf(`
+	SELECT user_id FROM sportgether_schema.user_event_notification_mute
+	WHERE event_id = $1 AND user_id IN (%s)
+`, strings.Join(placeholders, ","))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := dao.db.QueryContext(ctx, query, values...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var userId int64
+		err = rows.Scan(&userId)
+		if err != nil {
+			return nil, err
+		}
+		muted[userId] = true
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return muted, nil
+}
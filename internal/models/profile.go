@@ -13,14 +13,16 @@ type UserProfileDao struct {
 }
 
 type UserProfileDetail struct {
-	PreferredName       *string   `json:"preferredName"`
-	BirthDate           *string   `json:"birthDate"`
-	Signature           *string   `json:"signature"`
-	Memo                *string   `json:"memo"`
-	JoinTime            time.Time `json:"joinTime"`
-	ProfileIconUrl      *string   `json:"profileIconUrl"`
-	ProfileIconPublicId *string   `json:"profileIconPublicId"`
-	Gender              *string   `json:"gender"`
+	PreferredName           *string          `json:"preferredName"`
+	BirthDate               *string          `json:"birthDate"`
+	Signature               *string          `json:"signature"`
+	Memo                    *string          `json:"memo"`
+	JoinTime                time.Time        `json:"joinTime"`
+	ProfileIconUrl          *string          `json:"profileIconUrl"`
+	ProfileIconPublicId     *string          `json:"profileIconPublicId"`
+	ProfileIconThumbnailUrl *string          `json:"profileIconThumbnailUrl"`
+	Gender                  *string          `json:"gender"`
+	SportSkills             []UserSportSkill `json:"sportSkills"`
 }
 
 func (profileDao UserProfileDao) UserIsOnboarded(userId int64) (bool, error) {
@@ -50,6 +52,7 @@ func (profileDao UserProfileDao) GetProfileDetail(userId int64) (*UserProfileDet
 	    birth_date, 
 	    join_date, 
 	    profile_icon_url, 
+	    profile_icon_thumbnail_url, 
 	    signature, 
 	    memo 
 	FROM sportgether_schema.user_profile up
@@ -65,6 +68,7 @@ func (profileDao UserProfileDao) GetProfileDetail(userId int64) (*UserProfileDet
 		&userProfileDetail.BirthDate,
 		&userProfileDetail.JoinTime,
 		&userProfileDetail.ProfileIconUrl,
+		&userProfileDetail.ProfileIconThumbnailUrl,
 		&userProfileDetail.Signature,
 		&userProfileDetail.Memo,
 	)
@@ -72,6 +76,11 @@ func (profileDao UserProfileDao) GetProfileDetail(userId int64) (*UserProfileDet
 		return nil, err
 	}
 
+	userProfileDetail.SportSkills, err = profileDao.GetUserSportSkills(userId)
+	if err != nil {
+		return nil, err
+	}
+
 	return userProfileDetail, nil
 }
 
@@ -122,14 +131,13 @@ func (profileDao UserProfileDao) GetUserProfileIconUrl(userId int64) (*string, *
 }
 
 func (profileDao UserProfileDao) UpdateUserProfile(userId int64, detail UserProfileDetail) error {
+	// Profile icon is only updated by UpdateUserProfileIcon, after the upload is verified.
 	columnsMap := map[string]any{
-		"preferred_name":         detail.PreferredName,
-		"birth_date":             detail.BirthDate,
-		"signature":              detail.Signature,
-		"gender":                 detail.Gender,
-		"profile_icon_url":       detail.ProfileIconUrl,
-		"profile_icon_public_id": detail.ProfileIconPublicId,
-		"memo":                   detail.Memo,
+		"preferred_name": detail.PreferredName,
+		"birth_date":     detail.BirthDate,
+		"signature":      detail.Signature,
+		"gender":         detail.Gender,
+		"memo":           detail.Memo,
 	}
 	setQuery, values := buildColumnsToUpdate(columnsMap)
 	whereClause := fmt.Sprintf("WHERE up.user_id = $%d", len(values)+1)
@@ -151,6 +159,37 @@ func (profileDao UserProfileDao) UpdateUserProfile(userId int64, detail UserProf
 	return nil
 }
 
+// UpdateUserProfileIcon replaces the profile icon and returns the public id of the old one, if there is.
+func (profileDao UserProfileDao) UpdateUserProfileIcon(userId int64, url string, publicId string, thumbnailUrl string, tx *sql.Tx) (*string, error) {
+	query := `
+	WITH old AS (
+		SELECT profile_icon_public_id FROM sportgether_schema.user_profile WHERE user_id = $1 FOR UPDATE
+	)
+	UPDATE sportgether_schema.user_profile up
+	SET profile_icon_url = $2, profile_icon_public_id = $3, profile_icon_thumbnail_url = $4, version = version + 1
+	FROM old
+	WHERE up.user_id = $1
+	RETURNING old.profile_icon_public_id
+`
+	args := []any{
+		userId,
+		url,
+		publicId,
+		thumbnailUrl,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var oldPublicId *string
+	err := tx.QueryRowContext(ctx, query, args...).Scan(&oldPublicId)
+	if err != nil {
+		return nil, err
+	}
+
+	return oldPublicId, nil
+}
+
 func buildColumnsToUpdate(columnNames map[string]any) (string, []any) {
 	setColumn := "SET version = version + 1, "
 	var values []any
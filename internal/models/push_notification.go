@@ -3,6 +3,8 @@ package models
 import (
 	"context"
 	"database/sql"
+	"fmt"
+	"strings"
 	"time"
 )
 
@@ -10,18 +12,46 @@ type MessagingDao struct {
 	db *sql.DB
 }
 
-func (dao MessagingDao) UpdateFCMToken(userId int64, token string) error {
+type DevicePlatform string
+
+var (
+	IOSPlatform     = DevicePlatform("IOS")
+	AndroidPlatform = DevicePlatform("ANDROID")
+	WebPlatform     = DevicePlatform("WEB")
+)
+
+func (platform DevicePlatform) IsValid() bool {
+	return platform == IOSPlatform || platform == AndroidPlatform || platform == WebPlatform
+}
+
+// UpdateFCMToken registers the token of a device of the user. A device has one token, so the token moves to the user
+// when another user logged in on the device before.
+func (dao MessagingDao) UpdateFCMToken(userId int64, token string, platform *DevicePlatform) error {
 	query := `
-	INSERT INTO sportgether_schema.firebase_messaging_token_table (user_id, token)
-	VALUES($1, $2) 
-	ON CONFLICT (user_id) 
+	INSERT INTO sportgether_schema.firebase_messaging_token_table (user_id, token, platform)
+	VALUES($1, $2, $3) 
+	ON CONFLICT (token) 
 	DO
 	UPDATE
-	SET user_id=$1, token=$2;
+	SET user_id = $1, platform = COALESCE($3, firebase_messaging_token_table.platform), last_seen_at = NOW();
 `
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
+	_, err := dao.db.ExecContext(ctx, query, userId, token, platform)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// DeleteFCMToken unregisters the token of the device, e.g. when the user logs out on it.
+func (dao MessagingDao) DeleteFCMToken(userId int64, token string) error {
+	query := `DELETE FROM sportgether_schema.firebase_messaging_token_table WHERE user_id = $1 AND token = $2`
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
 	_, err := dao.db.ExecContext(ctx, query, userId, token)
 	if err != nil {
 		return err
@@ -30,35 +60,112 @@ func (dao MessagingDao) UpdateFCMToken(userId int64, token string) error {
 	return nil
 }
 
-func (dao MessagingDao) GetEventParticipantTokens(eventId int64) (*[]string, error) {
-	tokens := []string{}
+// DeleteInvalidFCMTokens deletes the tokens which FCM no longer accepts, whoever they belong to.
+func (dao MessagingDao) DeleteInvalidFCMTokens(tokens []string) error {
+	if len(tokens) == 0 {
+		return nil
+	}
 
-	query := `SELECT fcm.token FROM sportgether_schema.users u
-    	INNER JOIN sportgether_schema.event_participant ep on u.id = ep.participantid
-         INNER JOIN sportgether_schema.firebase_messaging_token_table fcm on ep.participantid = fcm.user_id
-		WHERE ep.eventid = $1
-`
+	placeholders := make([]string, 0, len(tokens))
+	args := make([]any, 0, len(tokens))
+	for _, token := range tokens {
+		placeholders = append(placeholders, fmt.Sprintf("$%d", len(args)+1))
+		args = append(args, token)
+	}
+
+	query := fmt.Sprintf(`DELETE FROM sportgether_schema.firebase_messaging_token_table WHERE token IN (%s)`, strings.Join(placeholders, ","))
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
-	rows, err := dao.db.QueryContext(ctx, query, eventId)
+	_, err := dao.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetUsersTokens returns the tokens of all the devices of each user.
+func (dao MessagingDao) GetUsersTokens(userIds []int64) (map[int64][]string, error) {
+	tokens := map[int64][]string{}
+	if len(userIds) == 0 {
+		return tokens, nil
+	}
+
+	placeholders := make([]string, 0, len(userIds))
+	args := make([]any, 0, len(userIds))
+	for _, userId := range userIds {
+		placeholders = append(placeholders, fmt.Sprintf("$%d", len(args)+1))
+		args = append(args, userId)
+	}
+
+	query := fmt.Sprintf(`SELECT fcm.user_id, fcm.token FROM sportgether_schema.firebase_messaging_token_table fcm WHERE fcm.user_id IN (%s)`, strings.Join(placeholders, ","))
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := dao.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
 
 	for rows.Next() {
+		var userId int64
 		var token string
-		err = rows.Scan(&token)
+		err = rows.Scan(&userId, &token)
+		if err != nil {
+			return nil, err
+		}
+
+		tokens[userId] = append(tokens[userId], token)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return tokens, nil
+}
+
+// GetTournamentEntryMemberIds returns the players in the tournament entries.
+func (dao MessagingDao) GetTournamentEntryMemberIds(entryIds []int64) ([]int64, error) {
+	memberIds := []int64{}
+	if len(entryIds) == 0 {
+		return memberIds, nil
+	}
+
+	placeholders := make([]string, 0, len(entryIds))
+	args := make([]any, 0, len(entryIds))
+	for _, entryId := range entryIds {
+		placeholders = append(placeholders, fmt.Sprintf("$%d", len(args)+1))
+		args = append(args, entryId)
+	}
+
+	query := fmt.Sprintf(`SELECT DISTINCT tem.user_id FROM sportgether_schema.tournament_entry_member tem
+		WHERE tem.entry_id IN (%s)
+`, strings.Join(placeholders, ","))
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := dao.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var memberId int64
+		err = rows.Scan(&memberId)
 		if err != nil {
 			return nil, err
 		}
 
-		tokens = append(tokens, token)
+		memberIds = append(memberIds, memberId)
 	}
 
 	if err = rows.Err(); err != nil {
 		return nil, err
 	}
 
-	return &tokens, nil
+	return memberIds, nil
 }
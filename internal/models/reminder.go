@@ -0,0 +1,110 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// Any constant works, as long as it is unique among the advisory locks taken in the schema.
+const eventReminderLockKey = 20260028
+
+type ReminderDao struct {
+	db *sql.DB
+}
+
+type DueReminder struct {
+	EventId       int64
+	ParticipantId int64
+	OffsetInMin   int
+	EventName     string
+	StartTime     time.Time
+}
+
+// ReminderWindow is the reminder of the offset, which is due for the events starting within (From, To].
+type ReminderWindow struct {
+	OffsetInMin int
+	From        time.Time
+	To          time.Time
+}
+
+// ClaimDueReminders marks the reminders of events starting within the window as sent at now, and returns them to be
+// delivered. Only one instance can claim at a time, and a reminder that was claimed is never returned again, so each
+// participant receives at most one reminder per offset. Participants who disabled event reminders are skipped.
+func (dao ReminderDao) ClaimDueReminders(now time.Time, window ReminderWindow) ([]*DueReminder, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	tx, err := dao.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var locked bool
+	err = tx.QueryRowContext(ctx, `SELECT pg_try_advisory_xact_lock($1)`, eventReminderLockKey).Scan(&locked)
+	if err != nil {
+		return nil, err
+	}
+
+	// Another instance is working on it.
+	if !locked {
+		return []*DueReminder{}, nil
+	}
+
+	query := `
+	WITH due AS (
+		INSERT INTO sportgether_schema.event_reminder (event_id, participant_id, offset_in_min, sent_at)
+		SELECT e.id, ep.participantid, $1, $2
+		FROM sportgether_schema.events e
+		INNER JOIN sportgether_schema.event_participant ep ON ep.eventid = e.id
+		LEFT JOIN sportgether_schema.user_notification_setting ns ON ns.user_id = ep.participantid
+		WHERE e.deleted IS FALSE AND e.start_time > $3 AND e.start_time <= $4
+		AND COALESCE(ns.event_reminder_enabled, TRUE)
+		ON CONFLICT (event_id, participant_id, offset_in_min) DO NOTHING
+		RETURNING event_id, participant_id, offset_in_min
+	)
+	SELECT due.event_id, due.participant_id, due.offset_in_min, e.event_name, e.start_time
+	FROM due
+	INNER JOIN sportgether_schema.events e ON e.id = due.event_id
+`
+	args := []any{
+		window.OffsetInMin,
+		now,
+		window.From,
+		window.To,
+	}
+
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	reminders := []*DueReminder{}
+	for rows.Next() {
+		reminder := &DueReminder{}
+		err = rows.Scan(
+			&reminder.EventId,
+			&reminder.ParticipantId,
+			&reminder.OffsetInMin,
+			&reminder.EventName,
+			&reminder.StartTime,
+		)
+		if err != nil {
+			return nil, err
+		}
+		reminders = append(reminders, reminder)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, err
+	}
+
+	return reminders, nil
+}
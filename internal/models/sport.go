@@ -0,0 +1,265 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sportgether/constants"
+	"sportgether/internal/i18n"
+	"strings"
+	"time"
+)
+
+type SportStatus string
+
+var (
+	ActiveSport = SportStatus("ACTIVE")
+	// RetiredSport is kept for the past events, but cannot be hosted or searched anymore.
+	RetiredSport = SportStatus("RETIRED")
+)
+
+func (status SportStatus) IsValid() bool {
+	return status == ActiveSport || status == RetiredSport
+}
+
+type SportDao struct {
+	db *sql.DB
+}
+
+type Sport struct {
+	// Sport is the code kept in the event type of the events, e.g. BADMINTON.
+	Sport string `json:"sport"`
+	Index int64  `json:"sportIndex"`
+	// Names are the names of the sport keyed by the locale, where the English name is required.
+	Names                      map[i18n.Locale]string `json:"names"`
+	ImageUrl                   string                 `json:"imageUrl"`
+	IconUrl                    *string                `json:"iconUrl"`
+	DefaultMaxParticipantCount int                    `json:"defaultMaxParticipantCount"`
+	Status                     SportStatus            `json:"status"`
+	Version                    int                    `json:"version"`
+}
+
+// Name is the name of the sport in the locale, which falls back to the English name, then the code.
+func (sport *Sport) Name(locale i18n.Locale) string {
+	if name, ok := sport.Names[locale]; ok && name != "" {
+		return name
+	}
+	if name, ok := sport.Names[i18n.DefaultLocale]; ok && name != "" {
+		return name
+	}
+	return sport.Sport
+}
+
+const sportColumns = `sport, sport_index, names, image_url, icon_url, default_max_participant_count, status, version`
+
+// GetSports returns the sports by the index, only the active ones unless includeRetired.
+func (dao SportDao) GetSports(includeRetired bool) ([]*Sport, error) {
+	query := fmt.Sprintf(`
+	SELECT %s FROM sportgether_schema.sport
+	WHERE $1 OR status = $2
+	ORDER BY sport_index, sport
+`, sportColumns)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := dao.db.QueryContext(ctx, query, includeRetired, ActiveSport)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sports := []*Sport{}
+	for rows.Next() {
+		sport, err := scanSport(rows)
+		if err != nil {
+			return nil, err
+		}
+		sports = append(sports, sport)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return sports, nil
+}
+
+func (dao SportDao) GetSport(code string) (*Sport, error) {
+	query := fmt.Sprintf(`SELECT %s FROM sportgether_schema.sport WHERE sport = $1`, sportColumns)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	return scanSport(dao.db.QueryRowContext(ctx, query, code))
+}
+
+// GetActiveSport returns the sport when it can be hosted, or constants.SportConfigNotFoundError when it is unknown or
+// retired.
+func (dao SportDao) GetActiveSport(code string) (*Sport, error) {
+	sport, err := dao.GetSport(code)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, constants.SportConfigNotFoundError
+		}
+		return nil, err
+	}
+	if sport.Status != ActiveSport {
+		return nil, constants.SportConfigNotFoundError
+	}
+
+	return sport, nil
+}
+
+// GetInactiveSports returns which of the sports are unknown or retired.
+func (dao SportDao) GetInactiveSports(codes []string) ([]string, error) {
+	if len(codes) == 0 {
+		return []string{}, nil
+	}
+
+	values := []any{ActiveSport}
+	placeholders := make([]string, 0, len(codes))
+	for _, code := range codes {
+		values = append(values, code)
+		placeholders = append(placeholders, fmt.Sprintf("$%d", len(values)))
+	}
+
+	query := fmt.Sprintf(`
+	SELECT sport FROM sportgether_schema.sport WHERE status = $1 AND sport IN (%s)
+`, strings.Join(placeholders, ","))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := dao.db.QueryContext(ctx, query, values...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	active := map[string]bool{}
+	for rows.Next() {
+		var code string
+		err = rows.Scan(&code)
+		if err != nil {
+			return nil, err
+		}
+		active[code] = true
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	inactive := []string{}
+	for _, code := range codes {
+		if !active[code] {
+			inactive = append(inactive, code)
+		}
+	}
+
+	return inactive, nil
+}
+
+// InsertSport returns constants.SportExistError when the sport code is taken, even by a retired sport.
+func (dao SportDao) InsertSport(sport *Sport) error {
+	query := fmt.Sprintf(`
+	INSERT INTO sportgether_schema.sport (sport, sport_index, names, image_url, icon_url, default_max_participant_count, status)
+	VALUES ($1, $2, $3, $4, $5, $6, $7)
+	ON CONFLICT (sport) DO NOTHING
+	RETURNING %s
+`, sportColumns)
+
+	names, err := json.Marshal(sport.Names)
+	if err != nil {
+		return err
+	}
+	args := []any{
+		sport.Sport,
+		sport.Index,
+		string(names),
+		sport.ImageUrl,
+		sport.IconUrl,
+		sport.DefaultMaxParticipantCount,
+		sport.Status,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	inserted, err := scanSport(dao.db.QueryRowContext(ctx, query, args...))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return constants.SportExistError
+		}
+		return err
+	}
+
+	*sport = *inserted
+	return nil
+}
+
+// UpdateSport returns constants.StaleInfoError when the sport has been changed since the version.
+func (dao SportDao) UpdateSport(sport *Sport) error {
+	query := `
+	UPDATE sportgether_schema.sport
+	SET sport_index = $1, names = $2, image_url = $3, icon_url = $4, default_max_participant_count = $5, status = $6,
+		updated_at = NOW(), version = version + 1
+	WHERE sport = $7 AND version = $8
+	RETURNING version
+`
+	names, err := json.Marshal(sport.Names)
+	if err != nil {
+		return err
+	}
+	args := []any{
+		sport.Index,
+		string(names),
+		sport.ImageUrl,
+		sport.IconUrl,
+		sport.DefaultMaxParticipantCount,
+		sport.Status,
+		sport.Sport,
+		sport.Version,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err = dao.db.QueryRowContext(ctx, query, args...).Scan(&sport.Version)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return constants.StaleInfoError
+		}
+		return err
+	}
+
+	return nil
+}
+
+func scanSport(row interface{ Scan(dest ...any) error }) (*Sport, error) {
+	sport := &Sport{}
+	var names []byte
+	err := row.Scan(
+		&sport.Sport,
+		&sport.Index,
+		&names,
+		&sport.ImageUrl,
+		&sport.IconUrl,
+		&sport.DefaultMaxParticipantCount,
+		&sport.Status,
+		&sport.Version,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	err = json.Unmarshal(names, &sport.Names)
+	if err != nil {
+		return nil, err
+	}
+
+	return sport, nil
+}
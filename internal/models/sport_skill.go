@@ -0,0 +1,177 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+type SkillLevel string
+
+var (
+	BeginnerLevel     = SkillLevel("BEGINNER")
+	IntermediateLevel = SkillLevel("INTERMEDIATE")
+	AdvancedLevel     = SkillLevel("ADVANCED")
+)
+
+// skillLevels is in the order of the skill, from the lowest.
+var skillLevels = []SkillLevel{BeginnerLevel, IntermediateLevel, AdvancedLevel}
+
+func (level SkillLevel) IsValid() bool {
+	return level.rank() > 0
+}
+
+// Rating is the skill rating used to balance the teams, see DefaultSkillRating.
+func (level SkillLevel) Rating() float64 {
+	return float64(level.rank())
+}
+
+func (level SkillLevel) rank() int {
+	for index, value := range skillLevels {
+		if value == level {
+			return index + 1
+		}
+	}
+	return 0
+}
+
+// InSkillRange tells whether the level is within the range, where a nil bound means no limit. A nil level is never
+// in a limited range, as the skill of the user is unknown.
+func InSkillRange(level *SkillLevel, min *SkillLevel, max *SkillLevel) bool {
+	if min == nil && max == nil {
+		return true
+	}
+	if level == nil {
+		return false
+	}
+	return (min == nil || level.rank() >= min.rank()) && (max == nil || level.rank() <= max.rank())
+}
+
+// skillRankQuery builds the sql expression of the rank of the skill level in the column.
+func skillRankQuery(column string) string {
+	return fmt.Sprintf("array_position(ARRAY['BEGINNER', 'INTERMEDIATE', 'ADVANCED']::text[], %s)", column)
+}
+
+// suitsSkillCondition builds the sql condition of whether the user is in the skill range of the event.
+func suitsSkillCondition(eventAlias string, userIdPlaceholder string) string {
+	return fmt.Sprintf(`((%[1]s.min_skill_level IS NULL AND %[1]s.max_skill_level IS NULL) OR EXISTS (
+		SELECT 1 FROM sportgether_schema.user_sport_skill uss
+		WHERE uss.user_id = %[2]s AND uss.event_type = %[1]s.event_type
+		AND (%[1]s.min_skill_level IS NULL OR %[3]s >= %[4]s)
+		AND (%[1]s.max_skill_level IS NULL OR %[3]s <= %[5]s)
+	))`,
+		eventAlias,
+		userIdPlaceholder,
+		skillRankQuery("uss.skill_level"),
+		skillRankQuery(eventAlias+".min_skill_level"),
+		skillRankQuery(eventAlias+".max_skill_level"),
+	)
+}
+
+type SkillRangePolicy string
+
+var (
+	// WarnSkillRange lets the users outside of the skill range join, after they are warned.
+	WarnSkillRange  = SkillRangePolicy("WARN")
+	BlockSkillRange = SkillRangePolicy("BLOCK")
+)
+
+func (policy SkillRangePolicy) IsValid() bool {
+	return policy == WarnSkillRange || policy == BlockSkillRange
+}
+
+type UserSportSkill struct {
+	EventType  string     `json:"eventType"`
+	SkillLevel SkillLevel `json:"skillLevel"`
+}
+
+func (profileDao UserProfileDao) GetUserSportSkills(userId int64) ([]UserSportSkill, error) {
+	query := `
+	SELECT event_type, skill_level FROM sportgether_schema.user_sport_skill
+	WHERE user_id = $1
+	ORDER BY event_type
+`
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := profileDao.db.QueryContext(ctx, query, userId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	skills := []UserSportSkill{}
+	for rows.Next() {
+		skill := UserSportSkill{}
+		err = rows.Scan(&skill.EventType, &skill.SkillLevel)
+		if err != nil {
+			return nil, err
+		}
+		skills = append(skills, skill)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return skills, nil
+}
+
+// GetUserSkillLevel returns nil when the user has not declared the skill level of the sport.
+func (profileDao UserProfileDao) GetUserSkillLevel(userId int64, eventType string) (*SkillLevel, error) {
+	query := `SELECT skill_level FROM sportgether_schema.user_sport_skill WHERE user_id = $1 AND event_type = $2`
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var level SkillLevel
+	err := profileDao.db.QueryRowContext(ctx, query, userId, eventType).Scan(&level)
+	if err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			return nil, nil
+		default:
+			return nil, err
+		}
+	}
+
+	return &level, nil
+}
+
+// ReplaceUserSportSkills replaces all the skill levels of the user, and the skill ratings follow the levels.
+func (profileDao UserProfileDao) ReplaceUserSportSkills(userId int64, skills []UserSportSkill, tx *sql.Tx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	values := []any{userId}
+	eventTypePlaceholders := make([]string, 0, len(skills))
+	for _, skill := range skills {
+		eventTypePlaceholders = append(eventTypePlaceholders, fmt.Sprintf("$%d", len(values)+1))
+		values = append(values, skill.EventType)
+	}
+
+	query := `DELETE FROM sportgether_schema.user_sport_skill WHERE user_id = $1`
+	if len(eventTypePlaceholders) > 0 {
+		query += fmt.Sprintf(" AND event_type NOT IN (%s)", strings.Join(eventTypePlaceholders, ","))
+	}
+	_, err := tx.ExecContext(ctx, query, values...)
+	if err != nil {
+		return err
+	}
+
+	for _, skill := range skills {
+		query = `
+		INSERT INTO sportgether_schema.user_sport_skill (user_id, event_type, skill_level, skill_rating)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (user_id, event_type) DO UPDATE
+		SET skill_level = EXCLUDED.skill_level, skill_rating = EXCLUDED.skill_rating, updated_at = NOW()
+`
+		_, err = tx.ExecContext(ctx, query, userId, skill.EventType, skill.SkillLevel, skill.SkillLevel.Rating())
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -13,6 +13,7 @@ import (
 const (
 	AccountActivationScope    = "activation"
 	AcccountDeactivationScope = "deactivation"
+	CalendarFeedScope         = "calendar-feed"
 )
 
 type Token struct {
@@ -0,0 +1,601 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sportgether/constants"
+	"sportgether/internal/tournament"
+	"strings"
+	"time"
+)
+
+const (
+	TournamentIndividualRegistration = "INDIVIDUAL"
+	TournamentTeamRegistration       = "TEAM"
+
+	TournamentRegistration = "REGISTRATION"
+	TournamentOngoing      = "ONGOING"
+	TournamentCompleted    = "COMPLETED"
+	TournamentCancelled    = "CANCELLED"
+
+	MatchPending   = "PENDING"
+	MatchReady     = "READY"
+	MatchCompleted = "COMPLETED"
+	MatchBye       = "BYE"
+)
+
+type TournamentDao struct {
+	db *sql.DB
+}
+
+type Tournament struct {
+	ID               int64             `json:"id"`
+	HostId           int64             `json:"hostId"`
+	TournamentName   string            `json:"tournamentName"`
+	EventType        string            `json:"eventType"`
+	Format           tournament.Format `json:"format"`
+	RegistrationType string            `json:"registrationType"`
+	TeamSize         int               `json:"teamSize"`
+	MaxEntryCount    int               `json:"maxEntryCount"`
+	Description      *string           `json:"description"`
+	Status           string            `json:"status"`
+	CreatedAt        time.Time         `json:"createdAt"`
+	Version          int               `json:"version"`
+}
+
+type TournamentEntry struct {
+	ID        int64                    `json:"id"`
+	EntryName string                   `json:"entryName"`
+	CaptainId int64                    `json:"captainId"`
+	Members   []EventParticipantDetail `json:"members"`
+	CreatedAt time.Time                `json:"createdAt"`
+}
+
+type TournamentMatch struct {
+	ID            int64   `json:"id"`
+	TournamentId  int64   `json:"tournamentId"`
+	Round         int     `json:"round"`
+	MatchIndex    int     `json:"matchIndex"`
+	HomeEntryId   *int64  `json:"homeEntryId"`
+	AwayEntryId   *int64  `json:"awayEntryId"`
+	HomeScore     *int    `json:"homeScore"`
+	AwayScore     *int    `json:"awayScore"`
+	WinnerEntryId *int64  `json:"winnerEntryId"`
+	Status        string  `json:"status"`
+	EventId       *int64  `json:"eventId"`
+	ReportedAt    *string `json:"reportedAt"`
+}
+
+func (dao TournamentDao) CreateTournament(t *Tournament) error {
+	query := `
+	INSERT INTO sportgether_schema.tournament (host_id, tournament_name, event_type, format, registration_type, team_size, max_entry_count, description)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	RETURNING id, status, created_at, version
+`
+	args := []any{
+		t.HostId,
+		t.TournamentName,
+		t.EventType,
+		t.Format,
+		t.RegistrationType,
+		t.TeamSize,
+		t.MaxEntryCount,
+		t.Description,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := dao.db.QueryRowContext(ctx, query, args...).Scan(&t.ID, &t.Status, &t.CreatedAt, &t.Version)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetTournament locks the tournament row when tx is given, so that the registrations and score reports of the
+// same tournament go one by one.
+func (dao TournamentDao) GetTournament(tournamentId int64, tx *sql.Tx) (*Tournament, error) {
+	query := `
+	SELECT id, host_id, tournament_name, event_type, format, registration_type, team_size, max_entry_count, description, status, created_at, version
+	FROM sportgether_schema.tournament
+	WHERE id = $1
+`
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var row *sql.Row
+	if tx != nil {
+		row = tx.QueryRowContext(ctx, query+" FOR UPDATE", tournamentId)
+	} else {
+		row = dao.db.QueryRowContext(ctx, query, tournamentId)
+	}
+
+	t := &Tournament{}
+	err := row.Scan(
+		&t.ID,
+		&t.HostId,
+		&t.TournamentName,
+		&t.EventType,
+		&t.Format,
+		&t.RegistrationType,
+		&t.TeamSize,
+		&t.MaxEntryCount,
+		&t.Description,
+		&t.Status,
+		&t.CreatedAt,
+		&t.Version,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
+
+// GetTournamentEntries returns the entries in the registration order, which is also the seeding order.
+func (dao TournamentDao) GetTournamentEntries(tournamentId int64) ([]*TournamentEntry, error) {
+	query := `
+	SELECT
+	    te.id,
+	    te.entry_name,
+	    te.captain_id,
+	    te.created_at,
+	    u.id,
+	    u.username,
+	    up.preferred_name,
+	    up.profile_icon_url
+	FROM sportgether_schema.tournament_entry te
+	INNER JOIN sportgether_schema.tournament_entry_member tem ON tem.entry_id = te.id
+	INNER JOIN sportgether_schema.users u ON u.id = tem.user_id
+	LEFT JOIN sportgether_schema.user_profile up ON up.user_id = u.id
+	WHERE te.tournament_id = $1
+	ORDER BY te.created_at, te.id, u.id
+`
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := dao.db.QueryContext(ctx, query, tournamentId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []*TournamentEntry{}
+	var last *TournamentEntry
+	for rows.Next() {
+		entry := &TournamentEntry{}
+		member := EventParticipantDetail{}
+		var preferredName *string
+		err = rows.Scan(
+			&entry.ID,
+			&entry.EntryName,
+			&entry.CaptainId,
+			&entry.CreatedAt,
+			&member.ParticipantId,
+			&member.ParticipantUsername,
+			&preferredName,
+			&member.ProfileIconUrl,
+		)
+		if err != nil {
+			return nil, err
+		}
+		if preferredName != nil {
+			member.ParticipantPreferredName = *preferredName
+		}
+
+		if last == nil || last.ID != entry.ID {
+			entry.Members = []EventParticipantDetail{}
+			entries = append(entries, entry)
+			last = entry
+		}
+		last.Members = append(last.Members, member)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+// GetCaptainConnectedUserIds returns the users among userIds who are in a club with the captain, or have joined an
+// event with the captain, which are the only users the captain can enlist without their consent.
+func (dao TournamentDao) GetCaptainConnectedUserIds(captainId int64, userIds []int64) (map[int64]bool, error) {
+	connected := map[int64]bool{}
+	if len(userIds) == 0 {
+		return connected, nil
+	}
+
+	placeholders := make([]string, 0, len(userIds))
+	args := []any{captainId}
+	for _, userId := range userIds {
+		placeholders = append(placeholders, fmt.Sprintf("$%d", len(args)+1))
+		args = append(args, userId)
+	}
+
+	query := fmt.Sprintf(`
+	SELECT u.id FROM sportgether_schema.users u
+	WHERE u.id IN (%s) AND (
+	    EXISTS (
+	        SELECT 1 FROM sportgether_schema.club_member captain
+	        INNER JOIN sportgether_schema.club_member cm ON cm.club_id = captain.club_id
+	        WHERE captain.user_id = $1 AND cm.user_id = u.id
+	    ) OR EXISTS (
+	        SELECT 1 FROM sportgether_schema.event_participant captain
+	        INNER JOIN sportgether_schema.event_participant ep ON ep.eventid = captain.eventid
+	        WHERE captain.participantid = $1 AND ep.participantid = u.id
+	    )
+	)
+`, strings.Join(placeholders, ","))
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := dao.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var userId int64
+		err = rows.Scan(&userId)
+		if err != nil {
+			return nil, err
+		}
+		connected[userId] = true
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return connected, nil
+}
+
+// RegisterTournamentEntry must run after GetTournament with the same tx, which holds the lock of the tournament.
+func (dao TournamentDao) RegisterTournamentEntry(t *Tournament, entry *TournamentEntry, memberIds []int64, tx *sql.Tx) error {
+	if t.Status != TournamentRegistration {
+		return constants.TournamentNotEditableError
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	placeholders := make([]string, 0, len(memberIds))
+	args := []any{t.ID}
+	for _, memberId := range memberIds {
+		placeholders = append(placeholders, fmt.Sprintf("$%d", len(args)+1))
+		args = append(args, memberId)
+	}
+
+	query := fmt.Sprintf(`
+	SELECT
+	    (SELECT count(*) FROM sportgether_schema.tournament_entry te WHERE te.tournament_id = $1),
+	    EXISTS (
+	        SELECT 1 FROM sportgether_schema.tournament_entry_member tem
+	        WHERE tem.tournament_id = $1 AND tem.user_id IN (%s)
+	    )
+`, strings.Join(placeholders, ","))
+
+	var entryCount int
+	var registered bool
+	err := tx.QueryRowContext(ctx, query, args...).Scan(&entryCount, &registered)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case registered:
+		return constants.TournamentRegisteredError
+	case entryCount >= t.MaxEntryCount:
+		return constants.TournamentFullError
+	}
+
+	query = `
+	INSERT INTO sportgether_schema.tournament_entry (tournament_id, entry_name, captain_id)
+	VALUES ($1, $2, $3)
+	RETURNING id, created_at
+`
+	err = tx.QueryRowContext(ctx, query, t.ID, entry.EntryName, entry.CaptainId).Scan(&entry.ID, &entry.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	for _, memberId := range memberIds {
+		query = `
+		INSERT INTO sportgether_schema.tournament_entry_member (entry_id, tournament_id, user_id)
+		VALUES ($1, $2, $3)
+`
+		_, err = tx.ExecContext(ctx, query, entry.ID, t.ID, memberId)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// WithdrawTournamentEntry removes the entry led by the captain, which is only allowed before the tournament starts.
+func (dao TournamentDao) WithdrawTournamentEntry(tournamentId int64, captainId int64) error {
+	query := `
+	DELETE FROM sportgether_schema.tournament_entry te
+	USING sportgether_schema.tournament t
+	WHERE te.tournament_id = t.id AND t.id = $1 AND te.captain_id = $2 AND t.status = $3
+`
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	res, err := dao.db.ExecContext(ctx, query, tournamentId, captainId, TournamentRegistration)
+	if err != nil {
+		return err
+	}
+
+	if rowCount, err := res.RowsAffected(); err != nil {
+		return err
+	} else if rowCount == 0 {
+		return constants.TournamentNotEditableError
+	}
+
+	return nil
+}
+
+// StartTournament closes the registration and saves the generated bracket.
+func (dao TournamentDao) StartTournament(tournamentId int64, matches []*tournament.Match, tx *sql.Tx) error {
+	query := `
+	UPDATE sportgether_schema.tournament SET status = $1, version = version + 1
+	WHERE id = $2 AND status = $3
+`
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	res, err := tx.ExecContext(ctx, query, TournamentOngoing, tournamentId, TournamentRegistration)
+	if err != nil {
+		return err
+	}
+
+	if rowCount, err := res.RowsAffected(); err != nil {
+		return err
+	} else if rowCount == 0 {
+		return constants.TournamentNotEditableError
+	}
+
+	for _, match := range matches {
+		status := MatchPending
+		switch {
+		case match.Bye:
+			status = MatchBye
+		case match.Home != nil && match.Away != nil:
+			status = MatchReady
+		}
+
+		query = `
+		INSERT INTO sportgether_schema.tournament_match (tournament_id, round, match_index, home_entry_id, away_entry_id, winner_entry_id, status)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+`
+		args := []any{
+			tournamentId,
+			match.Round,
+			match.Index,
+			match.Home,
+			match.Away,
+			match.Winner,
+			status,
+		}
+		_, err = tx.ExecContext(ctx, query, args...)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (dao TournamentDao) CancelTournament(tournamentId int64) error {
+	query := `
+	UPDATE sportgether_schema.tournament SET status = $1, version = version + 1
+	WHERE id = $2 AND status IN ($3, $4)
+`
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	res, err := dao.db.ExecContext(ctx, query, TournamentCancelled, tournamentId, TournamentRegistration, TournamentOngoing)
+	if err != nil {
+		return err
+	}
+
+	if rowCount, err := res.RowsAffected(); err != nil {
+		return err
+	} else if rowCount == 0 {
+		return constants.TournamentNotEditableError
+	}
+
+	return nil
+}
+
+func (dao TournamentDao) GetTournamentMatches(tournamentId int64, tx *sql.Tx) ([]*TournamentMatch, error) {
+	query := `
+	SELECT id, tournament_id, round, match_index, home_entry_id, away_entry_id, home_score, away_score, winner_entry_id, status, event_id, reported_at
+	FROM sportgether_schema.tournament_match
+	WHERE tournament_id = $1
+	ORDER BY round, match_index
+`
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var rows *sql.Rows
+	var err error
+	if tx != nil {
+		rows, err = tx.QueryContext(ctx, query, tournamentId)
+	} else {
+		rows, err = dao.db.QueryContext(ctx, query, tournamentId)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	matches := []*TournamentMatch{}
+	for rows.Next() {
+		match := &TournamentMatch{}
+		err = rows.Scan(
+			&match.ID,
+			&match.TournamentId,
+			&match.Round,
+			&match.MatchIndex,
+			&match.HomeEntryId,
+			&match.AwayEntryId,
+			&match.HomeScore,
+			&match.AwayScore,
+			&match.WinnerEntryId,
+			&match.Status,
+			&match.EventId,
+			&match.ReportedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		matches = append(matches, match)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return matches, nil
+}
+
+func (dao TournamentDao) UpdateMatchResult(match *TournamentMatch, reportedBy int64, tx *sql.Tx) error {
+	query := `
+	UPDATE sportgether_schema.tournament_match
+	SET home_score = $1, away_score = $2, winner_entry_id = $3, status = $4, reported_by = $5, reported_at = $6
+	WHERE id = $7
+`
+	args := []any{
+		match.HomeScore,
+		match.AwayScore,
+		match.WinnerEntryId,
+		MatchCompleted,
+		reportedBy,
+		time.Now(),
+		match.ID,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	match.Status = MatchCompleted
+
+	return nil
+}
+
+// UpdateMatchEntries sets the opponents of the later round match, after the winner of the earlier round advances.
+func (dao TournamentDao) UpdateMatchEntries(match *TournamentMatch, tx *sql.Tx) error {
+	status := MatchPending
+	if match.HomeEntryId != nil && match.AwayEntryId != nil {
+		status = MatchReady
+	}
+
+	query := `
+	UPDATE sportgether_schema.tournament_match
+	SET home_entry_id = $1, away_entry_id = $2, status = $3
+	WHERE id = $4
+`
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := tx.ExecContext(ctx, query, match.HomeEntryId, match.AwayEntryId, status, match.ID)
+	if err != nil {
+		return err
+	}
+	match.Status = status
+
+	return nil
+}
+
+func (dao TournamentDao) CompleteTournament(tournamentId int64, tx *sql.Tx) error {
+	query := `
+	UPDATE sportgether_schema.tournament SET status = $1, version = version + 1
+	WHERE id = $2 AND status = $3
+`
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := tx.ExecContext(ctx, query, TournamentCompleted, tournamentId, TournamentOngoing)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (dao TournamentDao) LinkMatchEvent(matchId int64, eventId int64, tx *sql.Tx) error {
+	query := `UPDATE sportgether_schema.tournament_match SET event_id = $1 WHERE id = $2`
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := tx.ExecContext(ctx, query, eventId, matchId)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// JoinMatchEventByEntries adds the members of the entries to the event of their match, except the ones in it already,
+// e.g. the host playing in the match.
+func (dao TournamentDao) JoinMatchEventByEntries(eventId int64, entryIds []int64, tx *sql.Tx) error {
+	placeholders := make([]string, 0, len(entryIds))
+	args := []any{eventId}
+	for _, entryId := range entryIds {
+		placeholders = append(placeholders, fmt.Sprintf("$%d", len(args)+1))
+		args = append(args, entryId)
+	}
+
+	query := fmt.Sprintf(`
+	INSERT INTO sportgether_schema.event_participant (eventid, participantid)
+	SELECT DISTINCT $1::bigint, tem.user_id FROM sportgether_schema.tournament_entry_member tem
+	WHERE tem.entry_id IN (%s) AND NOT EXISTS (
+	    SELECT 1 FROM sportgether_schema.event_participant ep WHERE ep.eventid = $1 AND ep.participantid = tem.user_id
+	)
+`, strings.Join(placeholders, ","))
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// CanJoinMatchEvent is true when the event is not of any tournament match, or the user is in one of the entries of the
+// match.
+func (dao TournamentDao) CanJoinMatchEvent(eventId int64, userId int64) (bool, error) {
+	query := `
+	SELECT NOT EXISTS (
+	    SELECT 1 FROM sportgether_schema.tournament_match tm WHERE tm.event_id = $1
+	) OR EXISTS (
+	    SELECT 1 FROM sportgether_schema.tournament_match tm
+	    INNER JOIN sportgether_schema.tournament_entry_member tem ON tem.entry_id IN (tm.home_entry_id, tm.away_entry_id)
+	    WHERE tm.event_id = $1 AND tem.user_id = $2
+	)
+`
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var canJoin bool
+	err := dao.db.QueryRowContext(ctx, query, eventId, userId).Scan(&canJoin)
+	if err != nil {
+		return false, err
+	}
+
+	return canJoin, nil
+}
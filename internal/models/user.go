@@ -232,6 +232,21 @@ func (userDao UserDao) DeleteUser(userId int64) error {
 	return nil
 }
 
+// IsAdmin tells whether the user is an admin of the app.
+func (userDao UserDao) IsAdmin(userId int64) (bool, error) {
+	query := `SELECT EXISTS (SELECT 1 FROM sportgether_schema.admin_user WHERE user_id = $1)`
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var isAdmin bool
+	err := userDao.db.QueryRowContext(ctx, query, userId).Scan(&isAdmin)
+	if err != nil {
+		return false, err
+	}
+
+	return isAdmin, nil
+}
+
 //	func (dao UserDao) UpdateProfileIconUrl(userId int64, url string) error {
 //		query := `
 //			UPDATE sportgether_schema.users u
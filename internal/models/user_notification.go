@@ -0,0 +1,263 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sportgether/internal/i18n"
+	"sportgether/tools"
+	"strings"
+	"time"
+)
+
+type UserNotificationDao struct {
+	db *sql.DB
+}
+
+type UserNotification struct {
+	ID        int64                `json:"id"`
+	UserId    int64                `json:"-"`
+	Kind      NotificationKind     `json:"kind"`
+	Data      map[string]string    `json:"data"`
+	Message   *NotificationMessage `json:"-"`
+	IsRead    bool                 `json:"isRead"`
+	CreatedAt time.Time            `json:"createdAt"`
+	// PushedTokens are the devices already reached when the push to some other devices of the user failed.
+	PushedTokens []string `json:"-"`
+}
+
+// Localize renders the title and subtitle into the data in the locale.
+func (notification *UserNotification) Localize(locale i18n.Locale) {
+	notification.Data = notification.Message.Render(notification.Data, locale)
+}
+
+// Payload is the data payload of the push message in the locale, so that the app can open the notification the same
+// way whether it comes from the push or the inbox.
+func (notification *UserNotification) Payload(locale i18n.Locale) map[string]string {
+	payload := notification.Message.Render(notification.Data, locale)
+	payload["notificationId"] = fmt.Sprintf("%d", notification.ID)
+	return payload
+}
+
+type UserNotificationResponse struct {
+	Notifications []*UserNotification `json:"notifications"`
+	NextCursorId  string              `json:"nextCursorId"`
+}
+
+type UserNotificationCursor struct {
+	LastId int64 `json:"lastId"`
+}
+
+// GetOutboxUserNotifications returns the notifications stored in the inboxes for the outbox notification, which are
+// not pushed yet, so that the retries only push to the recipients not reached yet.
+func (dao UserNotificationDao) GetOutboxUserNotifications(outboxId int64) ([]*UserNotification, error) {
+	query := `
+	SELECT id, user_id, kind, data, message, read_at IS NOT NULL, created_at, array_to_json(pushed_tokens)
+	FROM sportgether_schema.user_notification
+	WHERE outbox_id = $1 AND pushed_at IS NULL
+	ORDER BY id
+`
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := dao.db.QueryContext(ctx, query, outboxId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	notifications := []*UserNotification{}
+	for rows.Next() {
+		var pushedTokens []byte
+		notification, err := scanUserNotification(rows, &pushedTokens)
+		if err != nil {
+			return nil, err
+		}
+		err = json.Unmarshal(pushedTokens, &notification.PushedTokens)
+		if err != nil {
+			return nil, err
+		}
+		notifications = append(notifications, notification)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return notifications, nil
+}
+
+// MarkUserNotificationTokensPushed records the devices the push message of the notification has reached, while it
+// failed for some other devices of the user, which are retried.
+func (dao UserNotificationDao) MarkUserNotificationTokensPushed(notificationId int64, tokens []string) error {
+	query := `
+	UPDATE sportgether_schema.user_notification SET pushed_tokens = pushed_tokens || $2::text[]
+	WHERE id = $1
+`
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := dao.db.ExecContext(ctx, query, notificationId, tokens)
+	return err
+}
+
+// MarkUserNotificationPushed records that the push message of the notification has been sent to the devices.
+func (dao UserNotificationDao) MarkUserNotificationPushed(notificationId int64, pushedAt time.Time) error {
+	query := `
+	UPDATE sportgether_schema.user_notification SET pushed_at = $2
+	WHERE id = $1
+`
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := dao.db.ExecContext(ctx, query, notificationId, pushedAt)
+	return err
+}
+
+// GetUserNotifications returns the notifications of the user from the latest, after the cursor if given.
+func (dao UserNotificationDao) GetUserNotifications(userId int64, cursor *UserNotificationCursor, pageSize int) (*UserNotificationResponse, error) {
+	values := []any{userId}
+	whereClause := "WHERE user_id = $1"
+	if cursor != nil {
+		whereClause += fmt.Sprintf(" AND id < $%d", len(values)+1)
+		values = append(values, cursor.LastId)
+	}
+	values = append(values, pageSize)
+
+	query := fmt.Sprintf(`
+	SELECT id, user_id, kind, data, message, read_at IS NOT NULL, created_at
+	FROM sportgether_schema.user_notification
+	%s
+	ORDER BY id DESC LIMIT $%d
+`, whereClause, len(values))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := dao.db.QueryContext(ctx, query, values...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	notifications, err := scanUserNotifications(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &UserNotificationResponse{Notifications: notifications}
+	// No more page when this one is not full.
+	if len(notifications) == pageSize {
+		res.NextCursorId, err = tools.EncodeToBase32(UserNotificationCursor{LastId: notifications[len(notifications)-1].ID})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return res, nil
+}
+
+func (dao UserNotificationDao) CountUnreadUserNotifications(userId int64) (int, error) {
+	query := `SELECT COUNT(*) FROM sportgether_schema.user_notification WHERE user_id = $1 AND read_at IS NULL`
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var count int
+	err := dao.db.QueryRowContext(ctx, query, userId).Scan(&count)
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// MarkUserNotificationsRead marks the notifications of the user as read, or unread when read is false. The
+// notifications of other users are left untouched.
+func (dao UserNotificationDao) MarkUserNotificationsRead(userId int64, notificationIds []int64, read bool) error {
+	if len(notificationIds) == 0 {
+		return nil
+	}
+
+	var readAt *time.Time
+	if read {
+		now := time.Now()
+		readAt = &now
+	}
+
+	values := []any{readAt, userId}
+	placeholders := make([]string, 0, len(notificationIds))
+	for _, notificationId := range notificationIds {
+		placeholders = append(placeholders, fmt.Sprintf("$%d", len(values)+1))
+		values = append(values, notificationId)
+	}
+
+	// Keep the time it was first read.
+	query := fmt.Sprintf(`
+	UPDATE sportgether_schema.user_notification
+	SET read_at = CASE WHEN $1::timestamptz IS NULL THEN NULL ELSE COALESCE(read_at, $1) END
+	WHERE user_id = $2 AND id IN (%s)
+`, strings.Join(placeholders, ","))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := dao.db.ExecContext(ctx, query, values...)
+	return err
+}
+
+func (dao UserNotificationDao) MarkAllUserNotificationsRead(userId int64) error {
+	query := `UPDATE sportgether_schema.user_notification SET read_at = $1 WHERE user_id = $2 AND read_at IS NULL`
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := dao.db.ExecContext(ctx, query, time.Now(), userId)
+	return err
+}
+
+func scanUserNotifications(rows *sql.Rows) ([]*UserNotification, error) {
+	notifications := []*UserNotification{}
+	for rows.Next() {
+		notification, err := scanUserNotification(rows)
+		if err != nil {
+			return nil, err
+		}
+		notifications = append(notifications, notification)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return notifications, nil
+}
+
+// scanUserNotification scans the row of the notification, followed by the extra columns into extra.
+func scanUserNotification(rows *sql.Rows, extra ...any) (*UserNotification, error) {
+	notification := &UserNotification{}
+	var data, message []byte
+	dest := []any{
+		&notification.ID,
+		&notification.UserId,
+		&notification.Kind,
+		&data,
+		&message,
+		&notification.IsRead,
+		&notification.CreatedAt,
+	}
+	err := rows.Scan(append(dest, extra...)...)
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(data, &notification.Data)
+	if err != nil {
+		return nil, err
+	}
+	if message != nil {
+		notification.Message = &NotificationMessage{}
+		err = json.Unmarshal(message, notification.Message)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return notification, nil
+}
@@ -0,0 +1,59 @@
+package push
+
+import (
+	"context"
+	"sync"
+
+	"firebase.google.com/go/v4/messaging"
+)
+
+// MaxTokensPerMessage is the most tokens FCM accepts in one multicast message.
+const MaxTokensPerMessage = 500
+
+// Sender sends the push messages to the devices. *messaging.Client is the Sender in production, while FakeSender
+// can be used in tests and local development.
+type Sender interface {
+	SendEachForMulticast(ctx context.Context, message *messaging.MulticastMessage) (*messaging.BatchResponse, error)
+}
+
+// FakeSender records the messages instead of sending them. Tokens in FailedTokens fail with the given error, and
+// every other token succeeds.
+type FakeSender struct {
+	FailedTokens map[string]error
+	// Err fails the whole send when set.
+	Err error
+
+	mu       sync.Mutex
+	messages []*messaging.MulticastMessage
+}
+
+func (sender *FakeSender) SendEachForMulticast(ctx context.Context, message *messaging.MulticastMessage) (*messaging.BatchResponse, error) {
+	sender.mu.Lock()
+	defer sender.mu.Unlock()
+
+	if sender.Err != nil {
+		return nil, sender.Err
+	}
+	sender.messages = append(sender.messages, message)
+
+	response := &messaging.BatchResponse{}
+	for _, token := range message.Tokens {
+		if err, ok := sender.FailedTokens[token]; ok {
+			response.Responses = append(response.Responses, &messaging.SendResponse{Error: err})
+			response.FailureCount++
+			continue
+		}
+		response.Responses = append(response.Responses, &messaging.SendResponse{Success: true, MessageID: token})
+		response.SuccessCount++
+	}
+
+	return response, nil
+}
+
+// Messages returns the messages sent so far.
+func (sender *FakeSender) Messages() []*messaging.MulticastMessage {
+	sender.mu.Lock()
+	defer sender.mu.Unlock()
+
+	return append([]*messaging.MulticastMessage{}, sender.messages...)
+}
@@ -0,0 +1,107 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// Clock is injected so that jobs can be run against a fixed time in tests.
+type Clock interface {
+	Now() time.Time
+}
+
+type SystemClock struct{}
+
+func (SystemClock) Now() time.Time {
+	return time.Now()
+}
+
+// JobFunc receives the time of the tick, taken from the scheduler's clock.
+type JobFunc func(ctx context.Context, now time.Time) error
+
+type job struct {
+	name     string
+	interval time.Duration
+	run      JobFunc
+}
+
+type Scheduler struct {
+	clock  Clock
+	logger *slog.Logger
+	jobs   []job
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func New(clock Clock, logger *slog.Logger) *Scheduler {
+	return &Scheduler{
+		clock:  clock,
+		logger: logger,
+	}
+}
+
+// Every registers a job to be run on every interval. It must be called before Start.
+func (s *Scheduler) Every(name string, interval time.Duration, fn JobFunc) {
+	s.jobs = append(s.jobs, job{
+		name:     name,
+		interval: interval,
+		run:      fn,
+	})
+}
+
+func (s *Scheduler) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+
+	for _, j := range s.jobs {
+		s.wg.Add(1)
+		go func(j job) {
+			defer s.wg.Done()
+
+			ticker := time.NewTicker(j.interval)
+			defer ticker.Stop()
+
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+					s.RunOnce(ctx, j.name)
+				}
+			}
+		}(j)
+	}
+}
+
+// Stop waits for the running jobs to complete.
+func (s *Scheduler) Stop() {
+	if s.cancel != nil {
+		s.cancel()
+	}
+	s.wg.Wait()
+}
+
+// RunOnce runs the named job immediately with the current time of the clock.
+func (s *Scheduler) RunOnce(ctx context.Context, name string) {
+	for _, j := range s.jobs {
+		if j.name != name {
+			continue
+		}
+
+		func() {
+			defer func() {
+				if err := recover(); err != nil {
+					s.logger.Error(fmt.Sprintf("%s", err), "JOB", j.name)
+				}
+			}()
+
+			err := j.run(ctx, s.clock.Now())
+			if err != nil {
+				s.logger.Error(err.Error(), "JOB", j.name)
+			}
+		}()
+	}
+}
@@ -0,0 +1,234 @@
+package teams
+
+import (
+	"errors"
+	"math/rand"
+	"slices"
+)
+
+var (
+	NotEnoughPlayersError = errors.New("there must be at least one player for each team")
+	GroupTooLargeError    = errors.New("players kept together are more than the size of a team")
+	UnknownPlayerError    = errors.New("player kept together is not in the event")
+	CannotFitGroupsError  = errors.New("players kept together cannot be split into teams of even size")
+)
+
+// genderWeight decides how much an uneven gender mix counts against the rating gap of the teams.
+const genderWeight = 4.0
+
+type Player struct {
+	UserId int64
+	Rating float64
+	Gender string
+}
+
+type Options struct {
+	TeamCount     int
+	BalanceGender bool
+	// KeepTogether are the pairs of players who must be in the same team, e.g. friends who came together.
+	KeepTogether [][2]int64
+}
+
+type Team struct {
+	Players []Player
+	Rating  float64
+}
+
+// unit is a group of players who must stay in the same team.
+type unit struct {
+	players []Player
+	rating  float64
+	genders map[string]int
+}
+
+// Generate splits the players into balanced teams. The team sizes differ by at most one, and the total ratings are
+// kept as close as possible. Different random sources give different teams of similar balance, which is how the
+// teams are reshuffled.
+func Generate(players []Player, options Options, random *rand.Rand) ([]Team, error) {
+	if options.TeamCount < 2 || len(players) < options.TeamCount {
+		return nil, NotEnoughPlayersError
+	}
+
+	units, err := groupPlayers(players, options.KeepTogether)
+	if err != nil {
+		return nil, err
+	}
+
+	maxTeamSize := (len(players) + options.TeamCount - 1) / options.TeamCount
+	for _, unit := range units {
+		if len(unit.players) > maxTeamSize {
+			return nil, GroupTooLargeError
+		}
+	}
+
+	// Place the larger groups first, as they are harder to fit. The order within the same size is random, and the
+	// swaps below even out the ratings.
+	random.Shuffle(len(units), func(i, j int) {
+		units[i], units[j] = units[j], units[i]
+	})
+	slices.SortStableFunc(units, func(a, b *unit) int {
+		return len(b.players) - len(a.players)
+	})
+
+	assignment := make([][]*unit, options.TeamCount)
+	for _, unit := range units {
+		best := -1
+		for team := range assignment {
+			if teamSize(assignment[team])+len(unit.players) > maxTeamSize {
+				continue
+			}
+			// Fill the smallest team first, so that the team sizes stay even.
+			if best < 0 || teamSize(assignment[team]) < teamSize(assignment[best]) ||
+				(teamSize(assignment[team]) == teamSize(assignment[best]) && teamRating(assignment[team]) < teamRating(assignment[best])) {
+				best = team
+			}
+		}
+		if best < 0 {
+			return nil, CannotFitGroupsError
+		}
+		assignment[best] = append(assignment[best], unit)
+	}
+
+	improve(assignment, options.BalanceGender)
+
+	teams := make([]Team, 0, len(assignment))
+	for _, units := range assignment {
+		team := Team{Players: []Player{}}
+		for _, unit := range units {
+			team.Players = append(team.Players, unit.players...)
+			team.Rating += unit.rating
+		}
+		teams = append(teams, team)
+	}
+
+	return teams, nil
+}
+
+func groupPlayers(players []Player, keepTogether [][2]int64) ([]*unit, error) {
+	parent := map[int64]int64{}
+	for _, player := range players {
+		parent[player.UserId] = player.UserId
+	}
+
+	var find func(userId int64) int64
+	find = func(userId int64) int64 {
+		if parent[userId] != userId {
+			parent[userId] = find(parent[userId])
+		}
+		return parent[userId]
+	}
+
+	for _, pair := range keepTogether {
+		_, ok0 := parent[pair[0]]
+		_, ok1 := parent[pair[1]]
+		if !ok0 || !ok1 {
+			return nil, UnknownPlayerError
+		}
+		parent[find(pair[0])] = find(pair[1])
+	}
+
+	byRoot := map[int64]*unit{}
+	units := []*unit{}
+	for _, player := range players {
+		root := find(player.UserId)
+		u, ok := byRoot[root]
+		if !ok {
+			u = &unit{genders: map[string]int{}}
+			byRoot[root] = u
+			units = append(units, u)
+		}
+		u.players = append(u.players, player)
+		u.rating += player.Rating
+		u.genders[player.Gender]++
+	}
+
+	return units, nil
+}
+
+// improve swaps the groups of the same size between teams as long as it makes the teams more balanced.
+func improve(assignment [][]*unit, balanceGender bool) {
+	const maxRounds = 100
+
+	current := cost(assignment, balanceGender)
+	for round := 0; round < maxRounds; round++ {
+		improved := false
+		for a := 0; a < len(assignment); a++ {
+			for b := a + 1; b < len(assignment); b++ {
+				for i := range assignment[a] {
+					for j := range assignment[b] {
+						if len(assignment[a][i].players) != len(assignment[b][j].players) {
+							continue
+						}
+
+						assignment[a][i], assignment[b][j] = assignment[b][j], assignment[a][i]
+						next := cost(assignment, balanceGender)
+						if next < current-1e-9 {
+							current = next
+							improved = true
+							continue
+						}
+						assignment[a][i], assignment[b][j] = assignment[b][j], assignment[a][i]
+					}
+				}
+			}
+		}
+		if !improved {
+			return
+		}
+	}
+}
+
+// cost is the spread of the team ratings, plus the spread of each gender across the teams when balancing gender.
+func cost(assignment [][]*unit, balanceGender bool) float64 {
+	ratings := make([]float64, len(assignment))
+	genderCounts := map[string][]float64{}
+	for team, units := range assignment {
+		ratings[team] = teamRating(units)
+		for _, unit := range units {
+			for gender, count := range unit.genders {
+				if _, ok := genderCounts[gender]; !ok {
+					genderCounts[gender] = make([]float64, len(assignment))
+				}
+				genderCounts[gender][team] += float64(count)
+			}
+		}
+	}
+
+	total := variance(ratings)
+	if balanceGender {
+		for _, counts := range genderCounts {
+			total += genderWeight * variance(counts)
+		}
+	}
+	return total
+}
+
+func variance(values []float64) float64 {
+	mean := 0.0
+	for _, value := range values {
+		mean += value
+	}
+	mean /= float64(len(values))
+
+	total := 0.0
+	for _, value := range values {
+		total += (value - mean) * (value - mean)
+	}
+	return total / float64(len(values))
+}
+
+func teamSize(units []*unit) int {
+	size := 0
+	for _, unit := range units {
+		size += len(unit.players)
+	}
+	return size
+}
+
+func teamRating(units []*unit) float64 {
+	rating := 0.0
+	for _, unit := range units {
+		rating += unit.rating
+	}
+	return rating
+}
@@ -0,0 +1,173 @@
+package teams
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func newPlayers(ratings ...float64) []Player {
+	players := make([]Player, 0, len(ratings))
+	for index, rating := range ratings {
+		players = append(players, Player{UserId: int64(index + 1), Rating: rating, Gender: "MALE"})
+	}
+	return players
+}
+
+// teamOf returns the team of each player.
+func teamOf(teams []Team) map[int64]int {
+	teamOf := map[int64]int{}
+	for index, team := range teams {
+		for _, player := range team.Players {
+			teamOf[player.UserId] = index
+		}
+	}
+	return teamOf
+}
+
+func TestGenerateInvalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		players []Player
+		options Options
+		wantErr error
+	}{
+		{name: "one team", players: newPlayers(1, 2), options: Options{TeamCount: 1}, wantErr: NotEnoughPlayersError},
+		{name: "fewer players than teams", players: newPlayers(1, 2), options: Options{TeamCount: 3}, wantErr: NotEnoughPlayersError},
+		{name: "unknown player", players: newPlayers(1, 2, 3, 4), options: Options{TeamCount: 2, KeepTogether: [][2]int64{{1, 9}}}, wantErr: UnknownPlayerError},
+		{name: "group larger than a team", players: newPlayers(1, 2, 3, 4), options: Options{TeamCount: 2, KeepTogether: [][2]int64{{1, 2}, {2, 3}}}, wantErr: GroupTooLargeError},
+		{name: "groups cannot fit", players: newPlayers(1, 2, 3, 4, 5, 6), options: Options{TeamCount: 2, KeepTogether: [][2]int64{{1, 2}, {3, 4}, {5, 6}}}, wantErr: CannotFitGroupsError},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := Generate(test.players, test.options, rand.New(rand.NewSource(1)))
+			if !errors.Is(err, test.wantErr) {
+				t.Errorf("got error %v, want %v", err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	tests := []struct {
+		name    string
+		players []Player
+		options Options
+		// maxGap is the most the total ratings of the teams may differ.
+		maxGap float64
+	}{
+		{name: "two teams", players: newPlayers(1, 2, 3, 4, 5, 6, 7, 8), options: Options{TeamCount: 2}, maxGap: 0},
+		{name: "uneven sizes", players: newPlayers(5, 5, 5, 5, 5, 5, 5), options: Options{TeamCount: 2}, maxGap: 5},
+		// The swaps stop at the first teams which no single swap improves, which are close but not always the best.
+		{name: "three teams", players: newPlayers(9, 8, 7, 3, 2, 1, 5, 5, 5), options: Options{TeamCount: 3}, maxGap: 2},
+		{
+			name:    "kept together",
+			players: newPlayers(10, 10, 1, 1, 5, 5),
+			options: Options{TeamCount: 2, KeepTogether: [][2]int64{{1, 3}, {2, 4}}},
+			maxGap:  0,
+		},
+	}
+
+	for _, test := range tests {
+		for seed := int64(1); seed <= 20; seed++ {
+			t.Run(fmt.Sprintf("%s seed %d", test.name, seed), func(t *testing.T) {
+				teams, err := Generate(test.players, test.options, rand.New(rand.NewSource(seed)))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(teams) != test.options.TeamCount {
+					t.Fatalf("got %d teams, want %d", len(teams), test.options.TeamCount)
+				}
+
+				// Every player is in exactly one team.
+				if got := teamOf(teams); len(got) != len(test.players) {
+					t.Errorf("got %d players in the teams, want %d", len(got), len(test.players))
+				}
+
+				minSize, maxSize := math.MaxInt, 0
+				minRating, maxRating := math.Inf(1), math.Inf(-1)
+				total := 0
+				for _, team := range teams {
+					total += len(team.Players)
+					minSize, maxSize = min(minSize, len(team.Players)), max(maxSize, len(team.Players))
+					minRating, maxRating = math.Min(minRating, team.Rating), math.Max(maxRating, team.Rating)
+
+					rating := 0.0
+					for _, player := range team.Players {
+						rating += player.Rating
+					}
+					if rating != team.Rating {
+						t.Errorf("got team rating %v, want the sum %v of the players", team.Rating, rating)
+					}
+				}
+				if total != len(test.players) {
+					t.Errorf("got %d players in the teams, want %d", total, len(test.players))
+				}
+				if maxSize-minSize > 1 {
+					t.Errorf("got team sizes from %d to %d, want them to differ by at most 1", minSize, maxSize)
+				}
+				if gap := maxRating - minRating; gap > test.maxGap {
+					t.Errorf("got ratings from %v to %v, want a gap of at most %v", minRating, maxRating, test.maxGap)
+				}
+
+				teamOf := teamOf(teams)
+				for _, pair := range test.options.KeepTogether {
+					if teamOf[pair[0]] != teamOf[pair[1]] {
+						t.Errorf("got players %d and %d in different teams, want them together", pair[0], pair[1])
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestGenerateBalancesGender(t *testing.T) {
+	players := []Player{}
+	for index := 0; index < 8; index++ {
+		gender := "MALE"
+		if index%2 == 0 {
+			gender = "FEMALE"
+		}
+		// The same rating, so that only the genders tell the teams apart.
+		players = append(players, Player{UserId: int64(index + 1), Rating: 5, Gender: gender})
+	}
+
+	for seed := int64(1); seed <= 20; seed++ {
+		teams, err := Generate(players, Options{TeamCount: 2, BalanceGender: true}, rand.New(rand.NewSource(seed)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for index, team := range teams {
+			women := 0
+			for _, player := range team.Players {
+				if player.Gender == "FEMALE" {
+					women++
+				}
+			}
+			if women != 2 {
+				t.Errorf("got %d women in team %d with seed %d, want 2", women, index, seed)
+			}
+		}
+	}
+}
+
+func TestGenerateIsDeterministic(t *testing.T) {
+	players := newPlayers(9, 8, 7, 6, 5, 4, 3, 2, 1, 1)
+
+	first, err := Generate(players, Options{TeamCount: 2}, rand.New(rand.NewSource(7)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := Generate(players, Options{TeamCount: 2}, rand.New(rand.NewSource(7)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The same random source gives the same teams, so that the teams are only reshuffled on purpose.
+	if fmt.Sprint(first) != fmt.Sprint(second) {
+		t.Errorf("got teams %v then %v with the same seed", first, second)
+	}
+}
@@ -0,0 +1,151 @@
+package tlscert
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+// Reloader keeps the certificate loaded from the files, so that a renewed certificate is served without restart. It is
+// reloaded when either file changes, or on SIGHUP. A reload with an invalid certificate, e.g. when only one of the files
+// has been replaced so far, is rejected, and the current certificate is kept.
+type Reloader struct {
+	certFile string
+	keyFile  string
+	logger   *slog.Logger
+	current  atomic.Pointer[tls.Certificate]
+	// Guards the reloads, so that the file change and the SIGHUP never reload at the same time.
+	mu       sync.Mutex
+	modTimes [2]time.Time
+	cancel   context.CancelFunc
+	wg       sync.WaitGroup
+}
+
+// NewReloader loads the certificate, which fails when the files are invalid, so that the server never starts without
+// one.
+func NewReloader(certFile string, keyFile string, logger *slog.Logger) (*Reloader, error) {
+	reloader := &Reloader{
+		certFile: certFile,
+		keyFile:  keyFile,
+		logger:   logger,
+	}
+
+	err := reloader.Reload()
+	if err != nil {
+		return nil, err
+	}
+
+	return reloader, nil
+}
+
+// GetCertificate is for tls.Config, which returns the latest certificate for every handshake.
+func (r *Reloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return r.current.Load(), nil
+}
+
+func (r *Reloader) Reload() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Kept even when the reload is rejected, so that the bad files are only reloaded again after they change.
+	r.modTimes = r.readModTimes()
+
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("invalid certificate %s: %w", r.certFile, err)
+	}
+	cert.Leaf, err = x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		return fmt.Errorf("invalid certificate %s: %w", r.certFile, err)
+	}
+
+	r.current.Store(&cert)
+	return nil
+}
+
+// NotAfter is when the current certificate expires.
+func (r *Reloader) NotAfter() time.Time {
+	return r.current.Load().Leaf.NotAfter
+}
+
+// Watch reloads the certificate when the files change, which is checked every interval, or on SIGHUP. It must be
+// stopped by Stop.
+func (r *Reloader) Watch(interval time.Duration) {
+	ctx, cancel := context.WithCancel(context.Background())
+	r.cancel = cancel
+
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		defer signal.Stop(hangup)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-hangup:
+				r.reload("SIGHUP")
+			case <-ticker.C:
+				if r.changed() {
+					r.reload("file change")
+				}
+			}
+		}
+	}()
+}
+
+func (r *Reloader) Stop() {
+	if r.cancel == nil {
+		return
+	}
+	r.cancel()
+	r.wg.Wait()
+}
+
+func (r *Reloader) reload(reason string) {
+	err := r.Reload()
+	if err != nil {
+		r.logger.Error("rejected TLS certificate reload, keeping the current certificate", "reason", reason, "error", err.Error())
+		return
+	}
+	r.logger.Info("reloaded TLS certificate", "reason", reason, "notAfter", r.NotAfter())
+}
+
+func (r *Reloader) changed() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	modTimes := r.readModTimes()
+	for i := range modTimes {
+		if !modTimes[i].Equal(r.modTimes[i]) {
+			return true
+		}
+	}
+	return false
+}
+
+// readModTimes returns the modification time of the files, which is zero for the file missing.
+func (r *Reloader) readModTimes() [2]time.Time {
+	modTimes := [2]time.Time{}
+	for i, file := range []string{r.certFile, r.keyFile} {
+		info, err := os.Stat(file)
+		if err == nil {
+			modTimes[i] = info.ModTime()
+		}
+	}
+	return modTimes
+}
@@ -0,0 +1,193 @@
+package tlscert
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"log/slog"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeSelfSigned writes a self-signed certificate for localhost, with the serial to tell the certificates apart.
+func writeSelfSigned(t *testing.T, dir string, serial int64) (certFile string, keyFile string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		DNSNames:     []string{"localhost"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	certFile = filepath.Join(dir, "cert.pem")
+	keyFile = filepath.Join(dir, "key.pem")
+	writeFile(t, certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+	writeFile(t, keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}))
+	return certFile, keyFile
+}
+
+// writeFile replaces the file by rename, as the certificate renewal does, so that it is never read half written.
+func writeFile(t *testing.T, path string, content []byte) {
+	t.Helper()
+
+	temp := path + ".tmp"
+	err := os.WriteFile(temp, content, 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.Rename(temp, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func newTestReloader(t *testing.T, certFile string, keyFile string) *Reloader {
+	t.Helper()
+
+	reloader, err := NewReloader(certFile, keyFile, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return reloader
+}
+
+// servedSerial makes a handshake with the server over HTTP/2, and returns the serial of the certificate served.
+func servedSerial(t *testing.T, url string) int64 {
+	t.Helper()
+
+	client := &http.Client{Transport: &http.Transport{
+		TLSClientConfig:   &tls.Config{InsecureSkipVerify: true},
+		ForceAttemptHTTP2: true,
+		DisableKeepAlives: true,
+	}}
+	response, err := client.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer response.Body.Close()
+
+	if response.ProtoMajor != 2 {
+		t.Errorf("got protocol %s, want HTTP/2", response.Proto)
+	}
+	return response.TLS.PeerCertificates[0].SerialNumber.Int64()
+}
+
+// startServer serves HTTPS with the certificate of the reloader, as the server does, and returns the url.
+func startServer(t *testing.T, reloader *Reloader) string {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := &http.Server{
+		Handler:   http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}),
+		TLSConfig: &tls.Config{GetCertificate: reloader.GetCertificate, NextProtos: []string{"h2", "http/1.1"}},
+	}
+	go server.ServeTLS(listener, "", "")
+	t.Cleanup(func() {
+		server.Close()
+	})
+
+	return "https://" + listener.Addr().String()
+}
+
+func TestReloaderServesCertificate(t *testing.T) {
+	certFile, keyFile := writeSelfSigned(t, t.TempDir(), 1)
+	reloader := newTestReloader(t, certFile, keyFile)
+
+	url := startServer(t, reloader)
+
+	if serial := servedSerial(t, url); serial != 1 {
+		t.Errorf("got serial %d, want 1", serial)
+	}
+}
+
+func TestReloaderReloadsReplacedFiles(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := writeSelfSigned(t, dir, 1)
+	reloader := newTestReloader(t, certFile, keyFile)
+
+	url := startServer(t, reloader)
+
+	// The mod time may not change within the resolution of the file system.
+	time.Sleep(10 * time.Millisecond)
+	writeSelfSigned(t, dir, 2)
+
+	reloader.Watch(10 * time.Millisecond)
+	defer reloader.Stop()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for reloader.current.Load().Leaf.SerialNumber.Int64() != 2 {
+		if time.Now().After(deadline) {
+			t.Fatal("the replaced certificate is not reloaded")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if serial := servedSerial(t, url); serial != 2 {
+		t.Errorf("got serial %d, want 2", serial)
+	}
+}
+
+func TestReloaderRejectsMismatchedKey(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := writeSelfSigned(t, dir, 1)
+	reloader := newTestReloader(t, certFile, keyFile)
+
+	// Only the key of another certificate has been replaced so far.
+	otherDir := t.TempDir()
+	_, otherKeyFile := writeSelfSigned(t, otherDir, 2)
+	otherKey, err := os.ReadFile(otherKeyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, keyFile, otherKey)
+
+	err = reloader.Reload()
+	if err == nil {
+		t.Fatal("got no error for the mismatched key")
+	}
+
+	url := startServer(t, reloader)
+
+	if serial := servedSerial(t, url); serial != 1 {
+		t.Errorf("got serial %d, want the current certificate 1 kept", serial)
+	}
+}
+
+func TestNewReloaderRejectsMismatchedKey(t *testing.T) {
+	certFile, _ := writeSelfSigned(t, t.TempDir(), 1)
+	_, otherKeyFile := writeSelfSigned(t, t.TempDir(), 2)
+
+	_, err := NewReloader(certFile, otherKeyFile, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err == nil {
+		t.Fatal("got no error for the mismatched key")
+	}
+}
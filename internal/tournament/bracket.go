@@ -0,0 +1,170 @@
+package tournament
+
+import (
+	"errors"
+)
+
+type Format string
+
+var (
+	SingleElimination = Format("SINGLE_ELIMINATION")
+	RoundRobin        = Format("ROUND_ROBIN")
+)
+
+func (format Format) IsValid() bool {
+	return format == SingleElimination || format == RoundRobin
+}
+
+var NotEnoughEntriesError = errors.New("at least 2 entries are needed")
+
+// Match is a slot in the bracket. Home and Away are nil when the opponent is not decided yet, or when it is a bye.
+type Match struct {
+	Round  int
+	Index  int
+	Home   *int64
+	Away   *int64
+	Bye    bool
+	Winner *int64
+}
+
+// Generate builds all the matches of the tournament, where the entries are given in the seeding order.
+func Generate(format Format, entries []int64) ([]*Match, error) {
+	if len(entries) < 2 {
+		return nil, NotEnoughEntriesError
+	}
+
+	switch format {
+	case SingleElimination:
+		return generateSingleElimination(entries), nil
+	case RoundRobin:
+		return generateRoundRobin(entries), nil
+	default:
+		return nil, errors.New("unknown tournament format " + string(format))
+	}
+}
+
+// EliminationRoundCount is the number of rounds for the entries to get a champion.
+func EliminationRoundCount(entryCount int) int {
+	rounds := 0
+	for size := 1; size < entryCount; size *= 2 {
+		rounds++
+	}
+	return rounds
+}
+
+// NextMatch tells where the winner of the elimination match goes. ok is false for the final.
+func NextMatch(round int, index int, roundCount int) (nextRound int, nextIndex int, home bool, ok bool) {
+	if round >= roundCount {
+		return 0, 0, false, false
+	}
+	return round + 1, index / 2, index%2 == 0, true
+}
+
+// generateSingleElimination pairs the seeds so that the top seeds only meet in the later rounds, e.g. 1 vs 8, 4 vs 5,
+// 2 vs 7, 3 vs 6. The bracket is padded to the power of 2 with byes, which go to the top seeds and advance right away.
+func generateSingleElimination(entries []int64) []*Match {
+	roundCount := EliminationRoundCount(len(entries))
+	seeds := seedOrder(1 << roundCount)
+
+	matches := []*Match{}
+	byRound := map[int][]*Match{}
+	for round := 1; round <= roundCount; round++ {
+		matchCount := 1 << (roundCount - round)
+		for index := 0; index < matchCount; index++ {
+			match := &Match{Round: round, Index: index}
+			matches = append(matches, match)
+			byRound[round] = append(byRound[round], match)
+		}
+	}
+
+	for index, match := range byRound[1] {
+		match.Home = seedEntry(entries, seeds[2*index])
+		match.Away = seedEntry(entries, seeds[2*index+1])
+
+		if match.Home != nil && match.Away != nil {
+			continue
+		}
+
+		match.Bye = true
+		match.Winner = match.Home
+		if match.Winner == nil {
+			match.Winner = match.Away
+		}
+
+		nextRound, nextIndex, home, ok := NextMatch(1, index, roundCount)
+		if !ok {
+			continue
+		}
+		next := byRound[nextRound][nextIndex]
+		if home {
+			next.Home = match.Winner
+		} else {
+			next.Away = match.Winner
+		}
+	}
+
+	return matches
+}
+
+// seedOrder returns the seeds (starting from 1) in the bracket positions.
+func seedOrder(size int) []int {
+	order := []int{1}
+	for length := 2; length <= size; length *= 2 {
+		next := make([]int, 0, length)
+		for _, seed := range order {
+			next = append(next, seed, length+1-seed)
+		}
+		order = next
+	}
+	return order
+}
+
+func seedEntry(entries []int64, seed int) *int64 {
+	if seed > len(entries) {
+		return nil
+	}
+	entry := entries[seed-1]
+	return &entry
+}
+
+// generateRoundRobin uses the circle method, so that every entry plays once in each round. With an odd number of
+// entries, one entry rests in each round.
+func generateRoundRobin(entries []int64) []*Match {
+	slots := make([]*int64, 0, len(entries)+1)
+	for index := range entries {
+		slots = append(slots, &entries[index])
+	}
+	if len(slots)%2 == 1 {
+		slots = append(slots, nil)
+	}
+
+	matches := []*Match{}
+	slotCount := len(slots)
+	for round := 1; round < slotCount; round++ {
+		index := 0
+		for i := 0; i < slotCount/2; i++ {
+			home, away := slots[i], slots[slotCount-1-i]
+			if home == nil || away == nil {
+				continue
+			}
+			// Swap sides every other round, so that the first slot is not always at home.
+			if round%2 == 0 {
+				home, away = away, home
+			}
+			matches = append(matches, &Match{Round: round, Index: index, Home: copyEntry(home), Away: copyEntry(away)})
+			index++
+		}
+
+		// Keep the first slot, and rotate the rest clockwise.
+		last := slots[slotCount-1]
+		copy(slots[2:], slots[1:slotCount-1])
+		slots[1] = last
+	}
+
+	return matches
+}
+
+func copyEntry(entry *int64) *int64 {
+	value := *entry
+	return &value
+}
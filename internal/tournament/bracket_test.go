@@ -0,0 +1,223 @@
+package tournament
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func formatEntry(entry *int64) string {
+	if entry == nil {
+		return "?"
+	}
+	return fmt.Sprint(*entry)
+}
+
+// formatMatches writes the matches as round.index home-away, with the bye and its winner, e.g. 1.0 1-? bye 1.
+func formatMatches(matches []*Match) []string {
+	formatted := make([]string, 0, len(matches))
+	for _, match := range matches {
+		value := fmt.Sprintf("%d.%d %s-%s", match.Round, match.Index, formatEntry(match.Home), formatEntry(match.Away))
+		if match.Bye {
+			value += " bye " + formatEntry(match.Winner)
+		}
+		formatted = append(formatted, value)
+	}
+	return formatted
+}
+
+func entryIds(count int) []int64 {
+	entries := make([]int64, 0, count)
+	for id := int64(1); id <= int64(count); id++ {
+		entries = append(entries, id)
+	}
+	return entries
+}
+
+func TestGenerateInvalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  Format
+		entries []int64
+		wantErr error
+	}{
+		{name: "no entry", format: SingleElimination, entries: nil, wantErr: NotEnoughEntriesError},
+		{name: "one entry", format: RoundRobin, entries: []int64{1}, wantErr: NotEnoughEntriesError},
+		{name: "unknown format", format: Format("DOUBLE_ELIMINATION"), entries: []int64{1, 2}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := Generate(test.format, test.entries)
+			if err == nil {
+				t.Fatal("got no error")
+			}
+			if test.wantErr != nil && !errors.Is(err, test.wantErr) {
+				t.Errorf("got error %v, want %v", err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestEliminationRoundCount(t *testing.T) {
+	tests := []struct {
+		entryCount int
+		want       int
+	}{
+		{entryCount: 2, want: 1},
+		{entryCount: 3, want: 2},
+		{entryCount: 4, want: 2},
+		{entryCount: 5, want: 3},
+		{entryCount: 8, want: 3},
+		{entryCount: 9, want: 4},
+		{entryCount: 16, want: 4},
+	}
+
+	for _, test := range tests {
+		if got := EliminationRoundCount(test.entryCount); got != test.want {
+			t.Errorf("got %d rounds for %d entries, want %d", got, test.entryCount, test.want)
+		}
+	}
+}
+
+func TestSeedOrder(t *testing.T) {
+	tests := []struct {
+		size int
+		want []int
+	}{
+		{size: 2, want: []int{1, 2}},
+		{size: 4, want: []int{1, 4, 2, 3}},
+		{size: 8, want: []int{1, 8, 4, 5, 2, 7, 3, 6}},
+	}
+
+	for _, test := range tests {
+		if got := seedOrder(test.size); fmt.Sprint(got) != fmt.Sprint(test.want) {
+			t.Errorf("got seeds %v for %d, want %v", got, test.size, test.want)
+		}
+	}
+}
+
+func TestNextMatch(t *testing.T) {
+	tests := []struct {
+		round      int
+		index      int
+		roundCount int
+		wantRound  int
+		wantIndex  int
+		wantHome   bool
+		wantOk     bool
+	}{
+		{round: 1, index: 0, roundCount: 3, wantRound: 2, wantIndex: 0, wantHome: true, wantOk: true},
+		{round: 1, index: 1, roundCount: 3, wantRound: 2, wantIndex: 0, wantHome: false, wantOk: true},
+		{round: 1, index: 3, roundCount: 3, wantRound: 2, wantIndex: 1, wantHome: false, wantOk: true},
+		{round: 2, index: 1, roundCount: 3, wantRound: 3, wantIndex: 0, wantHome: false, wantOk: true},
+		{round: 3, index: 0, roundCount: 3, wantOk: false},
+	}
+
+	for _, test := range tests {
+		round, index, home, ok := NextMatch(test.round, test.index, test.roundCount)
+		if round != test.wantRound || index != test.wantIndex || home != test.wantHome || ok != test.wantOk {
+			t.Errorf("got %d.%d home %t ok %t after %d.%d, want %d.%d home %t ok %t", round, index, home, ok, test.round, test.index, test.wantRound, test.wantIndex, test.wantHome, test.wantOk)
+		}
+	}
+}
+
+func TestGenerateSingleElimination(t *testing.T) {
+	tests := []struct {
+		name       string
+		entryCount int
+		want       []string
+	}{
+		{name: "final only", entryCount: 2, want: []string{"1.0 1-2"}},
+		{name: "bye to the top seed", entryCount: 3, want: []string{"1.0 1-? bye 1", "1.1 2-3", "2.0 1-?"}},
+		{name: "full bracket", entryCount: 4, want: []string{"1.0 1-4", "1.1 2-3", "2.0 ?-?"}},
+		{
+			name:       "byes to the top 3 seeds",
+			entryCount: 5,
+			want: []string{
+				"1.0 1-? bye 1", "1.1 4-5", "1.2 2-? bye 2", "1.3 3-? bye 3",
+				"2.0 1-?", "2.1 2-3",
+				"3.0 ?-?",
+			},
+		},
+		{
+			name:       "top seeds meet last",
+			entryCount: 8,
+			want: []string{
+				"1.0 1-8", "1.1 4-5", "1.2 2-7", "1.3 3-6",
+				"2.0 ?-?", "2.1 ?-?",
+				"3.0 ?-?",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			matches, err := Generate(SingleElimination, entryIds(test.entryCount))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := formatMatches(matches); fmt.Sprint(got) != fmt.Sprint(test.want) {
+				t.Errorf("got matches %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestGenerateRoundRobin(t *testing.T) {
+	matches, err := Generate(RoundRobin, entryIds(4))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The first entry stays, and swaps sides every other round.
+	want := []string{"1.0 1-4", "1.1 2-3", "2.0 3-1", "2.1 2-4", "3.0 1-2", "3.1 3-4"}
+	if got := formatMatches(matches); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("got matches %v, want %v", got, want)
+	}
+
+	tests := []struct {
+		entryCount int
+		wantRounds int
+	}{
+		{entryCount: 2, wantRounds: 1},
+		{entryCount: 3, wantRounds: 3},
+		{entryCount: 6, wantRounds: 5},
+		{entryCount: 7, wantRounds: 7},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("%d entries", test.entryCount), func(t *testing.T) {
+			matches, err := Generate(RoundRobin, entryIds(test.entryCount))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// Every entry plays every other entry once, and at most once in each round.
+			pairs := map[[2]int64]int{}
+			playedInRound := map[[2]int64]bool{}
+			rounds := 0
+			for _, match := range matches {
+				if match.Home == nil || match.Away == nil || match.Bye {
+					t.Fatalf("got match %+v without both entries", match)
+				}
+				home, away := *match.Home, *match.Away
+				pairs[[2]int64{min(home, away), max(home, away)}]++
+				for _, entry := range []int64{home, away} {
+					key := [2]int64{int64(match.Round), entry}
+					if playedInRound[key] {
+						t.Errorf("got entry %d playing twice in round %d", entry, match.Round)
+					}
+					playedInRound[key] = true
+				}
+				rounds = max(rounds, match.Round)
+			}
+
+			if wantPairs := test.entryCount * (test.entryCount - 1) / 2; len(pairs) != wantPairs || len(matches) != wantPairs {
+				t.Errorf("got %d matches of %d pairs, want %d", len(matches), len(pairs), wantPairs)
+			}
+			if rounds != test.wantRounds {
+				t.Errorf("got %d rounds, want %d", rounds, test.wantRounds)
+			}
+		})
+	}
+}
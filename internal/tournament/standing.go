@@ -0,0 +1,141 @@
+package tournament
+
+import (
+	"slices"
+)
+
+const (
+	winPoints  = 3
+	drawPoints = 1
+)
+
+// Result is a completed match.
+type Result struct {
+	Round     int
+	Home      int64
+	Away      int64
+	HomeScore int
+	AwayScore int
+	Winner    *int64
+}
+
+type Standing struct {
+	EntryId      int64 `json:"entryId"`
+	Rank         *int  `json:"rank"`
+	Played       int   `json:"played"`
+	Won          int   `json:"won"`
+	Drawn        int   `json:"drawn"`
+	Lost         int   `json:"lost"`
+	ScoreFor     int   `json:"scoreFor"`
+	ScoreAgainst int   `json:"scoreAgainst"`
+	Points       int   `json:"points"`
+	Eliminated   bool  `json:"eliminated"`
+}
+
+// Standings ranks the entries. Round robin ranks by points, then score difference, then score. Single elimination
+// ranks by how far the entry went, where entries knocked out in the same round share the rank, e.g. both losing
+// semi-finalists are 3rd. The entries still in the running have no rank yet.
+func Standings(format Format, entries []int64, results []Result) []*Standing {
+	standings := make([]*Standing, 0, len(entries))
+	byEntry := map[int64]*Standing{}
+	for _, entry := range entries {
+		standing := &Standing{EntryId: entry}
+		standings = append(standings, standing)
+		byEntry[entry] = standing
+	}
+
+	// The round where the entry was knocked out.
+	knockedOutRound := map[int64]int{}
+	championDecided := false
+	roundCount := EliminationRoundCount(len(entries))
+
+	for _, result := range results {
+		home, homeOk := byEntry[result.Home]
+		away, awayOk := byEntry[result.Away]
+		if !homeOk || !awayOk {
+			continue
+		}
+
+		home.Played++
+		away.Played++
+		home.ScoreFor += result.HomeScore
+		home.ScoreAgainst += result.AwayScore
+		away.ScoreFor += result.AwayScore
+		away.ScoreAgainst += result.HomeScore
+
+		switch {
+		case result.Winner != nil && *result.Winner == result.Home:
+			home.Won++
+			home.Points += winPoints
+			away.Lost++
+			knockedOutRound[result.Away] = result.Round
+		case result.Winner != nil && *result.Winner == result.Away:
+			away.Won++
+			away.Points += winPoints
+			home.Lost++
+			knockedOutRound[result.Home] = result.Round
+		default:
+			home.Drawn++
+			away.Drawn++
+			home.Points += drawPoints
+			away.Points += drawPoints
+		}
+
+		if result.Round == roundCount && result.Winner != nil {
+			championDecided = true
+		}
+	}
+
+	if format == RoundRobin {
+		slices.SortStableFunc(standings, compareRoundRobin)
+		for index, standing := range standings {
+			rank := index + 1
+			// Share the rank when tied.
+			if index > 0 && compareRoundRobin(standings[index-1], standing) == 0 {
+				rank = *standings[index-1].Rank
+			}
+			standing.Rank = &rank
+		}
+		return standings
+	}
+
+	for _, standing := range standings {
+		round, ok := knockedOutRound[standing.EntryId]
+		if !ok {
+			if championDecided {
+				rank := 1
+				standing.Rank = &rank
+			}
+			continue
+		}
+		standing.Eliminated = true
+		rank := 1<<(roundCount-round) + 1
+		standing.Rank = &rank
+	}
+
+	slices.SortStableFunc(standings, func(a, b *Standing) int {
+		switch {
+		case a.Rank == nil && b.Rank == nil:
+			return b.Won - a.Won
+		case a.Rank == nil:
+			return -1
+		case b.Rank == nil:
+			return 1
+		default:
+			return *a.Rank - *b.Rank
+		}
+	})
+
+	return standings
+}
+
+func compareRoundRobin(a, b *Standing) int {
+	if a.Points != b.Points {
+		return b.Points - a.Points
+	}
+	aDiff, bDiff := a.ScoreFor-a.ScoreAgainst, b.ScoreFor-b.ScoreAgainst
+	if aDiff != bDiff {
+		return bDiff - aDiff
+	}
+	return b.ScoreFor - a.ScoreFor
+}
@@ -0,0 +1,124 @@
+package tournament
+
+import (
+	"fmt"
+	"testing"
+)
+
+// formatStandings writes the standings in order as entry:rank:points, with - for no rank and x for eliminated.
+func formatStandings(standings []*Standing) []string {
+	formatted := make([]string, 0, len(standings))
+	for _, standing := range standings {
+		rank := "-"
+		if standing.Rank != nil {
+			rank = fmt.Sprint(*standing.Rank)
+		}
+		value := fmt.Sprintf("%d:%s:%d", standing.EntryId, rank, standing.Points)
+		if standing.Eliminated {
+			value += "x"
+		}
+		formatted = append(formatted, value)
+	}
+	return formatted
+}
+
+func result(round int, home int64, away int64, homeScore int, awayScore int) Result {
+	r := Result{Round: round, Home: home, Away: away, HomeScore: homeScore, AwayScore: awayScore}
+	switch {
+	case homeScore > awayScore:
+		r.Winner = &r.Home
+	case awayScore > homeScore:
+		r.Winner = &r.Away
+	}
+	return r
+}
+
+func TestRoundRobinStandings(t *testing.T) {
+	tests := []struct {
+		name    string
+		entries []int64
+		results []Result
+		want    []string
+	}{
+		{name: "no result", entries: []int64{1, 2}, want: []string{"1:1:0", "2:1:0"}},
+		{
+			name:    "by points",
+			entries: []int64{1, 2, 3},
+			results: []Result{result(1, 1, 2, 3, 1), result(2, 2, 3, 2, 2), result(3, 3, 1, 1, 0)},
+			want:    []string{"3:1:4", "1:2:3", "2:3:1"},
+		},
+		{
+			name:    "by score difference",
+			entries: []int64{1, 2, 3},
+			results: []Result{result(1, 2, 3, 1, 0), result(2, 1, 3, 3, 0), result(3, 1, 2, 0, 0)},
+			want:    []string{"1:1:4", "2:2:4", "3:3:0"},
+		},
+		{
+			name:    "by score",
+			entries: []int64{1, 2, 3},
+			results: []Result{result(1, 2, 3, 1, 0), result(2, 1, 3, 2, 1), result(3, 1, 2, 0, 0)},
+			want:    []string{"1:1:4", "2:2:4", "3:3:0"},
+		},
+		{
+			name:    "shared rank",
+			entries: []int64{1, 2, 3},
+			results: []Result{result(1, 1, 3, 1, 0), result(2, 2, 3, 1, 0), result(3, 1, 2, 1, 1)},
+			want:    []string{"1:1:4", "2:1:4", "3:3:0"},
+		},
+		{
+			name:    "unknown entry ignored",
+			entries: []int64{1, 2},
+			results: []Result{result(1, 1, 9, 5, 0), result(1, 1, 2, 0, 1)},
+			want:    []string{"2:1:3", "1:2:0"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := formatStandings(Standings(RoundRobin, test.entries, test.results))
+			if fmt.Sprint(got) != fmt.Sprint(test.want) {
+				t.Errorf("got standings %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestRoundRobinStandingTotals(t *testing.T) {
+	standings := Standings(RoundRobin, []int64{1, 2}, []Result{result(1, 1, 2, 3, 1), result(2, 2, 1, 2, 2)})
+
+	want := Standing{EntryId: 1, Played: 2, Won: 1, Drawn: 1, Lost: 0, ScoreFor: 5, ScoreAgainst: 3, Points: 4}
+	got := *standings[0]
+	got.Rank = nil
+	if got != want {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestSingleEliminationStandings(t *testing.T) {
+	entries := []int64{1, 2, 3, 4, 5}
+	// The top 3 seeds have byes in the first round, see TestGenerateSingleElimination.
+	firstRound := []Result{result(1, 4, 5, 2, 1)}
+	semiFinals := append(append([]Result{}, firstRound...), result(2, 1, 4, 3, 0), result(2, 2, 3, 0, 1))
+	final := append(append([]Result{}, semiFinals...), result(3, 1, 3, 2, 1))
+
+	tests := []struct {
+		name    string
+		results []Result
+		want    []string
+	}{
+		{name: "not started", results: nil, want: []string{"1:-:0", "2:-:0", "3:-:0", "4:-:0", "5:-:0"}},
+		// The entries still in the running have no rank, the ones with more wins first.
+		{name: "first round", results: firstRound, want: []string{"4:-:3", "1:-:0", "2:-:0", "3:-:0", "5:5:0x"}},
+		{name: "semi-finals", results: semiFinals, want: []string{"1:-:3", "3:-:3", "2:3:0x", "4:3:3x", "5:5:0x"}},
+		{name: "final", results: final, want: []string{"1:1:6", "3:2:3x", "2:3:0x", "4:3:3x", "5:5:0x"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := formatStandings(Standings(SingleElimination, entries, test.results))
+			if fmt.Sprint(got) != fmt.Sprint(test.want) {
+				t.Errorf("got standings %v, want %v", got, test.want)
+			}
+		})
+	}
+}
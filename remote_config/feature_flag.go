@@ -0,0 +1,167 @@
+package remote_config
+
+import (
+	"fmt"
+	"hash/fnv"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+var (
+	flagNameRX = regexp.MustCompile(`^[a-z0-9_.-]+$`)
+	// The platforms of models.DevicePlatform.
+	flagPlatforms = []string{"IOS", "ANDROID", "WEB"}
+)
+
+type FeatureFlags struct {
+	Flags []FeatureFlag `json:"flags"`
+}
+
+// FeatureFlag is on for the caller when it is enabled and the caller is allowlisted, or when the caller matches all
+// the targeting, i.e. the platforms, the app version range and the rollout percentage. Any targeting left empty matches
+// all the callers. The denylisted callers never get the flag.
+type FeatureFlag struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	// Enabled is the kill switch, the flag is off for everyone when it is false, even for the allowlist.
+	Enabled       bool     `json:"enabled"`
+	Platforms     []string `json:"platforms"`
+	MinAppVersion string   `json:"minAppVersion"`
+	MaxAppVersion string   `json:"maxAppVersion"`
+	// RolloutPercentage is the share of the users the flag is on for, by the bucket of the user, see FlagBucket.
+	// A nil percentage means 100.
+	RolloutPercentage *int    `json:"rolloutPercentage"`
+	AllowedUserIds    []int64 `json:"allowedUserIds"`
+	// DeniedUserIds take the users out of the flag, even when they are allowlisted or in the rollout, e.g. the ones
+	// who hit a problem with it.
+	DeniedUserIds []int64 `json:"deniedUserIds"`
+}
+
+// FlagContext is who the flags are evaluated for. UserId is nil for the unauthenticated caller, and the app version and
+// the platform are empty when the app does not send them.
+type FlagContext struct {
+	UserId     *int64
+	AppVersion string
+	Platform   string
+}
+
+func (flags FeatureFlags) Validate() error {
+	seen := map[string]bool{}
+	for i, flag := range flags.Flags {
+		if !flagNameRX.MatchString(flag.Name) {
+			return fmt.Errorf("flags[%d].name must be lower case letters, digits, _, . or -", i)
+		}
+		if seen[flag.Name] {
+			return fmt.Errorf("flags[%d].name %s is duplicated", i, flag.Name)
+		}
+		seen[flag.Name] = true
+
+		for _, platform := range flag.Platforms {
+			if !slices.Contains(flagPlatforms, platform) {
+				return fmt.Errorf("flags[%d].platforms must be IOS, ANDROID or WEB", i)
+			}
+		}
+		if flag.RolloutPercentage != nil && (*flag.RolloutPercentage < 0 || *flag.RolloutPercentage > 100) {
+			return fmt.Errorf("flags[%d].rolloutPercentage must be between 0 and 100", i)
+		}
+
+		for _, version := range []string{flag.MinAppVersion, flag.MaxAppVersion} {
+			if version != "" && !versionRX.MatchString(version) {
+				return fmt.Errorf("flags[%d] app version %s must be a semantic version, e.g. 1.2.0", i, version)
+			}
+		}
+		if flag.MinAppVersion != "" && flag.MaxAppVersion != "" {
+			order, err := CompareVersions(flag.MinAppVersion, flag.MaxAppVersion)
+			if err != nil {
+				return err
+			}
+			if order > 0 {
+				return fmt.Errorf("flags[%d].minAppVersion must not be after maxAppVersion", i)
+			}
+		}
+	}
+
+	return nil
+}
+
+// IsOn evaluates the flag for the caller.
+func (flag FeatureFlag) IsOn(ctx FlagContext) bool {
+	if !flag.Enabled {
+		return false
+	}
+	if ctx.UserId != nil && slices.Contains(flag.DeniedUserIds, *ctx.UserId) {
+		return false
+	}
+	if ctx.UserId != nil && slices.Contains(flag.AllowedUserIds, *ctx.UserId) {
+		return true
+	}
+
+	if len(flag.Platforms) > 0 && !slices.Contains(flag.Platforms, strings.ToUpper(ctx.Platform)) {
+		return false
+	}
+	if !flag.matchesAppVersion(ctx.AppVersion) {
+		return false
+	}
+
+	if flag.RolloutPercentage == nil || *flag.RolloutPercentage >= 100 {
+		return true
+	}
+	// The unauthenticated caller has no bucket, so it only gets the flags rolled out to everyone.
+	if ctx.UserId == nil {
+		return false
+	}
+	return FlagBucket(flag.Name, *ctx.UserId) < *flag.RolloutPercentage
+}
+
+// matchesAppVersion tells whether the version is within the range. An unknown version only matches when there is no
+// range.
+func (flag FeatureFlag) matchesAppVersion(version string) bool {
+	if flag.MinAppVersion == "" && flag.MaxAppVersion == "" {
+		return true
+	}
+
+	if flag.MinAppVersion != "" {
+		order, err := CompareVersions(version, flag.MinAppVersion)
+		if err != nil || order < 0 {
+			return false
+		}
+	}
+	if flag.MaxAppVersion != "" {
+		order, err := CompareVersions(version, flag.MaxAppVersion)
+		if err != nil || order > 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// FlagBucket is the bucket of the user for the flag, from 0 to 99. It only depends on the flag name and the user, so
+// the user stays in the same bucket, and raising the rollout percentage only adds users. Each flag has its own
+// buckets, so that the same users are not always the first ones to get every flag.
+func FlagBucket(flagName string, userId int64) int {
+	hash := fnv.New32a()
+	hash.Write([]byte(flagName + ":" + strconv.FormatInt(userId, 10)))
+	return int(hash.Sum32() % 100)
+}
+
+// EvaluateFlags returns every flag evaluated for the caller.
+func (config *Config) EvaluateFlags(ctx FlagContext) map[string]bool {
+	evaluated := make(map[string]bool, len(config.Flags.Value.Flags))
+	for _, flag := range config.Flags.Value.Flags {
+		evaluated[flag.Name] = flag.IsOn(ctx)
+	}
+	return evaluated
+}
+
+// IsFlagOn evaluates the flag for the caller, where an unknown flag is off.
+func (config *Config) IsFlagOn(name string, ctx FlagContext) bool {
+	for _, flag := range config.Flags.Value.Flags {
+		if flag.Name == name {
+			return flag.IsOn(ctx)
+		}
+	}
+	return false
+}
@@ -0,0 +1,150 @@
+package remote_config
+
+import (
+	"math"
+	"testing"
+)
+
+func TestFlagBucket(t *testing.T) {
+	const users = 10000
+
+	below := 0
+	for userId := int64(1); userId <= users; userId++ {
+		bucket := FlagBucket("new_chat", userId)
+		if bucket < 0 || bucket > 99 {
+			t.Fatalf("got bucket %d for user %d, want 0 to 99", bucket, userId)
+		}
+		// The same user always lands in the same bucket.
+		if again := FlagBucket("new_chat", userId); again != bucket {
+			t.Fatalf("got bucket %d then %d for user %d", bucket, again, userId)
+		}
+		if bucket < 50 {
+			below++
+		}
+	}
+
+	// The users are spread evenly, so that the percentage is close to the share of the users.
+	if share := float64(below) / users; math.Abs(share-0.5) > 0.03 {
+		t.Errorf("got %.3f of the users in the buckets below 50, want about 0.5", share)
+	}
+
+	// Each flag has its own buckets.
+	same := 0
+	for userId := int64(1); userId <= 100; userId++ {
+		if FlagBucket("new_chat", userId) == FlagBucket("new_map", userId) {
+			same++
+		}
+	}
+	if same > 10 {
+		t.Errorf("got %d of 100 users in the same bucket for both flags", same)
+	}
+}
+
+func TestFeatureFlagIsOn(t *testing.T) {
+	percentage := func(value int) *int {
+		return &value
+	}
+	userId := func(value int64) *int64 {
+		return &value
+	}
+	// The rollouts just above and at the bucket of the user 1 are on and off for the user.
+	bucket := FlagBucket("new_chat", 1)
+
+	tests := []struct {
+		name string
+		flag FeatureFlag
+		ctx  FlagContext
+		want bool
+	}{
+		{name: "on for everyone", flag: FeatureFlag{Enabled: true}, ctx: FlagContext{}, want: true},
+		{name: "disabled", flag: FeatureFlag{Enabled: false}, ctx: FlagContext{UserId: userId(1)}, want: false},
+		{name: "disabled for the allowlist", flag: FeatureFlag{Enabled: false, AllowedUserIds: []int64{1}}, ctx: FlagContext{UserId: userId(1)}, want: false},
+		{name: "rollout 0", flag: FeatureFlag{Enabled: true, RolloutPercentage: percentage(0)}, ctx: FlagContext{UserId: userId(1)}, want: false},
+		{name: "rollout 100", flag: FeatureFlag{Enabled: true, RolloutPercentage: percentage(100)}, ctx: FlagContext{UserId: userId(1)}, want: true},
+		{name: "rollout 100 unauthenticated", flag: FeatureFlag{Enabled: true, RolloutPercentage: percentage(100)}, ctx: FlagContext{}, want: true},
+		{name: "within the rollout", flag: FeatureFlag{Name: "new_chat", Enabled: true, RolloutPercentage: percentage(bucket + 1)}, ctx: FlagContext{UserId: userId(1)}, want: true},
+		{name: "outside the rollout", flag: FeatureFlag{Name: "new_chat", Enabled: true, RolloutPercentage: percentage(bucket)}, ctx: FlagContext{UserId: userId(1)}, want: false},
+		{name: "partial rollout unauthenticated", flag: FeatureFlag{Enabled: true, RolloutPercentage: percentage(99)}, ctx: FlagContext{}, want: false},
+		{name: "allowlist over rollout 0", flag: FeatureFlag{Enabled: true, RolloutPercentage: percentage(0), AllowedUserIds: []int64{1}}, ctx: FlagContext{UserId: userId(1)}, want: true},
+		{name: "allowlist over platform", flag: FeatureFlag{Enabled: true, Platforms: []string{"IOS"}, AllowedUserIds: []int64{1}}, ctx: FlagContext{UserId: userId(1), Platform: "ANDROID"}, want: true},
+		{name: "denylist over rollout 100", flag: FeatureFlag{Enabled: true, RolloutPercentage: percentage(100), DeniedUserIds: []int64{1}}, ctx: FlagContext{UserId: userId(1)}, want: false},
+		{name: "denylist over allowlist", flag: FeatureFlag{Enabled: true, AllowedUserIds: []int64{1}, DeniedUserIds: []int64{1}}, ctx: FlagContext{UserId: userId(1)}, want: false},
+		{name: "denylist of other users", flag: FeatureFlag{Enabled: true, DeniedUserIds: []int64{2}}, ctx: FlagContext{UserId: userId(1)}, want: true},
+		{name: "platform", flag: FeatureFlag{Enabled: true, Platforms: []string{"IOS"}}, ctx: FlagContext{Platform: "ios"}, want: true},
+		{name: "other platform", flag: FeatureFlag{Enabled: true, Platforms: []string{"IOS"}}, ctx: FlagContext{Platform: "ANDROID"}, want: false},
+		{name: "unknown platform", flag: FeatureFlag{Enabled: true, Platforms: []string{"IOS"}}, ctx: FlagContext{}, want: false},
+		{name: "within the versions", flag: FeatureFlag{Enabled: true, MinAppVersion: "1.2.0", MaxAppVersion: "1.10.0"}, ctx: FlagContext{AppVersion: "1.9.0"}, want: true},
+		{name: "before the min version", flag: FeatureFlag{Enabled: true, MinAppVersion: "1.2.0"}, ctx: FlagContext{AppVersion: "1.2.0-beta.1"}, want: false},
+		{name: "after the max version", flag: FeatureFlag{Enabled: true, MaxAppVersion: "1.10.0"}, ctx: FlagContext{AppVersion: "1.10.1"}, want: false},
+		{name: "unknown version", flag: FeatureFlag{Enabled: true, MinAppVersion: "1.2.0"}, ctx: FlagContext{}, want: false},
+		{name: "unparsable version", flag: FeatureFlag{Enabled: true, MinAppVersion: "1.2.0"}, ctx: FlagContext{AppVersion: "latest"}, want: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := test.flag.IsOn(test.ctx); got != test.want {
+				t.Errorf("got %t, want %t", got, test.want)
+			}
+		})
+	}
+}
+
+func TestFeatureFlagRollout(t *testing.T) {
+	percentage := func(value int) *int {
+		return &value
+	}
+
+	// Raising the percentage only adds users, and 0 and 100 are off and on for every user.
+	previous := map[int64]bool{}
+	for _, rollout := range []int{0, 10, 50, 100} {
+		flag := FeatureFlag{Name: "new_chat", Enabled: true, RolloutPercentage: percentage(rollout)}
+		on := 0
+		for userId := int64(1); userId <= 1000; userId++ {
+			isOn := flag.IsOn(FlagContext{UserId: &userId})
+			if previous[userId] && !isOn {
+				t.Fatalf("got user %d out of the flag at %d%%", userId, rollout)
+			}
+			previous[userId] = isOn
+			if isOn {
+				on++
+			}
+		}
+
+		switch {
+		case rollout == 0 && on != 0:
+			t.Errorf("got %d users on at 0%%, want none", on)
+		case rollout == 100 && on != 1000:
+			t.Errorf("got %d users on at 100%%, want all", on)
+		}
+	}
+}
+
+func TestFeatureFlagsValidate(t *testing.T) {
+	percentage := func(value int) *int {
+		return &value
+	}
+
+	tests := []struct {
+		name    string
+		flags   []FeatureFlag
+		wantErr bool
+	}{
+		{name: "valid", flags: []FeatureFlag{{Name: "new_chat", Platforms: []string{"IOS"}, MinAppVersion: "1.2.0", MaxAppVersion: "1.10.0", RolloutPercentage: percentage(50)}}},
+		{name: "invalid name", flags: []FeatureFlag{{Name: "New Chat"}}, wantErr: true},
+		{name: "duplicated name", flags: []FeatureFlag{{Name: "new_chat"}, {Name: "new_chat"}}, wantErr: true},
+		{name: "invalid platform", flags: []FeatureFlag{{Name: "new_chat", Platforms: []string{"ios"}}}, wantErr: true},
+		{name: "percentage over 100", flags: []FeatureFlag{{Name: "new_chat", RolloutPercentage: percentage(101)}}, wantErr: true},
+		{name: "negative percentage", flags: []FeatureFlag{{Name: "new_chat", RolloutPercentage: percentage(-1)}}, wantErr: true},
+		{name: "invalid version", flags: []FeatureFlag{{Name: "new_chat", MinAppVersion: "1.x"}}, wantErr: true},
+		{name: "min version after max version", flags: []FeatureFlag{{Name: "new_chat", MinAppVersion: "1.10.0", MaxAppVersion: "1.9.0"}}, wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := FeatureFlags{Flags: test.flags}.Validate()
+			if (err != nil) != test.wantErr {
+				t.Errorf("got error %v, want error %t", err, test.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,163 @@
+package remote_config
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// The config files in the data dir, see Store.
+const (
+	MainMessageFile         = "main_message_config.json"
+	SupportedMinVersionFile = "supported_min_version.json"
+	HostingConfigFile       = "hosting_config.json"
+	FeatureFlagsFile        = "feature_flags.json"
+)
+
+type MainMessage struct {
+	Title      string `json:"title"`
+	Subtitle   string `json:"subtitle"`
+	ButtonText string `json:"buttonText"`
+}
+
+func (message MainMessage) Validate() error {
+	if message.Title == "" {
+		return errors.New("title must not be empty")
+	}
+	if message.ButtonText == "" {
+		return errors.New("buttonText must not be empty")
+	}
+	return nil
+}
+
+// SupportedMinVersion is the versions of the app supported, per platform, i.e. IOS, ANDROID or WEB. The versions of
+// the platforms not given fall back to the ones at the top level.
+type SupportedMinVersion struct {
+	// MinVersion is the oldest app version allowed, the older apps are asked to update. There is no min version when
+	// it is empty.
+	MinVersion string `json:"minVersion"`
+	// RecommendedVersion is the version the older apps are prompted to update to, without being blocked.
+	RecommendedVersion string                     `json:"recommendedVersion"`
+	Platforms          map[string]PlatformVersion `json:"platforms"`
+}
+
+type PlatformVersion struct {
+	MinVersion         string `json:"minVersion"`
+	RecommendedVersion string `json:"recommendedVersion"`
+	// Maintenance blocks the apps of the platform with the message, e.g. during a migration of the backend.
+	Maintenance Maintenance `json:"maintenance"`
+}
+
+type Maintenance struct {
+	Enabled bool   `json:"enabled"`
+	Message string `json:"message"`
+}
+
+// ForPlatform returns the versions of the platform, with the versions not given falling back to the top level. The
+// maintenance is only for the platforms given.
+func (versions SupportedMinVersion) ForPlatform(platform string) PlatformVersion {
+	resolved := versions.Platforms[strings.ToUpper(platform)]
+	if resolved.MinVersion == "" {
+		resolved.MinVersion = versions.MinVersion
+	}
+	if resolved.RecommendedVersion == "" {
+		resolved.RecommendedVersion = versions.RecommendedVersion
+	}
+	return resolved
+}
+
+func (versions SupportedMinVersion) Validate() error {
+	err := validateVersionRange("", versions.MinVersion, versions.RecommendedVersion)
+	if err != nil {
+		return err
+	}
+
+	for platform, version := range versions.Platforms {
+		if !slices.Contains(flagPlatforms, platform) {
+			return fmt.Errorf("platforms must be IOS, ANDROID or WEB, not %s", platform)
+		}
+
+		resolved := versions.ForPlatform(platform)
+		err = validateVersionRange(fmt.Sprintf("platforms.%s.", platform), resolved.MinVersion, resolved.RecommendedVersion)
+		if err != nil {
+			return err
+		}
+		if version.Maintenance.Enabled && version.Maintenance.Message == "" {
+			return fmt.Errorf("platforms.%s.maintenance.message must not be empty", platform)
+		}
+	}
+
+	return nil
+}
+
+// validateVersionRange checks the versions, where the recommended version must not be older than the min version.
+func validateVersionRange(prefix string, minVersion string, recommendedVersion string) error {
+	for _, version := range []string{minVersion, recommendedVersion} {
+		if version != "" && !versionRX.MatchString(version) {
+			return fmt.Errorf("%sversion %s must be a semantic version, e.g. 1.2.0", prefix, version)
+		}
+	}
+
+	if minVersion != "" && recommendedVersion != "" {
+		order, err := CompareVersions(minVersion, recommendedVersion)
+		if err != nil {
+			return err
+		}
+		if order > 0 {
+			return fmt.Errorf("%srecommendedVersion must not be older than minVersion", prefix)
+		}
+	}
+
+	return nil
+}
+
+type HostingTier string
+
+// The tiers of the hosting quota, which the user is assigned to by the admins, except the club admin tier, which the
+// owners and admins of any club get when it is a larger quota.
+const (
+	NewUserTier     = HostingTier("NEW")
+	VerifiedTier    = HostingTier("VERIFIED")
+	TrustedHostTier = HostingTier("TRUSTED_HOST")
+	ClubAdminTier   = HostingTier("CLUB_ADMIN")
+)
+
+var HostingTiers = []HostingTier{NewUserTier, VerifiedTier, TrustedHostTier, ClubAdminTier}
+
+func (tier HostingTier) IsValid() bool {
+	return slices.Contains(HostingTiers, tier)
+}
+
+// HostingConfig is the hosting quota of every tier.
+type HostingConfig struct {
+	Tiers map[HostingTier]HostingQuota `json:"tiers"`
+}
+
+// HostingQuota limits how many events a user can host within any rolling window.
+type HostingQuota struct {
+	MaxCount    int `json:"maxCount"`
+	WindowInMin int `json:"windowInMin"`
+}
+
+func (config HostingConfig) Validate() error {
+	for tier := range config.Tiers {
+		if !tier.IsValid() {
+			return fmt.Errorf("tiers must be NEW, VERIFIED, TRUSTED_HOST or CLUB_ADMIN, not %s", tier)
+		}
+	}
+
+	for _, tier := range HostingTiers {
+		quota, ok := config.Tiers[tier]
+		if !ok {
+			return fmt.Errorf("tiers.%s must be given", tier)
+		}
+		if quota.MaxCount <= 0 {
+			return fmt.Errorf("tiers.%s.maxCount must be positive", tier)
+		}
+		if quota.WindowInMin <= 0 {
+			return fmt.Errorf("tiers.%s.windowInMin must be positive", tier)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,208 @@
+package remote_config
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+var configFiles = []string{
+	MainMessageFile,
+	SupportedMinVersionFile,
+	HostingConfigFile,
+	FeatureFlagsFile,
+}
+
+// Section is a config file, parsed and validated, with the ETag of the file content, so that the clients can cache
+// the responses made from it.
+type Section[T any] struct {
+	Value T
+	ETag  string
+}
+
+// Config is a snapshot of all the config files. It is never changed once loaded, a reload swaps in a new one instead.
+type Config struct {
+	MainMessage Section[MainMessage]
+	MinVersion  Section[SupportedMinVersion]
+	Hosting     Section[HostingConfig]
+	Flags       Section[FeatureFlags]
+}
+
+// Store keeps the config loaded from the data dir, instead of reading the files on every request. It is reloaded when
+// any of the files change, or on SIGHUP. A reload with any invalid file is rejected as a whole, and the current config
+// is kept.
+type Store struct {
+	dir     string
+	logger  *slog.Logger
+	current atomic.Pointer[Config]
+	// Guards the reloads, so that the file change and the SIGHUP never reload at the same time.
+	mu       sync.Mutex
+	modTimes map[string]time.Time
+	cancel   context.CancelFunc
+	wg       sync.WaitGroup
+}
+
+// NewStore loads the config, which fails when any of the files is invalid, so that the server never starts with a bad
+// config.
+func NewStore(dir string, logger *slog.Logger) (*Store, error) {
+	store := &Store{
+		dir:    dir,
+		logger: logger,
+	}
+
+	err := store.Reload()
+	if err != nil {
+		return nil, err
+	}
+
+	return store, nil
+}
+
+// Current returns the latest config. Keep the returned config for the whole request, so that the request sees the
+// same config even when it is reloaded meanwhile.
+func (s *Store) Current() *Config {
+	return s.current.Load()
+}
+
+func (s *Store) Reload() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Kept even when the reload is rejected, so that the bad files are only reloaded again after they change.
+	s.modTimes = s.readModTimes()
+	config := &Config{}
+
+	err := loadSection(s.dir, MainMessageFile, false, &config.MainMessage)
+	if err != nil {
+		return err
+	}
+	err = loadSection(s.dir, SupportedMinVersionFile, true, &config.MinVersion)
+	if err != nil {
+		return err
+	}
+	err = loadSection(s.dir, HostingConfigFile, false, &config.Hosting)
+	if err != nil {
+		return err
+	}
+	err = loadSection(s.dir, FeatureFlagsFile, true, &config.Flags)
+	if err != nil {
+		return err
+	}
+
+	s.current.Store(config)
+	return nil
+}
+
+// Watch reloads the config when the files change, which is checked every interval, or on SIGHUP. It must be stopped
+// by Stop.
+func (s *Store) Watch(interval time.Duration) {
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		defer signal.Stop(hangup)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-hangup:
+				s.reload("SIGHUP")
+			case <-ticker.C:
+				if s.changed() {
+					s.reload("file change")
+				}
+			}
+		}
+	}()
+}
+
+func (s *Store) Stop() {
+	if s.cancel == nil {
+		return
+	}
+	s.cancel()
+	s.wg.Wait()
+}
+
+func (s *Store) reload(reason string) {
+	err := s.Reload()
+	if err != nil {
+		s.logger.Error("rejected remote config reload, keeping the current config", "reason", reason, "error", err.Error())
+		return
+	}
+	s.logger.Info("reloaded remote config", "reason", reason)
+}
+
+func (s *Store) changed() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	modTimes := s.readModTimes()
+	if len(modTimes) != len(s.modTimes) {
+		return true
+	}
+	for file, modTime := range modTimes {
+		if !s.modTimes[file].Equal(modTime) {
+			return true
+		}
+	}
+	return false
+}
+
+// readModTimes returns the modification time of the existing files.
+func (s *Store) readModTimes() map[string]time.Time {
+	modTimes := map[string]time.Time{}
+	for _, file := range configFiles {
+		info, err := os.Stat(filepath.Join(s.dir, file))
+		if err == nil {
+			modTimes[file] = info.ModTime()
+		}
+	}
+	return modTimes
+}
+
+// loadSection parses and validates the file, where an optional file which does not exist is left as the zero value.
+func loadSection[T interface{ Validate() error }](dir string, file string, optional bool, section *Section[T]) error {
+	content, err := os.ReadFile(filepath.Join(dir, file))
+	if err != nil {
+		if optional && errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+
+	err = json.Unmarshal(content, &section.Value)
+	if err != nil {
+		return fmt.Errorf("invalid %s: %w", file, err)
+	}
+
+	err = section.Value.Validate()
+	if err != nil {
+		return fmt.Errorf("invalid %s: %w", file, err)
+	}
+
+	sum := sha256.Sum256(content)
+	section.ETag = fmt.Sprintf(`"%s"`, hex.EncodeToString(sum[:8]))
+	return nil
+}
@@ -0,0 +1,108 @@
+package remote_config
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// versionRX matches the semantic versions, where the minor and the patch can be left out, e.g. 1.2 is 1.2.0.
+var versionRX = regexp.MustCompile(`^v?(\d+)(?:\.(\d+))?(?:\.(\d+))?(?:-([0-9A-Za-z-]+(?:\.[0-9A-Za-z-]+)*))?(?:\+[0-9A-Za-z-]+(?:\.[0-9A-Za-z-]+)*)?$`)
+
+type version struct {
+	numbers    [3]int
+	preRelease []string
+}
+
+// CompareVersions compares the app versions by the semantic versioning precedence, e.g. 1.10.0 is newer than 1.9.2,
+// 2.0.0-beta.1 is older than 2.0.0, and the build metadata is ignored. It returns -1, 0 or 1 when a is older than, the
+// same as or newer than b.
+func CompareVersions(a string, b string) (int, error) {
+	aVersion, err := parseVersion(a)
+	if err != nil {
+		return 0, err
+	}
+	bVersion, err := parseVersion(b)
+	if err != nil {
+		return 0, err
+	}
+
+	for i := range aVersion.numbers {
+		if order := compareInt(aVersion.numbers[i], bVersion.numbers[i]); order != 0 {
+			return order, nil
+		}
+	}
+
+	return comparePreRelease(aVersion.preRelease, bVersion.preRelease), nil
+}
+
+func parseVersion(value string) (*version, error) {
+	matches := versionRX.FindStringSubmatch(strings.TrimSpace(value))
+	if matches == nil {
+		return nil, fmt.Errorf("invalid version %q", value)
+	}
+
+	parsed := &version{}
+	for i := range parsed.numbers {
+		if matches[i+1] == "" {
+			continue
+		}
+		number, err := strconv.Atoi(matches[i+1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid version %q", value)
+		}
+		parsed.numbers[i] = number
+	}
+	if matches[4] != "" {
+		parsed.preRelease = strings.Split(matches[4], ".")
+	}
+
+	return parsed, nil
+}
+
+// comparePreRelease follows the semantic versioning, where the release is newer than any of its pre-releases, and the
+// numeric identifiers are compared as numbers and are older than the alphanumeric ones.
+func comparePreRelease(a []string, b []string) int {
+	switch {
+	case len(a) == 0 && len(b) == 0:
+		return 0
+	case len(a) == 0:
+		return 1
+	case len(b) == 0:
+		return -1
+	}
+
+	for i := 0; i < min(len(a), len(b)); i++ {
+		aNumber, aErr := strconv.Atoi(a[i])
+		bNumber, bErr := strconv.Atoi(b[i])
+
+		var order int
+		switch {
+		case aErr == nil && bErr == nil:
+			order = compareInt(aNumber, bNumber)
+		case aErr == nil:
+			order = -1
+		case bErr == nil:
+			order = 1
+		default:
+			order = strings.Compare(a[i], b[i])
+		}
+		if order != 0 {
+			return order
+		}
+	}
+
+	return compareInt(len(a), len(b))
+}
+
+func compareInt(a int, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
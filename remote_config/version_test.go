@@ -0,0 +1,67 @@
+package remote_config
+
+import "testing"
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		name string
+		a    string
+		b    string
+		want int
+	}{
+		{name: "same", a: "1.2.3", b: "1.2.3", want: 0},
+		{name: "multi-digit minor", a: "1.10.0", b: "1.9.2", want: 1},
+		{name: "multi-digit patch", a: "1.2.9", b: "1.2.10", want: -1},
+		{name: "multi-digit major", a: "10.0.0", b: "9.99.99", want: 1},
+		{name: "left out parts", a: "1.2", b: "1.2.0", want: 0},
+		{name: "major only", a: "2", b: "1.9.9", want: 1},
+		{name: "v prefix", a: "v1.2.3", b: "1.2.3", want: 0},
+		{name: "spaces", a: " 1.2.3 ", b: "1.2.3", want: 0},
+		{name: "pre-release is older than release", a: "2.0.0-beta.1", b: "2.0.0", want: -1},
+		{name: "release is newer than pre-release", a: "2.0.0", b: "2.0.0-rc.1", want: 1},
+		{name: "pre-release is newer than older release", a: "2.0.0-alpha", b: "1.9.9", want: 1},
+		{name: "numeric pre-releases", a: "1.0.0-beta.2", b: "1.0.0-beta.11", want: -1},
+		{name: "numeric is older than alphanumeric", a: "1.0.0-alpha.1", b: "1.0.0-alpha.beta", want: -1},
+		{name: "alphanumeric pre-releases", a: "1.0.0-rc.1", b: "1.0.0-beta.1", want: 1},
+		{name: "fewer pre-release identifiers", a: "1.0.0-alpha", b: "1.0.0-alpha.1", want: -1},
+		{name: "build metadata is ignored", a: "1.2.3+456", b: "1.2.3+789", want: 0},
+		{name: "build metadata with pre-release", a: "1.2.3-beta+exp.sha.5114f85", b: "1.2.3-beta", want: 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := CompareVersions(test.a, test.b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != test.want {
+				t.Errorf("got %d comparing %s to %s, want %d", got, test.a, test.b, test.want)
+			}
+		})
+	}
+}
+
+func TestCompareVersionsInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		a    string
+		b    string
+	}{
+		{name: "empty", a: "", b: "1.0.0"},
+		{name: "text", a: "latest", b: "1.0.0"},
+		{name: "too many parts", a: "1.2.3.4", b: "1.0.0"},
+		{name: "empty part", a: "1..3", b: "1.0.0"},
+		{name: "negative", a: "-1.0.0", b: "1.0.0"},
+		{name: "empty pre-release", a: "1.0.0-", b: "1.0.0"},
+		{name: "too large", a: "99999999999999999999.0.0", b: "1.0.0"},
+		{name: "invalid b", a: "1.0.0", b: "1.x"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got, err := CompareVersions(test.a, test.b); err == nil {
+				t.Errorf("got %d comparing %q to %q, want an error", got, test.a, test.b)
+			}
+		})
+	}
+}
@@ -22,6 +22,8 @@ type Filter struct {
 	PageSize     int                     `json:"pageSize"`
 	EventTypes   []string                `json:"eventTypes"`
 	FromLocation *UserFromLocationFilter `json:"fromLocation"`
+	// SuitsMyLevel only keeps the events without a skill range, or with the skill level of the user in the range.
+	SuitsMyLevel bool `json:"suitsMyLevel"`
 }
 
 func (filter Filter) IsCursorEmpty() bool {
@@ -0,0 +1,44 @@
+package tools
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"strconv"
+	"strings"
+)
+
+const DIGEST_UNSUBSCRIBE_SCOPE = "Digest unsubscribe scope"
+
+// GenerateSignedToken signs the user id for the scope, e.g. for the links in the emails which work without logging in.
+// Unlike the jwt, it never expires, and cannot be used to authenticate.
+func GenerateSignedToken(userId int64, scope string) string {
+	payload := strconv.FormatInt(userId, 10)
+	return payload + "." + base64.RawURLEncoding.EncodeToString(signToken(payload, scope))
+}
+
+// ParseSignedToken returns the user id of the token signed for the scope.
+func ParseSignedToken(token string, scope string) (*int64, bool) {
+	payload, signature, found := strings.Cut(token, ".")
+	if !found {
+		return nil, false
+	}
+
+	decoded, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil || !hmac.Equal(decoded, signToken(payload, scope)) {
+		return nil, false
+	}
+
+	userId, err := strconv.ParseInt(payload, 10, 64)
+	if err != nil {
+		return nil, false
+	}
+
+	return &userId, true
+}
+
+func signToken(payload string, scope string) []byte {
+	mac := hmac.New(sha256.New, []byte(JWT_SECRET_KEY))
+	mac.Write([]byte(scope + ":" + payload))
+	return mac.Sum(nil)
+}